@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+var statusReason string
+
+var freezeDebitsOnly bool
+
+var freezeCmd = &cobra.Command{
+	Use:   "freeze <id>",
+	Short: "Freezing an account. Both debits and credits are rejected unless --debits-only is set",
+	Args:  cobra.ExactArgs(1),
+	Run: runStatusChange(func(c *client.AdminServiceClient, id int32) error {
+		return c.Freeze(context.Background(), id, freezeDebitsOnly, statusReason)
+	}),
+}
+
+var unfreezeCmd = &cobra.Command{
+	Use:   "unfreeze <id>",
+	Short: "Making a frozen account active again",
+	Args:  cobra.ExactArgs(1),
+	Run: runStatusChange(func(c *client.AdminServiceClient, id int32) error {
+		return c.Unfreeze(context.Background(), id, statusReason)
+	}),
+}
+
+var closeCmd = &cobra.Command{
+	Use:   "close <id>",
+	Short: "Closing an account with a zero balance permanently",
+	Args:  cobra.ExactArgs(1),
+	Run: runStatusChange(func(c *client.AdminServiceClient, id int32) error {
+		return c.Close(context.Background(), id, statusReason)
+	}),
+}
+
+//runStatusChange возвращает обработчик команды изменения состояния счёта, идентификатор которого передан первым
+//аргументом.
+func runStatusChange(change func(c *client.AdminServiceClient, id int32) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			failUsage("invalid account id %q\n", args[0])
+			return
+		}
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		if err = change(client.NewAdminServiceClient(conn), int32(id)); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func init() {
+	freezeCmd.Flags().BoolVar(&freezeDebitsOnly, "debits-only", false, "reject only debits")
+	for _, cmd := range []*cobra.Command{freezeCmd, unfreezeCmd, closeCmd} {
+		cmd.Flags().StringVar(&statusReason, "reason", "", "reason recorded in the audit trail")
+		rootCmd.AddCommand(cmd)
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	addCurrency      string
+	transferCurrency string
+	transferFXRate   string
+)
+
+//balanceRecord - баланс счёта в выводе команды get
+type balanceRecord struct {
+	BalanceId int32  `json:"balance_id"`
+	Amount    int64  `json:"amount"`
+	Available int64  `json:"available"`
+	Currency  string `json:"currency"`
+	Version   int64  `json:"version"`
+}
+
+//transferRecord - результат перевода в выводе команды transfer
+type transferRecord struct {
+	From     int32  `json:"from"`
+	To       int32  `json:"to"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+	Credited int64  `json:"credited"`
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <id...>",
+	Short: "Printing the balances of the accounts. A nonexistent account has a zero balance",
+	Args:  cobra.MinimumNArgs(1),
+	Run: runBalances(func(c *client.BalancesClient, cmd *cobra.Command, args []string) error {
+		ids := make([]int32, 0, len(args))
+		for _, arg := range args {
+			id, err := strconv.ParseInt(arg, 10, 32)
+			if err != nil {
+				failUsage("invalid account id %q\n", arg)
+				return nil
+			}
+			ids = append(ids, int32(id))
+		}
+
+		balances, err := c.GetBalances(context.Background(), ids)
+		if err != nil {
+			return err
+		}
+
+		records := make([]balanceRecord, 0, len(balances))
+		rows := make([][]string, 0, len(balances))
+		for _, b := range balances {
+			records = append(records, balanceRecord{b.BalanceId, b.Amount, b.Available, b.Currency, b.Version})
+			rows = append(rows, []string{
+				strconv.Itoa(int(b.BalanceId)),
+				strconv.FormatInt(b.Amount, 10),
+				strconv.FormatInt(b.Available, 10),
+				b.Currency,
+				strconv.FormatInt(b.Version, 10),
+			})
+		}
+
+		return printRecords(os.Stdout, records, []string{"balance_id", "amount", "available", "currency", "version"}, rows)
+	}),
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <id> <amount>",
+	Short: "Adding the amount in minor currency units to the balance of the account. A negative amount is debited",
+	Args:  cobra.ExactArgs(2),
+	Run: runBalances(func(c *client.BalancesClient, cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			failUsage("invalid account id %q\n", args[0])
+			return nil
+		}
+		amount, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			failUsage("invalid amount %q\n", args[1])
+			return nil
+		}
+
+		return c.AddAmount(context.Background(), int32(id), amount, addCurrency)
+	}),
+}
+
+var transferCmd = &cobra.Command{
+	Use:   "transfer <from-id> <to-id> <amount>",
+	Short: "Transferring the amount in minor units of the source account currency between the accounts",
+	Args:  cobra.ExactArgs(3),
+	Run: runBalances(func(c *client.BalancesClient, cmd *cobra.Command, args []string) error {
+		var ids [2]int32
+		for i, arg := range args[:2] {
+			id, err := strconv.ParseInt(arg, 10, 32)
+			if err != nil {
+				failUsage("invalid account id %q\n", arg)
+				return nil
+			}
+			ids[i] = int32(id)
+		}
+		amount, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			failUsage("invalid amount %q\n", args[2])
+			return nil
+		}
+
+		credited, err := c.Transfer(context.Background(), &api.TransferRequest{
+			FromBalanceId: ids[0],
+			ToBalanceId:   ids[1],
+			Amount:        amount,
+			Currency:      transferCurrency,
+			FxRate:        transferFXRate,
+		})
+		if err != nil {
+			return err
+		}
+
+		record := transferRecord{ids[0], ids[1], amount, transferCurrency, credited}
+		row := []string{args[0], args[1], args[2], transferCurrency, strconv.FormatInt(credited, 10)}
+
+		return printRecords(os.Stdout, record, []string{"from", "to", "amount", "currency", "credited"}, [][]string{row})
+	}),
+}
+
+//runBalances возвращает обработчик команды, выполняющей запросы к сервису счетов.
+func runBalances(run func(c *client.BalancesClient, cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(); err != nil {
+			failUsage("%s\n", err)
+			return
+		}
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		if err = run(client.NewBalancesClient(conn), cmd, args); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func init() {
+	addOutputFlag(getCmd)
+	addOutputFlag(transferCmd)
+
+	addCmd.Flags().StringVar(&addCurrency, "currency", "", "ISO 4217 currency code of the amount. If omitted, the"+
+		" account currency is used")
+	transferCmd.Flags().StringVar(&transferCurrency, "currency", "", "ISO 4217 currency code of the amount. It must"+
+		" match the source account currency")
+	transferCmd.Flags().StringVar(&transferFXRate, "fx-rate", "", "target currency units per one source currency"+
+		" unit for accounts in different currencies")
+
+	rootCmd.AddCommand(getCmd, addCmd, transferCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+//Форматы вывода результатов команд
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+var outputFormat string
+
+//addOutputFlag добавляет команде флаг формата вывода.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", outputTable, "output format: table, json or csv")
+}
+
+func checkOutputFormat() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	}
+
+	return fmt.Errorf("output format must be %s, %s or %s, got %q", outputTable, outputJSON, outputCSV, outputFormat)
+}
+
+//printRecords выводит записи в формате outputFormat. Для таблицы и CSV используются заголовок header и строки
+//rows, а в JSON выводится records.
+func printRecords(w io.Writer, records interface{}, header []string, rows [][]string) error {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(records)
+	case outputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+
+		return cw.Error()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
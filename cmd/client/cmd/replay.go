@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/vps2/accounttesttask/internal/client"
+	"github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/traffic"
+
+	"github.com/spf13/cobra"
+)
+
+//mismatchRecord - отличие результата воспроизведённого вызова от записанного в выводе команды replay
+type mismatchRecord struct {
+	Index        int    `json:"index"`
+	Method       string `json:"method"`
+	RecordedCode string `json:"recorded_code"`
+	Code         string `json:"code"`
+	Diff         string `json:"diff"`
+}
+
+var replayCmd = &cobra.Command{
+	Use: "replay <file>",
+	Short: "Replaying the calls recorded by the server with the record_file setting and printing the calls whose" +
+		" results differ from the recorded ones",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(); err != nil {
+			failUsage("%s\n", err)
+			return
+		}
+		speed, _ := cmd.Flags().GetFloat64("speed")
+		if speed < 0 {
+			failUsage("speed must not be negative, got %v\n", speed)
+			return
+		}
+		ignored, _ := cmd.Flags().GetStringSlice("ignore")
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			fail(err)
+			return
+		}
+		records, err := traffic.Read(file)
+		file.Close()
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		doneCh := make(chan os.Signal, 1)
+		signal.Notify(doneCh, os.Interrupt)
+		go func() {
+			select {
+			case <-doneCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		results, err := client.NewReplayer(conn).
+			WithSpeed(speed).
+			WithIgnoredFields(ignored...).
+			Replay(ctx, records)
+		if err != nil && results == nil {
+			fail(err)
+			return
+		}
+		if err != nil {
+			log.Warning("replay interrupted", log.F("replayed", len(results)), log.F("recorded", len(records)))
+		}
+
+		mismatches := make([]mismatchRecord, 0)
+		rows := make([][]string, 0)
+		for _, r := range results {
+			if r.Diff == "" {
+				continue
+			}
+			mismatches = append(mismatches, mismatchRecord{r.Index, r.Method, r.RecordedCode, r.Code, r.Diff})
+			rows = append(rows, []string{strconv.Itoa(r.Index), r.Method, r.RecordedCode, r.Code, r.Diff})
+		}
+
+		if err := printRecords(os.Stdout, mismatches, []string{"index", "method", "recorded_code", "code", "diff"}, rows); err != nil {
+			fail(err)
+			return
+		}
+
+		log.Info("replay completed", log.F("calls", len(results)), log.F("mismatches", len(mismatches)))
+		if len(mismatches) > 0 {
+			exitCode = exitMismatch
+		}
+	},
+}
+
+func init() {
+	addOutputFlag(replayCmd)
+
+	replayCmd.Flags().Float64("speed", 1, "replay speed relative to the recording, e.g. 2 for twice as fast. Zero"+
+		" replays the calls one by one without pauses")
+	replayCmd.Flags().StringSlice("ignore", nil, "names of response fields excluded from the comparison, e.g."+
+		" version")
+
+	rootCmd.AddCommand(replayCmd)
+}
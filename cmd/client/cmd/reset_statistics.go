@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/vps2/accounttesttask/internal/client"
-	"github.com/vps2/accounttesttask/pkg/log"
 
 	"github.com/spf13/cobra"
 )
@@ -14,15 +13,16 @@ var resetStatCmd = &cobra.Command{
 	Short: "Resetting the statistics of transactions on the accounts",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := readConfig()
+		_, conn, err := connect()
 		if err != nil {
-			log.Error(err.Error())
+			fail(err)
 			return
 		}
+		defer conn.Close()
 
-		client := client.NewStatisticsServiceClient(cfg.Client.Addr)
+		client := client.NewStatisticsServiceClient(conn)
 		if err = client.ResetStatistics(context.Background()); err != nil {
-			log.Error(err.Error())
+			fail(err)
 		}
 	},
 }
@@ -1,46 +1,160 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/vps2/accounttesttask/internal/client"
 	"github.com/vps2/accounttesttask/internal/client/config"
 	"github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/tracing"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+//Коды завершения программы. Если запрос к серверу завершился ошибкой, то кодом завершения является
+//exitStatusBase плюс код состояния gRPC, например 69 для NOT_FOUND.
+const (
+	exitError      = 1
+	exitUsage      = 2
+	exitMismatch   = 3
+	exitStatusBase = 64
+)
+
+//exitCode - код завершения программы, установленный командой
+var exitCode int
+
 var rootCmd = &cobra.Command{
 	Use: "client",
+	Long: "Client of the accounts server.\n\nSettings are read from the config file, the profile selected with" +
+		" --profile and the " + config.EnvPrefix + "* environment variables named after the setting paths in the" +
+		" config file, e.g. " + config.EnvPrefix + "CONNECTION_TIMEOUT for connection.timeout.\n\nExit codes: 0 on" +
+		" success, 1 on a local error, 2 on invalid arguments, 3 when update-accounts finds a consistency violation," +
+		" replay finds results different from the recorded ones or run-scenario has failed scenarios and 64 plus the" +
+		" gRPC status code when a request fails, e.g. 69 for NOT_FOUND.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initLog(cmd); err != nil {
+			return err
+		}
+
+		return initTracing(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if shutdownTracing != nil {
+			shutdownTracing(context.Background())
+		}
+	},
 }
 
+//shutdownTracing отправляет накопленные span'ы перед завершением программы
+var shutdownTracing func(context.Context) error
+
 func Execute() {
-	initLog()
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(exitUsage)
+	}
+	os.Exit(exitCode)
+}
+
+//fail выводит ошибку в лог и устанавливает код завершения программы. Для ошибки gRPC код завершения определяется
+//кодом её состояния.
+func fail(err error) {
+	log.Error(err.Error())
+
+	exitCode = exitError
+	if st, ok := status.FromError(err); ok {
+		exitCode = exitStatusBase + int(st.Code())
+	}
+}
 
-	rootCmd.Execute()
+//failUsage выводит в лог ошибку в аргументах команды.
+func failUsage(format string, v ...interface{}) {
+	log.Errorf(format, v...)
+	exitCode = exitUsage
 }
 
 func init() {
 	executableDir := filepath.Dir(os.Args[0])
 	defaultCfgFile := filepath.Join(executableDir, "config/config.yml")
 	rootCmd.PersistentFlags().String("cfg-file", defaultCfgFile, "path to config file")
+	rootCmd.PersistentFlags().String("addr", "", "server address or comma-separated addresses of several servers to"+
+		" balance the calls between. Overrides the addresses from the config file, which is not required then")
+	rootCmd.PersistentFlags().String("profile", "", "name of a profile from the client.profiles section of the config"+
+		" file overriding the client settings. Overrides the "+config.ProfileEnv+" environment variable")
 
 	rootCmd.PersistentFlags().String("log-file", "", "path to a log file")
+	rootCmd.PersistentFlags().String("log-level", "info", "minimum level of log messages: debug, info, warning or error")
+	rootCmd.PersistentFlags().String("log-format", "text", "format of log messages: text or json")
+
+	rootCmd.PersistentFlags().String("trace-exporter", tracing.ExporterNone, "trace exporter: none, stdout, file or otlp")
+	rootCmd.PersistentFlags().String("trace-target", "", "path to the trace file for the file exporter or the collector"+
+		" address for the otlp exporter")
 }
 
+//readConfig читает файл конфигурации, профиль и переменные окружения ACCOUNTS_CLIENT_*. Адреса серверов, заданные
+//флагом --addr, заменяют адреса из файла. Отсутствие файла не является ошибкой, если задан флаг --addr или путь
+//к файлу не задан явно.
 func readConfig() (*config.Config, error) {
 	cfgFile, _ := rootCmd.PersistentFlags().GetString("cfg-file")
-	cfg, err := config.New(cfgFile)
+	addr, _ := rootCmd.PersistentFlags().GetString("addr")
+	profile, _ := rootCmd.PersistentFlags().GetString("profile")
+
+	cfg, err := config.Load(cfgFile, profile, os.LookupEnv)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, os.ErrNotExist) || addr == "" && rootCmd.PersistentFlags().Changed("cfg-file") {
+			return nil, err
+		}
+		if cfg, err = config.Load("", profile, os.LookupEnv); err != nil {
+			return nil, err
+		}
+	}
+	if addr != "" {
+		cfg.Client.Addr, cfg.Client.Addrs = "", strings.Split(addr, ",")
 	}
 
 	return cfg, nil
 }
 
-func initLog() {
-	logFile, _ := rootCmd.PersistentFlags().GetString("log-file")
+//connect читает и проверяет настройки и создаёт общее для клиентов команды соединение с серверами.
+func connect() (*config.Config, *grpc.ClientConn, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := client.Dial(cfg.Client.Addresses(), cfg.Client.Connection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, conn, nil
+}
+
+func initLog(cmd *cobra.Command) error {
+	levelName, _ := cmd.Flags().GetString("log-level")
+	level, err := log.ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(level)
+
+	formatName, _ := cmd.Flags().GetString("log-format")
+	format, err := log.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+	log.SetFormat(format)
+
+	logFile, _ := cmd.Flags().GetString("log-file")
 	if logFile != "" {
 		log.SetOutput(&lumberjack.Logger{
 			Filename:   logFile,
@@ -49,4 +163,19 @@ func initLog() {
 			LocalTime:  true,
 		})
 	}
+
+	return nil
+}
+
+func initTracing(cmd *cobra.Command) error {
+	exporter, _ := cmd.Flags().GetString("trace-exporter")
+	target, _ := cmd.Flags().GetString("trace-target")
+
+	shutdown, err := tracing.Setup("accounts-client", exporter, target)
+	if err != nil {
+		return err
+	}
+	shutdownTracing = shutdown
+
+	return nil
 }
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/vps2/accounttesttask/internal/client"
+	"github.com/vps2/accounttesttask/internal/client/scenario"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"github.com/spf13/cobra"
+)
+
+//Статусы сценариев в выводе команды run-scenario
+const (
+	scenarioPassed = "passed"
+	scenarioFailed = "failed"
+	scenarioError  = "error"
+)
+
+//scenarioRecord - результат сценария в выводе команды run-scenario
+type scenarioRecord struct {
+	Suite    string   `json:"suite"`
+	Scenario string   `json:"scenario"`
+	Status   string   `json:"status"`
+	TimeMs   int64    `json:"time_ms"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+var runScenarioCmd = &cobra.Command{
+	Use: "run-scenario <file...>",
+	Short: "Running the scenarios described in YAML files against the server and printing their results. See the" +
+		" configs/scenario.yml example",
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(); err != nil {
+			failUsage("%s\n", err)
+			return
+		}
+		vars, _ := cmd.Flags().GetStringToString("var")
+		junitFile, _ := cmd.Flags().GetString("junit")
+
+		files := make([]*scenario.File, 0, len(args))
+		for _, path := range args {
+			f, err := scenario.Load(path)
+			if err != nil {
+				fail(err)
+				return
+			}
+			files = append(files, f)
+		}
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		doneCh := make(chan os.Signal, 1)
+		signal.Notify(doneCh, os.Interrupt)
+		go func() {
+			select {
+			case <-doneCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		runner := scenario.NewRunner(client.NewBalancesClient(conn)).WithVars(vars)
+		suites := make([]*scenario.Suite, 0, len(files))
+		for _, f := range files {
+			suites = append(suites, runner.Run(ctx, f))
+		}
+
+		records := make([]scenarioRecord, 0)
+		rows := make([][]string, 0)
+		failed := 0
+		for _, s := range suites {
+			for _, r := range s.Results {
+				record := scenarioRecord{
+					Suite:    s.Name,
+					Scenario: r.Name,
+					Status:   scenarioPassed,
+					TimeMs:   r.Duration.Milliseconds(),
+					Problems: append(append([]string(nil), r.Errors...), r.Failures...),
+				}
+				switch {
+				case len(r.Errors) > 0:
+					record.Status = scenarioError
+				case len(r.Failures) > 0:
+					record.Status = scenarioFailed
+				}
+				if !r.Passed() {
+					failed++
+				}
+
+				records = append(records, record)
+				rows = append(rows, []string{record.Suite, record.Scenario, record.Status,
+					strconv.FormatInt(record.TimeMs, 10), strings.Join(record.Problems, "; ")})
+			}
+		}
+
+		if err := printRecords(os.Stdout, records, []string{"suite", "scenario", "status", "time_ms", "problems"}, rows); err != nil {
+			fail(err)
+			return
+		}
+
+		if junitFile != "" {
+			if err := writeJUnit(junitFile, suites); err != nil {
+				fail(err)
+				return
+			}
+		}
+
+		if ctx.Err() != nil {
+			log.Warning("scenarios interrupted")
+		}
+		log.Info("scenarios completed", log.F("scenarios", len(records)), log.F("failed", failed))
+		if failed > 0 {
+			exitCode = exitMismatch
+		}
+	},
+}
+
+func writeJUnit(path string, suites []*scenario.Suite) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = scenario.WriteJUnit(file, suites); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func init() {
+	addOutputFlag(runScenarioCmd)
+
+	runScenarioCmd.Flags().StringToString("var", nil, "variables overriding the ones from the scenario files, e.g."+
+		" --var account=42")
+	runScenarioCmd.Flags().String("junit", "", "path to a JUnit XML report file")
+
+	rootCmd.AddCommand(runScenarioCmd)
+}
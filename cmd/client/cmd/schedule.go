@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleAmount      int64
+	scheduleTo          int32
+	scheduleCurrency    string
+	scheduleCron        string
+	scheduleStartAt     string
+	scheduleCatchUp     string
+	scheduleDescription string
+	scheduleBalanceId   int32
+	scheduleRunsLimit   int32
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Managing future-dated and recurring operations",
+}
+
+var scheduleCreateCmd = &cobra.Command{
+	Use:   "create <id>",
+	Short: "Scheduling a credit, a debit (negative amount) or a transfer with --to from the account",
+	Long: "Scheduling a credit, a debit (negative amount) or a transfer with --to from the account. A recurring" +
+		" operation is set with --cron, e.g. \"0 9 1 * *\" for 09:00 UTC on the first day of every month, a one-time" +
+		" operation requires --start-at.",
+	Args: cobra.ExactArgs(1),
+	Run: runAdmin(func(c *client.AdminServiceClient, cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			failUsage("invalid account id %q\n", args[0])
+			return nil
+		}
+
+		req := &api.CreateScheduleRequest{
+			BalanceId:   int32(id),
+			Amount:      scheduleAmount,
+			Currency:    scheduleCurrency,
+			Cron:        scheduleCron,
+			StartAt:     scheduleStartAt,
+			CatchUp:     scheduleCatchUp,
+			Description: scheduleDescription,
+		}
+		if cmd.Flags().Changed("to") {
+			req.ToBalanceId = &scheduleTo
+		}
+
+		schedule, err := c.CreateSchedule(context.Background(), req)
+		if err != nil {
+			return err
+		}
+
+		printSchedules([]*api.Schedule{schedule})
+
+		return nil
+	}),
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Listing the schedules of the account set with --balance-id or all schedules",
+	Args:  cobra.NoArgs,
+	Run: runAdmin(func(c *client.AdminServiceClient, cmd *cobra.Command, args []string) error {
+		var balanceId *int32
+		if cmd.Flags().Changed("balance-id") {
+			balanceId = &scheduleBalanceId
+		}
+
+		schedules, err := c.ListSchedules(context.Background(), balanceId)
+		if err != nil {
+			return err
+		}
+
+		printSchedules(schedules)
+
+		return nil
+	}),
+}
+
+var scheduleCancelCmd = &cobra.Command{
+	Use:   "cancel <schedule-id>",
+	Short: "Cancelling a schedule",
+	Args:  cobra.ExactArgs(1),
+	Run: runAdmin(func(c *client.AdminServiceClient, cmd *cobra.Command, args []string) error {
+		return c.CancelSchedule(context.Background(), args[0])
+	}),
+}
+
+var scheduleRunsCmd = &cobra.Command{
+	Use:   "runs <schedule-id>",
+	Short: "Listing the latest runs of a schedule and their outcomes",
+	Args:  cobra.ExactArgs(1),
+	Run: runAdmin(func(c *client.AdminServiceClient, cmd *cobra.Command, args []string) error {
+		runs, err := c.ListScheduleRuns(context.Background(), args[0], scheduleRunsLimit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSCHEDULED AT\tFINISHED AT\tSTATUS\tDETAIL")
+		for _, run := range runs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", run.Id, run.ScheduledAt, run.FinishedAt, run.Status, run.Detail)
+		}
+		w.Flush()
+
+		return nil
+	}),
+}
+
+//runAdmin возвращает обработчик команды, выполняющей запрос к административному сервису.
+func runAdmin(run func(c *client.AdminServiceClient, cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		if err = run(client.NewAdminServiceClient(conn), cmd, args); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func printSchedules(schedules []*api.Schedule) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tACCOUNT\tTO\tAMOUNT\tCURRENCY\tCRON\tCATCH-UP\tSTATUS\tNEXT RUN AT\tDESCRIPTION")
+	for _, s := range schedules {
+		to := ""
+		if s.ToBalanceId != nil {
+			to = strconv.Itoa(int(*s.ToBalanceId))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Id, s.BalanceId, to, s.Amount, s.Currency, s.Cron, s.CatchUp, s.Status, s.NextRunAt, s.Description)
+	}
+	w.Flush()
+}
+
+func init() {
+	scheduleCreateCmd.Flags().Int64Var(&scheduleAmount, "amount", 0, "amount in minor currency units. A negative amount is debited")
+	scheduleCreateCmd.Flags().Int32Var(&scheduleTo, "to", 0, "account to transfer the amount to")
+	scheduleCreateCmd.Flags().StringVar(&scheduleCurrency, "currency", "", "ISO 4217 currency code of the amount."+
+		" If omitted, the account currency is used")
+	scheduleCreateCmd.Flags().StringVar(&scheduleCron, "cron", "", "cron expression of a recurring operation in UTC")
+	scheduleCreateCmd.Flags().StringVar(&scheduleStartAt, "start-at", "", "RFC 3339 time of a one-time operation or"+
+		" the time recurring operations start at")
+	scheduleCreateCmd.Flags().StringVar(&scheduleCatchUp, "catch-up", "", "runs missed during a server downtime to"+
+		" execute: skip, latest or all. Default is latest")
+	scheduleCreateCmd.Flags().StringVar(&scheduleDescription, "description", "", "description of the operation")
+	scheduleCreateCmd.MarkFlagRequired("amount")
+
+	scheduleListCmd.Flags().Int32Var(&scheduleBalanceId, "balance-id", 0, "account to list the schedules of")
+	scheduleRunsCmd.Flags().Int32Var(&scheduleRunsLimit, "limit", 0, "maximum number of runs. Zero means 50")
+
+	scheduleCmd.AddCommand(scheduleCreateCmd, scheduleListCmd, scheduleCancelCmd, scheduleRunsCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	metadataOwner  string
+	metadataLabels map[string]string
+)
+
+var setMetadataCmd = &cobra.Command{
+	Use:   "set-metadata <id>",
+	Short: "Replacing the owner and the labels of an account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			failUsage("invalid account id %q\n", args[0])
+			return
+		}
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		client := client.NewAdminServiceClient(conn)
+		if err = client.SetMetadata(context.Background(), int32(id), metadataOwner, metadataLabels); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	setMetadataCmd.Flags().StringVar(&metadataOwner, "owner", "", "owner of the account")
+	setMetadataCmd.Flags().StringToStringVar(&metadataLabels, "labels", nil, "labels of the account, e.g. env=prod,team=payments")
+	rootCmd.AddCommand(setMetadataCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+)
+
+var setMinBalanceCmd = &cobra.Command{
+	Use:   "set-min-balance <id> <min-balance>",
+	Short: "Setting the minimum allowed balance of an account. A negative value allows an overdraft",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 32)
+		if err != nil {
+			failUsage("invalid account id %q\n", args[0])
+			return
+		}
+		minBalance, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			failUsage("invalid minimum balance %q\n", args[1])
+			return
+		}
+
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		client := client.NewAdminServiceClient(conn)
+		if err = client.SetMinBalance(context.Background(), int32(id), minBalance); err != nil {
+			fail(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setMinBalanceCmd)
+}
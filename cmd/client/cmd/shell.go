@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/vps2/accounttesttask/internal/client"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"google.golang.org/grpc/status"
+)
+
+const shellPrompt = "accounts> "
+
+var shellCmd = &cobra.Command{
+	Use: "shell",
+	Short: "Interactive shell with history and tab completion running get, add, stats, reset and watch commands over" +
+		" one connection. Type help for the list of commands",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, conn, err := connect()
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer conn.Close()
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			//команды из канала или файла выполняются без приглашения и редактирования строки
+			runShell(client.NewShell(conn, os.Stdout), newLineReader(os.Stdin), nil)
+			return
+		}
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			fail(err)
+			return
+		}
+		defer term.Restore(fd, state)
+
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}, shellPrompt)
+		if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+			t.SetSize(width, height)
+		}
+
+		shell := client.NewShell(conn, os.Stdout)
+		t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+			if key != '\t' {
+				return "", 0, false
+			}
+
+			newLine, newPos, candidates := shell.Complete(line, pos)
+			if newLine == line && len(candidates) > 1 {
+				fmt.Fprintln(t, strings.Join(candidates, "  "))
+			}
+
+			return newLine, newPos, true
+		}
+
+		//команды выполняются в обычном режиме терминала, чтобы Ctrl+C прерывал команду, а не завершал оболочку
+		runShell(shell, t.ReadLine, func(raw bool) {
+			if raw {
+				term.MakeRaw(fd)
+			} else {
+				term.Restore(fd, state)
+			}
+		})
+	},
+}
+
+//runShell выполняет команды, прочитанные readLine, до выхода из оболочки или конца ввода. setRaw, если задана,
+//вызывается для перевода терминала в обычный режим перед выполнением команды и обратно после неё.
+func runShell(shell *client.Shell, readLine func() (string, error), setRaw func(raw bool)) {
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		if setRaw != nil {
+			setRaw(false)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		quit, err := shell.Exec(ctx, line)
+		stop()
+		if setRaw != nil {
+			setRaw(true)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stdout, formatShellError(err))
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+//newLineReader возвращает функцию чтения строк из r, которая возвращает io.EOF в конце ввода.
+func newLineReader(r io.Reader) func() (string, error) {
+	scanner := bufio.NewScanner(r)
+
+	return func() (string, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+
+		return scanner.Text(), nil
+	}
+}
+
+//formatShellError возвращает описание ошибки команды оболочки. Для ошибки gRPC выводится код её состояния.
+func formatShellError(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Sprintf("error: %s: %s", st.Code(), st.Message())
+	}
+
+	return "error: " + err.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
@@ -2,15 +2,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
 	"time"
 
 	"github.com/vps2/accounttesttask/internal/client"
+	"github.com/vps2/accounttesttask/internal/client/config"
 	"github.com/vps2/accounttesttask/pkg/log"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 )
 
 var updateAccountsCmd = &cobra.Command{
@@ -18,14 +22,47 @@ var updateAccountsCmd = &cobra.Command{
 	Short: "Updating accounts",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg, err := readConfig()
+		cfg, conn, err := connect()
 		if err != nil {
-			log.Error(err.Error())
+			fail(err)
 			return
 		}
+		defer conn.Close()
+
+		if err = cfg.ValidateWorkload(); err != nil {
+			fail(err)
+			return
+		}
+		workload := &cfg.Client.Workload
+
+		historyPath, _ := cmd.Flags().GetString("history")
+		linearizability, _ := cmd.Flags().GetBool("linearizability")
+		verify, _ := cmd.Flags().GetBool("verify")
+		verify = verify || historyPath != "" || linearizability
+
+		var verifier *client.Verifier
+		if verify {
+			initial, err := readBalances(conn, cfg.Client.Keys)
+			if err != nil {
+				fail(err)
+				return
+			}
+			verifier = client.NewVerifier(initial, historyPath != "" || linearizability)
+		}
 
 		ctx, cancel := context.WithCancel(context.Background())
 
+		var pacer *client.Pacer
+		if workload.Rate.Profile != config.RateNone {
+			pacer = client.NewPacer(workload.Rate)
+			pacer.Start(ctx)
+		}
+
+		collector := client.NewCollector()
+		if interval, _ := cmd.Flags().GetDuration("progress-interval"); interval > 0 {
+			collector.StartProgress(ctx, interval)
+		}
+
 		var wg sync.WaitGroup
 
 		for i := 0; i < cfg.Client.Readers; i++ {
@@ -33,7 +70,11 @@ var updateAccountsCmd = &cobra.Command{
 			go func() {
 				defer wg.Done()
 
-				client := client.NewAccountServiceClient(cfg.Client.Addr, cfg.Client.Keys, client.OpRead)
+				client := client.NewAccountServiceClient(conn, cfg.Client.Keys, client.OpRead).
+					WithWorkload(cfg.Client.Keys, workload).
+					WithPacer(pacer).
+					WithRecorder(collector.NewRecorder()).
+					WithVerifier(verifier)
 				if err := client.Run(ctx); err != nil {
 					log.Error(err.Error())
 					return
@@ -45,7 +86,11 @@ var updateAccountsCmd = &cobra.Command{
 			go func() {
 				defer wg.Done()
 
-				client := client.NewAccountServiceClient(cfg.Client.Addr, cfg.Client.Keys, client.OpWrite)
+				client := client.NewAccountServiceClient(conn, cfg.Client.Keys, client.OpWrite).
+					WithWorkload(cfg.Client.Keys, workload).
+					WithPacer(pacer).
+					WithRecorder(collector.NewRecorder()).
+					WithVerifier(verifier)
 				if err := client.Run(ctx); err != nil {
 					log.Error(err.Error())
 					return
@@ -56,7 +101,10 @@ var updateAccountsCmd = &cobra.Command{
 		doneCh := make(chan os.Signal, 1)
 		signal.Notify(doneCh, os.Interrupt)
 
-		idle, _ := cmd.Flags().GetDuration("idle")
+		idle := workload.Duration
+		if cmd.Flags().Changed("idle") {
+			idle, _ = cmd.Flags().GetDuration("idle")
+		}
 
 		//ожидаем нажатия Ctrl+C или наступления таймаута
 		select {
@@ -68,12 +116,151 @@ var updateAccountsCmd = &cobra.Command{
 
 		wg.Wait()
 
+		report := collector.Report(time.Now())
+		if err := report.Print(os.Stdout); err != nil {
+			fail(err)
+		}
+		if path, _ := cmd.Flags().GetString("report-json"); path != "" {
+			if err := writeReport(path, report); err != nil {
+				fail(err)
+			}
+		}
+
+		if pacer != nil && pacer.Dropped() > 0 {
+			log.Warning("requests were not sent because all workers were busy", log.F("dropped", pacer.Dropped()))
+		}
+
+		if verifier != nil {
+			linearizabilityTimeout, _ := cmd.Flags().GetDuration("linearizability-timeout")
+			if !runVerification(conn, cfg.Client.Keys, verifier, historyPath, linearizability,
+				linearizabilityTimeout) {
+				return
+			}
+		}
 		log.Info("done")
 	},
 }
 
+//readBalances возвращает текущие балансы счетов keys.
+func readBalances(conn *grpc.ClientConn, keys []int) (map[int]int64, error) {
+	ids := make([]int32, 0, len(keys))
+	balances := make(map[int]int64, len(keys))
+	for _, key := range keys {
+		if _, ok := balances[key]; !ok {
+			balances[key] = 0
+			ids = append(ids, int32(key))
+		}
+	}
+
+	resp, err := client.NewBalancesClient(conn).GetBalances(context.Background(), ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range resp {
+		balances[int(r.BalanceId)] = r.Amount
+	}
+
+	return balances, nil
+}
+
+//runVerification сравнивает итоговые балансы с подтверждёнными изменениями, записывает историю операций в файл
+//historyPath, если он задан, и, если linearizability, проверяет её линеаризуемость. Каждое нарушение выводится
+//в лог, а код завершения программы устанавливается в exitMismatch. Возвращает false, если проверка
+//не пройдена или не выполнена.
+func runVerification(conn *grpc.ClientConn, keys []int, verifier *client.Verifier, historyPath string, linearizability bool,
+	timeout time.Duration) bool {
+	final, err := readBalances(conn, keys)
+	if err != nil {
+		fail(err)
+		return false
+	}
+
+	ok := true
+	for _, d := range verifier.CheckBalances(final) {
+		log.Error("balance verification failed: " + d.String())
+		ok = false
+	}
+
+	if historyPath != "" {
+		if err := writeJSON(historyPath, verifier.History()); err != nil {
+			fail(err)
+			return false
+		}
+	}
+
+	if linearizability {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		keys, err := client.CheckLinearizability(ctx, verifier.History(), verifier.Initial(), final)
+		if err != nil {
+			fail(fmt.Errorf("linearizability check was not completed: %w", err))
+			return false
+		}
+		for _, key := range keys {
+			log.Error("linearizability check failed: history of the key is not linearizable",
+				log.F(log.FieldBalanceId, key))
+			ok = false
+		}
+	}
+
+	if !ok {
+		exitCode = exitMismatch
+		return false
+	}
+	log.Info("verification passed", log.F("keys", len(final)))
+
+	return true
+}
+
+//writeReport записывает итоги теста в формате JSON в файл path или, если path равен "-", в стандартный вывод.
+func writeReport(path string, report *client.Report) error {
+	if path == "-" {
+		return report.WriteJSON(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = report.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+//writeJSON записывает v в формате JSON в файл path.
+func writeJSON(path string, v interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(file).Encode(v); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
 func init() {
-	updateAccountsCmd.Flags().Duration("idle", 15*time.Second, "the waiting time (in seconds) before program exit")
+	updateAccountsCmd.Flags().Duration("idle", 15*time.Second, "the waiting time (in seconds) before program exit."+
+		" Overrides workload.duration from the config file")
+	updateAccountsCmd.Flags().Duration("progress-interval", 5*time.Second, "interval of logging the progress of the"+
+		" run. Zero disables the progress")
+	updateAccountsCmd.Flags().String("report-json", "", "path to write the final report in JSON to, \"-\" for the"+
+		" standard output")
+	updateAccountsCmd.Flags().Bool("verify", false, "compare the final balances of the keys with the acknowledged"+
+		" updates. The balances must not be changed by anyone else during the run, e.g. by scheduled operations or"+
+		" accruals")
+	updateAccountsCmd.Flags().String("history", "", "path to write the history of operations in JSON to. Implies"+
+		" --verify")
+	updateAccountsCmd.Flags().Bool("linearizability", false, "check that the history of operations is linearizable."+
+		" Implies --verify")
+	updateAccountsCmd.Flags().Duration("linearizability-timeout", time.Minute, "maximum duration of the"+
+		" linearizability check")
 
 	rootCmd.AddCommand(updateAccountsCmd)
 }
@@ -3,44 +3,77 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	_http "net/http"
 	"os"
 	"os/signal"
 	"strings"
-	"time"
+	"syscall"
 
+	migrations "github.com/vps2/accounttesttask/db-migrations"
+	"github.com/vps2/accounttesttask/internal/server/config"
 	"github.com/vps2/accounttesttask/internal/server/grpc"
+	"github.com/vps2/accounttesttask/internal/server/http"
 	"github.com/vps2/accounttesttask/internal/server/repository"
 	"github.com/vps2/accounttesttask/internal/server/repository/inmem"
 	_pg "github.com/vps2/accounttesttask/internal/server/repository/pg"
 	"github.com/vps2/accounttesttask/internal/server/service"
+	_cache "github.com/vps2/accounttesttask/pkg/cache"
 	"github.com/vps2/accounttesttask/pkg/cache/lru"
+	_log "github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/tracing"
+	"github.com/vps2/accounttesttask/pkg/traffic"
 
 	"github.com/go-pg/pg/v10"
-)
-
-var (
-	addr            string
-	cacheSize       int
-	pgURL           string
-	pollingInterval string
+	"golang.org/x/time/rate"
 )
 
 func main() {
-	flag.StringVar(&addr, "addr", ":8080", "listening address")
-	flag.IntVar(&cacheSize, "cache-size", 10, "cache size")
-	flag.StringVar(&pgURL, "pg-url", os.Getenv("PG_URL"), "the postgresql connection string. If omitted, the PG_URL"+
-		"environment variable is searched for. If PG_URL not specified, then we use the in-memory storage")
-	flag.StringVar(&pollingInterval, "polling-interval", os.Getenv("POLLING_INTERVAL"), "polling interval by the"+
-		" statistics collection service. If omitted, the POLLING_INTERVAL environment variable is searched for."+
-		" If POLLING_INTERVAL not specified, the default value is '30s'")
-	flag.Parse()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	cfgFile := fs.String("cfg-file", "", "path to config file. Settings are taken from the 'server' section")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+	migrate := fs.Bool("migrate", false, "apply pending database migrations on startup")
+	flags := config.BindFlags(fs)
+	fs.Parse(os.Args[1:])
+
+	loadConfig := func() (*config.Config, error) {
+		return config.Load(*cfgFile, os.LookupEnv, flags)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *printConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	level, _ := _log.ParseLevel(cfg.Server.LogLevel)
+	_log.SetLevel(level)
+	format, _ := _log.ParseFormat(cfg.Server.LogFormat)
+	_log.SetFormat(format)
+
+	shutdownTracing, err := tracing.Setup("accounts-server", cfg.Server.Tracing.Exporter, cfg.Server.Tracing.Target)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer shutdownTracing(context.Background())
 
 	var repo repository.Accounts
-	if pgURL == "" {
+	var schedulesRepo repository.Schedules
+	if cfg.Server.Storage.Backend == config.StorageInMem {
 		repo = inmem.NewAccountsRepo()
+		schedulesRepo = inmem.NewSchedulesRepo()
 	} else {
-		opt, err := pg.ParseURL(pgURL)
+		opt, err := pg.ParseURL(cfg.Server.Storage.PgURL)
 		if err != nil {
 			panic(err)
 		}
@@ -53,42 +86,110 @@ func main() {
 			panic(err)
 		}
 
+		if *migrate {
+			migrator, err := _pg.NewMigrator(db, migrations.FS)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			migrator.WithParam("default_currency", cfg.Server.DefaultCurrency)
+			applied, err := migrator.Up(context.Background())
+			if err != nil {
+				log.Fatalln(err)
+			}
+			for _, m := range applied {
+				_log.Info("migration applied", _log.F("version", m.Version), _log.F("name", m.Name))
+			}
+		}
+
 		repo = _pg.NewAccountsRepo(db)
+		schedulesRepo = _pg.NewSchedulesRepo(db)
 	}
 
-	var _pollingInterval time.Duration
-	if pollingInterval == "" {
-		_pollingInterval = 30 * time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statisticsSvc := service.NewStatisticsSvc(ctx, cfg.Server.PollingInterval)
+
+	var cache _cache.Cache
+	var lruCache *lru.Cache
+	if cfg.Server.Cache.Policy == config.CachePolicyLRU {
+		lruCache = lru.NewCache(cfg.Server.Cache.Size)
+		cache = lruCache
 	} else {
-		var err error
-		if _pollingInterval, err = time.ParseDuration(pollingInterval); err != nil {
-			panic(err)
-		}
+		cache = _cache.Nop{}
 	}
 
-	statisticsSvc := service.NewStatisticsSvc(context.Background(), _pollingInterval)
+	accountsSvc := service.NewAccountsSvc(repo, cache).
+		WithDefaultCurrency(cfg.Server.DefaultCurrency).
+		WithLimits(cfg.Server.Limits.MaxOperationAmount, cfg.Server.Limits.MaxBalance)
+	accountsSvc.StartHoldSweeper(ctx, cfg.Server.HoldSweepInterval)
+
+	schedulerSvc := service.NewSchedulerSvc(schedulesRepo, accountsSvc).
+		WithMisfireThreshold(cfg.Server.Scheduler.MisfireThreshold)
+	schedulerSvc.Start(ctx, cfg.Server.Scheduler.Interval)
+
+	accrualSvc, err := service.NewAccrualSvc(accountsSvc, statisticsSvc, accrualRules(cfg.Server.Accrual.Rules))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	accrualSvc.Start(ctx, cfg.Server.Accrual.Interval)
 
-	cache := lru.NewCache(cacheSize)
-	accountsSvc := service.NewAccountsSvc(repo, cache)
 	accountsSrv := grpc.
-		NewServer(addr, accountsSvc, statisticsSvc).
-		WithUnaryInterceptors(
-			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-				if strings.Contains(info.FullMethod, "GetAmount") {
-					statisticsSvc.IncReadOperations()
-				}
-				if strings.Contains(info.FullMethod, "AddAmount") {
-					statisticsSvc.IncWriteOperations()
-				}
-
-				return handler(ctx, req)
-			},
-		)
+		NewServer(cfg.Server.Addr, accountsSvc, statisticsSvc).
+		WithAdminService(accountsSvc, schedulerSvc).
+		WithLimits(cfg.Server.Limits.MaxConcurrentStreams, cfg.Server.Limits.MaxRecvMsgSize)
+
+	//ограничитель создаётся всегда, чтобы ограничение можно было включить при перечитывании настроек
+	limiter := rate.NewLimiter(rateLimit(cfg.Server.Limits.RateLimit), cfg.Server.Limits.RateBurst)
+
+	r := &reloader{
+		cfg:           cfg,
+		cache:         lruCache,
+		statisticsSvc: statisticsSvc,
+		limiter:       limiter,
+		slowThreshold: int64(cfg.Server.SlowCallThreshold),
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpc.RequestIdInterceptor(),
+		grpc.TracingInterceptor(),
+		grpc.AccessLogInterceptor(r.slowCallThreshold),
+	}
+	if cfg.Server.RecordFile != "" {
+		recordFile, err := os.OpenFile(cfg.Server.RecordFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer recordFile.Close()
+
+		interceptors = append(interceptors,
+			grpc.RecordingInterceptor(traffic.NewWriter(recordFile), grpc.AccountsServiceName))
+	}
+	accountsSrv.WithUnaryInterceptors(append(interceptors,
+		grpc.RecoveryInterceptor(),
+		grpc.RateLimitInterceptor(limiter),
+	)...)
+	if cfg.Server.TLS.CertFile != "" {
+		accountsSrv.WithTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+	}
+
+	accountsSrv.WithUnaryInterceptors(
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+			switch info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:] {
+			case "GetAmount", "ListAccounts":
+				statisticsSvc.IncReadOperations()
+			case "AddAmount", "SetAmountIfVersion", "Authorize", "Capture", "Void", "Transfer":
+				statisticsSvc.IncWriteOperations()
+			}
+
+			return handler(ctx, req)
+		},
+	)
 
 	doneCh := make(chan os.Signal, 1)
 	signal.Notify(doneCh, os.Interrupt)
 
-	errCh := make(chan error)
+	errCh := make(chan error, 2)
 
 	go func() {
 		if err := accountsSrv.Start(); err != nil {
@@ -96,11 +197,78 @@ func main() {
 		}
 	}()
 
-	select {
-	case err := <-errCh:
-		log.Println(err)
-	case <-doneCh:
+	var gatewaySrv *http.Server
+	if cfg.Server.HTTPAddr != "" {
+		gatewaySrv = http.
+			NewServer(cfg.Server.HTTPAddr, accountsSvc, statisticsSvc).
+			WithMiddlewares(http.RateLimitMiddleware(limiter))
+		if cfg.Server.TLS.CertFile != "" {
+			gatewaySrv.WithTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		}
+
+		gatewaySrv.WithMiddlewares(
+			func(next _http.Handler) _http.Handler {
+				return _http.HandlerFunc(func(w _http.ResponseWriter, r *_http.Request) {
+					if r.Method == _http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/balances") {
+						statisticsSvc.IncReadOperations()
+					}
+					if r.Method == _http.MethodPost && (strings.HasPrefix(r.URL.Path, "/v1/balances/") ||
+						strings.HasPrefix(r.URL.Path, "/v1/holds/")) {
+						statisticsSvc.IncWriteOperations()
+					}
+
+					next.ServeHTTP(w, r)
+				})
+			},
+		)
+
+		go func() {
+			if err := gatewaySrv.Start(); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+
+loop:
+	for {
+		select {
+		case err := <-errCh:
+			log.Println(err)
+			break loop
+		case <-reloadCh:
+			newCfg, err := loadConfig()
+			if err != nil {
+				_log.Errorf("configuration is not reloaded: %s\n", err)
+				continue
+			}
+			r.apply(newCfg)
+		case <-doneCh:
+			break loop
+		}
 	}
 
+	if gatewaySrv != nil {
+		gatewaySrv.GracefulStop()
+	}
 	accountsSrv.GracefulStop()
 }
+
+//accrualRules преобразует правила начисления из настроек в правила сервиса начислений.
+func accrualRules(rules []config.AccrualRule) []service.AccrualRule {
+	res := make([]service.AccrualRule, 0, len(rules))
+	for _, rule := range rules {
+		res = append(res, service.AccrualRule{
+			Name:          rule.Name,
+			Kind:          service.AccrualKind(rule.Kind),
+			Period:        service.AccrualPeriod(rule.Period),
+			AnnualRate:    rule.AnnualRate,
+			Amount:        rule.Amount,
+			LabelSelector: rule.Selector,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	migrations "github.com/vps2/accounttesttask/db-migrations"
+	"github.com/vps2/accounttesttask/internal/server/config"
+	_pg "github.com/vps2/accounttesttask/internal/server/repository/pg"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const migrateUsage = `usage: %s migrate [flags] up|down [N]|status|baseline VERSION
+
+  up        apply all pending migrations
+  down      revert the last N applied migrations (1 by default)
+  status    print the state of all migrations
+  baseline  mark the migrations up to VERSION as applied without running them
+
+A database whose accounts table was created by hand before the migrations were introduced is upgraded with
+"baseline 1" followed by "up". Without the baseline the first migration fails because the table already exists.
+
+`
+
+//runMigrate выполняет подкоманду migrate. Строка подключения к postgresql берётся из тех же источников, что и
+//при запуске сервера.
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), migrateUsage, os.Args[0])
+		fs.PrintDefaults()
+	}
+	cfgFile := fs.String("cfg-file", "", "path to config file. Settings are taken from the 'server' section")
+	flags := config.BindFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*cfgFile, os.LookupEnv, flags)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if cfg.Server.Storage.Backend != config.StoragePg {
+		log.Fatalln("migrations require the pg storage backend")
+	}
+
+	opt, err := pg.ParseURL(cfg.Server.Storage.PgURL)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	db := pg.Connect(opt)
+	defer db.Close()
+
+	migrator, err := _pg.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	migrator.WithParam("default_currency", cfg.Server.DefaultCurrency)
+
+	ctx := context.Background()
+
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			steps, err = strconv.Atoi(fs.Arg(1))
+			if err != nil || steps <= 0 {
+				log.Fatalf("invalid number of migrations %q\n", fs.Arg(1))
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "baseline":
+		if fs.NArg() != 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		version, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatalf("invalid migration version %q\n", fs.Arg(1))
+		}
+
+		marked, err := migrator.Baseline(ctx, version)
+		for _, m := range marked {
+			fmt.Printf("marked %d_%s as applied\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(marked) == 0 {
+			fmt.Println("no pending migrations up to the version")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied() {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/config"
+	"github.com/vps2/accounttesttask/internal/server/service"
+	"github.com/vps2/accounttesttask/pkg/cache/lru"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"golang.org/x/time/rate"
+)
+
+//reloader применяет к работающему серверу настройки, которые можно изменить без перезапуска.
+type reloader struct {
+	cfg           *config.Config
+	cache         *lru.Cache
+	statisticsSvc *service.StatisticsSvc
+	limiter       *rate.Limiter
+	slowThreshold int64
+}
+
+func (r *reloader) slowCallThreshold() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.slowThreshold))
+}
+
+func (r *reloader) apply(cfg *config.Config) {
+	for _, name := range r.cfg.StaticChanges(cfg) {
+		log.Warningf("the %s setting cannot be changed without restart, ignored\n", name)
+	}
+
+	applied := *r.cfg
+	prev, next := &r.cfg.Server, &cfg.Server
+
+	if prev.Cache.Size != next.Cache.Size {
+		if r.cache != nil {
+			r.cache.Resize(next.Cache.Size)
+			log.Infof("cache size changed from %d to %d\n", prev.Cache.Size, next.Cache.Size)
+		}
+		applied.Server.Cache.Size = next.Cache.Size
+	}
+
+	if prev.PollingInterval != next.PollingInterval {
+		r.statisticsSvc.SetPollInterval(next.PollingInterval)
+		applied.Server.PollingInterval = next.PollingInterval
+		log.Infof("polling interval changed from %s to %s\n", prev.PollingInterval, next.PollingInterval)
+	}
+
+	if prev.Limits.RateLimit != next.Limits.RateLimit || prev.Limits.RateBurst != next.Limits.RateBurst {
+		r.limiter.SetLimit(rateLimit(next.Limits.RateLimit))
+		r.limiter.SetBurst(next.Limits.RateBurst)
+		applied.Server.Limits.RateLimit = next.Limits.RateLimit
+		applied.Server.Limits.RateBurst = next.Limits.RateBurst
+		log.Infof("rate limit changed to %v requests per second with burst %d\n", next.Limits.RateLimit, next.Limits.RateBurst)
+	}
+
+	if prev.LogLevel != next.LogLevel {
+		level, _ := log.ParseLevel(next.LogLevel)
+		log.SetLevel(level)
+		applied.Server.LogLevel = next.LogLevel
+		log.Infof("log level changed from %s to %s\n", prev.LogLevel, next.LogLevel)
+	}
+
+	if prev.LogFormat != next.LogFormat {
+		format, _ := log.ParseFormat(next.LogFormat)
+		log.SetFormat(format)
+		applied.Server.LogFormat = next.LogFormat
+		log.Infof("log format changed from %s to %s\n", prev.LogFormat, next.LogFormat)
+	}
+
+	if prev.SlowCallThreshold != next.SlowCallThreshold {
+		atomic.StoreInt64(&r.slowThreshold, int64(next.SlowCallThreshold))
+		applied.Server.SlowCallThreshold = next.SlowCallThreshold
+		log.Infof("slow call threshold changed from %s to %s\n", prev.SlowCallThreshold, next.SlowCallThreshold)
+	}
+
+	r.cfg = &applied
+}
+
+//rateLimit возвращает ограничение количества запросов в секунду. Нулевое значение означает отсутствие ограничения.
+func rateLimit(limit float64) rate.Limit {
+	if limit == 0 {
+		return rate.Inf
+	}
+
+	return rate.Limit(limit)
+}
@@ -0,0 +1,3 @@
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS "chk_account_min_balance",
+    DROP COLUMN IF EXISTS min_balance;
@@ -0,0 +1,3 @@
+ALTER TABLE accounts
+    ADD COLUMN min_balance BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT "chk_account_min_balance" CHECK (balance >= min_balance);
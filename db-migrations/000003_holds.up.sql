@@ -0,0 +1,11 @@
+CREATE TABLE holds (
+    id TEXT NOT NULL,
+    account_id INT NOT NULL,
+    amount BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT "pk_hold_id" PRIMARY KEY (id),
+    CONSTRAINT "fk_hold_account_id" FOREIGN KEY (account_id) REFERENCES accounts (id) ON DELETE CASCADE,
+    CONSTRAINT "chk_hold_amount" CHECK (amount > 0)
+);
+
+CREATE INDEX "idx_hold_account_id_expires_at" ON holds (account_id, expires_at);
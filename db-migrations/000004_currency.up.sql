@@ -0,0 +1,7 @@
+ALTER TABLE accounts
+    ADD COLUMN currency CHAR(3);
+
+UPDATE accounts SET currency = ?default_currency;
+
+ALTER TABLE accounts
+    ALTER COLUMN currency SET NOT NULL;
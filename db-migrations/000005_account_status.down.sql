@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS account_status_audit;
+
+ALTER TABLE accounts
+    DROP CONSTRAINT IF EXISTS "chk_account_status",
+    DROP COLUMN IF EXISTS status;
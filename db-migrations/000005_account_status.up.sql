@@ -0,0 +1,16 @@
+ALTER TABLE accounts
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active',
+    ADD CONSTRAINT "chk_account_status" CHECK (status IN ('active', 'debit_frozen', 'frozen', 'closed'));
+
+CREATE TABLE account_status_audit (
+    id BIGSERIAL NOT NULL,
+    account_id INT NOT NULL,
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT "pk_account_status_audit_id" PRIMARY KEY (id),
+    CONSTRAINT "fk_account_status_audit_account_id" FOREIGN KEY (account_id) REFERENCES accounts (id)
+);
+
+CREATE INDEX "idx_account_status_audit_account_id" ON account_status_audit (account_id, changed_at);
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS "trg_account_updated_at" ON accounts;
+DROP FUNCTION IF EXISTS accounts_set_updated_at();
+
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS labels,
+    DROP COLUMN IF EXISTS owner;
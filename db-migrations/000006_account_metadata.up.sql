@@ -0,0 +1,21 @@
+ALTER TABLE accounts
+    ADD COLUMN owner TEXT NOT NULL DEFAULT '',
+    ADD COLUMN labels JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE FUNCTION accounts_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_account_updated_at" BEFORE UPDATE ON accounts
+    FOR EACH ROW EXECUTE PROCEDURE accounts_set_updated_at();
+
+CREATE INDEX "idx_account_labels" ON accounts USING GIN (labels);
+CREATE INDEX "idx_account_balance_id" ON accounts (balance, id);
+CREATE INDEX "idx_account_created_at_id" ON accounts (created_at, id);
+CREATE INDEX "idx_account_updated_at_id" ON accounts (updated_at, id);
+CREATE INDEX "idx_account_status" ON accounts (status);
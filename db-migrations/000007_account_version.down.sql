@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS "trg_account_version" ON accounts;
+DROP FUNCTION IF EXISTS accounts_bump_version();
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS version;
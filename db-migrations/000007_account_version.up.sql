@@ -0,0 +1,11 @@
+ALTER TABLE accounts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+CREATE FUNCTION accounts_bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "trg_account_version" BEFORE UPDATE ON accounts
+    FOR EACH ROW EXECUTE PROCEDURE accounts_bump_version();
@@ -0,0 +1,36 @@
+CREATE TABLE schedules (
+    id TEXT NOT NULL,
+    account_id INT NOT NULL,
+    to_account_id INT,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL DEFAULT '',
+    cron TEXT NOT NULL DEFAULT '',
+    catch_up TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active',
+    description TEXT NOT NULL DEFAULT '',
+    next_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT "pk_schedule_id" PRIMARY KEY (id),
+    CONSTRAINT "chk_schedule_amount" CHECK (amount <> 0),
+    CONSTRAINT "chk_schedule_catch_up" CHECK (catch_up IN ('skip', 'latest', 'all')),
+    CONSTRAINT "chk_schedule_status" CHECK (status IN ('active', 'completed', 'cancelled')),
+    CONSTRAINT "chk_schedule_next_run_at" CHECK ((status = 'active') = (next_run_at IS NOT NULL))
+);
+
+CREATE INDEX "idx_schedule_next_run_at" ON schedules (next_run_at) WHERE status = 'active';
+CREATE INDEX "idx_schedule_account_id" ON schedules (account_id, created_at);
+
+CREATE TABLE schedule_runs (
+    id BIGSERIAL NOT NULL,
+    schedule_id TEXT NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    status TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    CONSTRAINT "pk_schedule_run_id" PRIMARY KEY (id),
+    CONSTRAINT "fk_schedule_run_schedule_id" FOREIGN KEY (schedule_id) REFERENCES schedules (id) ON DELETE CASCADE,
+    CONSTRAINT "chk_schedule_run_status" CHECK (status IN ('pending', 'succeeded', 'failed', 'skipped'))
+);
+
+CREATE INDEX "idx_schedule_run_schedule_id" ON schedule_runs (schedule_id, id);
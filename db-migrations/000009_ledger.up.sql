@@ -0,0 +1,11 @@
+CREATE TABLE ledger_entries (
+    id BIGSERIAL NOT NULL,
+    account_id INT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    tag TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    posted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT "pk_ledger_entry_id" PRIMARY KEY (id),
+    CONSTRAINT "fk_ledger_entry_account_id" FOREIGN KEY (account_id) REFERENCES accounts (id),
+    CONSTRAINT "uq_ledger_entry_account_id_key" UNIQUE (account_id, idempotency_key)
+);
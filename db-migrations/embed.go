@@ -0,0 +1,12 @@
+//Пакет migrations содержит SQL файлы миграций схемы базы данных, встроенные в исполняемый файл сервера.
+//Имена файлов имеют вид <версия>_<наименование>.up.sql и <версия>_<наименование>.down.sql.
+//
+//В базах данных, созданных до появления миграций, начальная миграция отмечается применённой командой
+//migrate baseline 1, после чего остальные миграции применяются командой migrate up.
+package migrations
+
+import "embed"
+
+//FS - файлы миграций
+//go:embed *.sql
+var FS embed.FS
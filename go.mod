@@ -1,6 +1,6 @@
 module github.com/vps2/accounttesttask
 
-go 1.15
+go 1.16
 
 require (
 	github.com/go-pg/pg/v10 v10.7.5
@@ -8,6 +8,12 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/spf13/cobra v1.1.3
 	github.com/stretchr/testify v1.6.1
+	go.opentelemetry.io/otel v0.16.0
+	go.opentelemetry.io/otel/exporters/otlp v0.16.0
+	go.opentelemetry.io/otel/exporters/stdout v0.16.0
+	go.opentelemetry.io/otel/sdk v0.16.0
+	golang.org/x/term v0.0.0-20201210144234-2321bbc49cbf
+	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/genproto v0.0.0-20201030142918-24207fddd1c3 // indirect
 	google.golang.org/grpc v1.35.0
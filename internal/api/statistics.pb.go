@@ -67,15 +67,229 @@ func (*Empty) Descriptor() ([]byte, []int) {
 	return file_statistics_proto_rawDescGZIP(), []int{0}
 }
 
+type StatisticsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ReadOperations  int64 `protobuf:"varint,1,opt,name=readOperations,proto3" json:"readOperations,omitempty"`
+	WriteOperations int64 `protobuf:"varint,2,opt,name=writeOperations,proto3" json:"writeOperations,omitempty"`
+	//Operations during the last polling interval per second.
+	ReadOperationsPerSecond  int64 `protobuf:"varint,3,opt,name=readOperationsPerSecond,proto3" json:"readOperationsPerSecond,omitempty"`
+	WriteOperationsPerSecond int64 `protobuf:"varint,4,opt,name=writeOperationsPerSecond,proto3" json:"writeOperationsPerSecond,omitempty"`
+	//The last reported progress of the interest and fee accrual rules, ordered by the rule name.
+	Accruals []*AccrualProgress `protobuf:"bytes,5,rep,name=accruals,proto3" json:"accruals,omitempty"`
+}
+
+func (x *StatisticsResponse) Reset() {
+	*x = StatisticsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statistics_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatisticsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatisticsResponse) ProtoMessage() {}
+
+func (x *StatisticsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_statistics_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatisticsResponse.ProtoReflect.Descriptor instead.
+func (*StatisticsResponse) Descriptor() ([]byte, []int) {
+	return file_statistics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StatisticsResponse) GetReadOperations() int64 {
+	if x != nil {
+		return x.ReadOperations
+	}
+	return 0
+}
+
+func (x *StatisticsResponse) GetWriteOperations() int64 {
+	if x != nil {
+		return x.WriteOperations
+	}
+	return 0
+}
+
+func (x *StatisticsResponse) GetReadOperationsPerSecond() int64 {
+	if x != nil {
+		return x.ReadOperationsPerSecond
+	}
+	return 0
+}
+
+func (x *StatisticsResponse) GetWriteOperationsPerSecond() int64 {
+	if x != nil {
+		return x.WriteOperationsPerSecond
+	}
+	return 0
+}
+
+func (x *StatisticsResponse) GetAccruals() []*AccrualProgress {
+	if x != nil {
+		return x.Accruals
+	}
+	return nil
+}
+
+type AccrualProgress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rule string `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	//The accrual period: a day, e.g. 2021-03-01, or a month, e.g. 2021-03.
+	Period string `protobuf:"bytes,2,opt,name=period,proto3" json:"period,omitempty"`
+	//The number of accounts processed.
+	Processed int64 `protobuf:"varint,3,opt,name=processed,proto3" json:"processed,omitempty"`
+	Posted    int64 `protobuf:"varint,4,opt,name=posted,proto3" json:"posted,omitempty"`
+	//The number of accounts that needed no posting, already had it or rejected it,
+	//e.g. because of insufficient funds or a frozen account.
+	Skipped int64 `protobuf:"varint,5,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	//The number of accounts whose posting failed with a transient error. The period is retried.
+	Failed int64 `protobuf:"varint,6,opt,name=failed,proto3" json:"failed,omitempty"`
+	//The pass over the accounts is completed.
+	Done bool `protobuf:"varint,7,opt,name=done,proto3" json:"done,omitempty"`
+}
+
+func (x *AccrualProgress) Reset() {
+	*x = AccrualProgress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_statistics_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AccrualProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccrualProgress) ProtoMessage() {}
+
+func (x *AccrualProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_statistics_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccrualProgress.ProtoReflect.Descriptor instead.
+func (*AccrualProgress) Descriptor() ([]byte, []int) {
+	return file_statistics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AccrualProgress) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *AccrualProgress) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *AccrualProgress) GetProcessed() int64 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *AccrualProgress) GetPosted() int64 {
+	if x != nil {
+		return x.Posted
+	}
+	return 0
+}
+
+func (x *AccrualProgress) GetSkipped() int64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *AccrualProgress) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *AccrualProgress) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
 var File_statistics_proto protoreflect.FileDescriptor
 
 var file_statistics_proto_rawDesc = []byte{
 	0x0a, 0x10, 0x73, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x12, 0x03, 0x61, 0x70, 0x69, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x32, 0x36, 0x0a, 0x11, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x0a,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0a, 0x2e, 0x61, 0x70, 0x69,
-	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x22, 0x8e, 0x02, 0x0a, 0x12, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x72, 0x65, 0x61, 0x64, 0x4f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0e, 0x72, 0x65, 0x61, 0x64, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x28, 0x0a, 0x0f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x4f,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x38, 0x0a, 0x17, 0x72, 0x65, 0x61,
+	0x64, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x17, 0x72, 0x65, 0x61, 0x64,
+	0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x12, 0x3a, 0x0a, 0x18, 0x77, 0x72, 0x69, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x18, 0x77, 0x72, 0x69, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12,
+	0x30, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x72, 0x75, 0x61, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x63, 0x63, 0x72, 0x75, 0x61, 0x6c, 0x50,
+	0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x52, 0x08, 0x61, 0x63, 0x63, 0x72, 0x75, 0x61, 0x6c,
+	0x73, 0x22, 0xb9, 0x01, 0x0a, 0x0f, 0x41, 0x63, 0x63, 0x72, 0x75, 0x61, 0x6c, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x75, 0x6c, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x75, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x65, 0x72,
+	0x69, 0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f,
+	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x70, 0x6f, 0x73, 0x74, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x70, 0x6f, 0x73, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70,
+	0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x32, 0x64, 0x0a,
+	0x11, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x21, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74, 0x12, 0x0a, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0a, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x2c, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0a, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -90,18 +304,23 @@ func file_statistics_proto_rawDescGZIP() []byte {
 	return file_statistics_proto_rawDescData
 }
 
-var file_statistics_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_statistics_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_statistics_proto_goTypes = []interface{}{
-	(*Empty)(nil), // 0: api.Empty
+	(*Empty)(nil),              // 0: api.Empty
+	(*StatisticsResponse)(nil), // 1: api.StatisticsResponse
+	(*AccrualProgress)(nil),    // 2: api.AccrualProgress
 }
 var file_statistics_proto_depIdxs = []int32{
-	0, // 0: api.StatisticsService.Reset:input_type -> api.Empty
-	0, // 1: api.StatisticsService.Reset:output_type -> api.Empty
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	2, // 0: api.StatisticsResponse.accruals:type_name -> api.AccrualProgress
+	0, // 1: api.StatisticsService.Reset:input_type -> api.Empty
+	0, // 2: api.StatisticsService.Get:input_type -> api.Empty
+	0, // 3: api.StatisticsService.Reset:output_type -> api.Empty
+	1, // 4: api.StatisticsService.Get:output_type -> api.StatisticsResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_statistics_proto_init() }
@@ -122,6 +341,30 @@ func file_statistics_proto_init() {
 				return nil
 			}
 		}
+		file_statistics_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatisticsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_statistics_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AccrualProgress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -129,7 +372,7 @@ func file_statistics_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_statistics_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -156,6 +399,8 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type StatisticsServiceClient interface {
 	Reset(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	//Returns the counters of operations on the accounts since the server start or the last reset.
+	Get(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StatisticsResponse, error)
 }
 
 type statisticsServiceClient struct {
@@ -175,9 +420,20 @@ func (c *statisticsServiceClient) Reset(ctx context.Context, in *Empty, opts ...
 	return out, nil
 }
 
+func (c *statisticsServiceClient) Get(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StatisticsResponse, error) {
+	out := new(StatisticsResponse)
+	err := c.cc.Invoke(ctx, "/api.StatisticsService/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StatisticsServiceServer is the server API for StatisticsService service.
 type StatisticsServiceServer interface {
 	Reset(context.Context, *Empty) (*Empty, error)
+	//Returns the counters of operations on the accounts since the server start or the last reset.
+	Get(context.Context, *Empty) (*StatisticsResponse, error)
 }
 
 // UnimplementedStatisticsServiceServer can be embedded to have forward compatible implementations.
@@ -187,6 +443,9 @@ type UnimplementedStatisticsServiceServer struct {
 func (*UnimplementedStatisticsServiceServer) Reset(context.Context, *Empty) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Reset not implemented")
 }
+func (*UnimplementedStatisticsServiceServer) Get(context.Context, *Empty) (*StatisticsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
 
 func RegisterStatisticsServiceServer(s *grpc.Server, srv StatisticsServiceServer) {
 	s.RegisterService(&_StatisticsService_serviceDesc, srv)
@@ -210,6 +469,24 @@ func _StatisticsService_Reset_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StatisticsService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatisticsServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.StatisticsService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatisticsServiceServer).Get(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StatisticsService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.StatisticsService",
 	HandlerType: (*StatisticsServiceServer)(nil),
@@ -218,6 +495,10 @@ var _StatisticsService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Reset",
 			Handler:    _StatisticsService_Reset_Handler,
 		},
+		{
+			MethodName: "Get",
+			Handler:    _StatisticsService_Get_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "statistics.proto",
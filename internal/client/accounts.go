@@ -3,22 +3,19 @@ package client
 import (
 	"context"
 	"errors"
-	"fmt"
+	"math"
 	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/client/config"
 	"github.com/vps2/accounttesttask/pkg/log"
 
 	"google.golang.org/grpc"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 type Operation int
 
 const (
@@ -40,21 +37,73 @@ func (t Operation) String() string {
 var id int32
 
 type AccountsServiceClient struct {
-	id        int32
-	addr      string
-	keys      []int
-	operation Operation
+	id     int32
+	client api.AccountsServiceClient
+	keys   keyChooser
+	//readRatio - доля запросов чтения
+	readRatio float64
+	minAmount int64
+	maxAmount int64
+	rnd       *rand.Rand
+	pacer     *Pacer
+	recorder  *Recorder
+	verifier  *Verifier
+	timeout   time.Duration
 	//
 	trigger *sync.WaitGroup
 }
 
-func NewAccountServiceClient(addr string, keys []int, op Operation) *AccountsServiceClient {
-	return &AccountsServiceClient{
+//NewAccountServiceClient создаёт воркер, который выполняет через соединение conn только операции op
+//с равномерно распределёнными ключами и суммами изменения баланса от -10 до 10 без ограничения частоты.
+func NewAccountServiceClient(conn *grpc.ClientConn, keys []int, op Operation) *AccountsServiceClient {
+	c := &AccountsServiceClient{
 		id:        atomic.AddInt32(&id, 1),
-		addr:      addr,
-		keys:      keys,
-		operation: op,
+		client:    api.NewAccountsServiceClient(conn),
+		minAmount: -10,
+		maxAmount: 10,
 	}
+	c.rnd = rand.New(rand.NewSource(time.Now().UnixNano() + int64(c.id)))
+	c.keys = newKeyChooser(keys, config.Keys{Distribution: config.KeysUniform}, c.rnd)
+	if op == OpRead {
+		c.readRatio = 1
+	}
+
+	return c
+}
+
+//WithWorkload задаёт распределение ключей, границы сумм и, если она задана, долю запросов чтения из проверенного
+//профиля нагрузки.
+func (c *AccountsServiceClient) WithWorkload(keys []int, workload *config.Workload) *AccountsServiceClient {
+	c.keys = newKeyChooser(keys, workload.Keys, c.rnd)
+	c.minAmount, c.maxAmount = workload.Amount.Min, workload.Amount.Max
+	c.timeout = workload.RequestTimeout
+	if workload.ReadRatio != nil {
+		c.readRatio = *workload.ReadRatio
+	}
+
+	return c
+}
+
+//WithRecorder задаёт сборщик статистики запросов воркера.
+func (c *AccountsServiceClient) WithRecorder(recorder *Recorder) *AccountsServiceClient {
+	c.recorder = recorder
+
+	return c
+}
+
+//WithVerifier задаёт проверку согласованности, которой передаются результаты всех запросов, в том числе
+//прерванных завершением теста.
+func (c *AccountsServiceClient) WithVerifier(verifier *Verifier) *AccountsServiceClient {
+	c.verifier = verifier
+
+	return c
+}
+
+//WithPacer задаёт общий для воркеров источник моментов запросов. Без него запросы выполняются без пауз.
+func (c *AccountsServiceClient) WithPacer(pacer *Pacer) *AccountsServiceClient {
+	c.pacer = pacer
+
+	return c
 }
 
 func (c *AccountsServiceClient) WithTrigger(trigger *sync.WaitGroup) *AccountsServiceClient {
@@ -64,54 +113,120 @@ func (c *AccountsServiceClient) WithTrigger(trigger *sync.WaitGroup) *AccountsSe
 }
 
 func (c *AccountsServiceClient) Run(ctx context.Context) error {
-	conn, err := grpc.Dial(c.addr, grpc.WithInsecure())
-	if err != nil {
-		return fmt.Errorf("[%d] %w", c.id, err)
-	}
-	defer conn.Close()
-
-	client := api.NewAccountsServiceClient(conn)
-
 	if c.trigger != nil {
 		c.trigger.Wait()
 	}
 
+	logger := log.With(log.F("worker", c.id))
+
 	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			err := c.doJob(ctx, client)
-			if err != nil {
-				log.Errorf("[%d]\t%s\n", c.id, err)
+		start := time.Now()
+		if c.pacer != nil {
+			scheduled, ok := c.pacer.Next(ctx)
+			if !ok {
+				return nil
 			}
+			start = scheduled
+		} else if ctx.Err() != nil {
+			return nil
+		}
+
+		op := c.nextOperation()
+		call := time.Now()
+		balanceId, value, err := c.doJob(ctx, logger, op, start)
+		if c.verifier != nil {
+			c.verify(op, balanceId, value, call, time.Now(), err)
+		}
+		if ctx.Err() != nil { //запрос прерван завершением теста
+			return nil
+		}
+		if c.recorder != nil {
+			c.recorder.Record(op, time.Since(start), err)
+		}
+		if err != nil {
+			logger.Error("request failed", log.F("operation", op), log.F(log.FieldError, err))
 		}
 	}
 }
 
-func (c *AccountsServiceClient) doJob(ctx context.Context, client api.AccountsServiceClient) error {
-	idx := rand.Int31n(int32(len(c.keys)))
-	balanceId := c.keys[idx]
+func (c *AccountsServiceClient) nextOperation() Operation {
+	if c.rnd.Float64() < c.readRatio {
+		return OpRead
+	}
 
-	switch c.operation {
-	case OpRead:
-		resp, err := client.GetAmount(ctx, &api.GetRequest{BalanceId: int32(balanceId)})
-		if err == nil {
-			log.Infof("[%d]\taccount_%d\trequested balance: %d\n", c.id, balanceId, resp.Amount)
+	return OpWrite
+}
+
+//nextAmount возвращает случайную ненулевую сумму в границах [minAmount, maxAmount], так как нулевое изменение
+//баланса отклоняется сервером. Ноль возвращается, только если обе границы нулевые.
+func (c *AccountsServiceClient) nextAmount() int64 {
+	if c.minAmount == 0 && c.maxAmount == 0 {
+		return 0
+	}
+
+	span := uint64(c.maxAmount - c.minAmount)
+	for {
+		amount := int64(c.rnd.Uint64())
+		if span != math.MaxUint64 {
+			amount = c.minAmount + int64(c.rnd.Uint64()%(span+1))
+		}
+		if amount != 0 {
+			return amount
 		}
+	}
+}
 
-		return err
+func (c *AccountsServiceClient) verify(op Operation, balanceId int, value int64, call, ret time.Time, err error) {
+	switch op {
+	case OpRead:
+		c.verifier.RecordRead(c.id, balanceId, value, call, ret, err)
 	case OpWrite:
-		var minBound int64 = -10
-		var maxBound int64 = 11
+		c.verifier.RecordAdd(c.id, balanceId, value, call, ret, err)
+	}
+}
+
+//doJob выполняет операцию op и возвращает ключ и прочитанный баланс или сумму его изменения. Задержка
+//отсчитывается от start - назначенного момента запроса.
+func (c *AccountsServiceClient) doJob(ctx context.Context, logger *log.Logger, op Operation, start time.Time) (int, int64, error) {
+	balanceId := c.keys.next()
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
 
-		amount := rand.Int63n(maxBound-minBound) + minBound
+	switch op {
+	case OpRead:
+		resp, err := c.client.GetAmount(ctx, &api.GetRequest{BalanceId: int32(balanceId)})
+		if err != nil {
+			return balanceId, 0, err
+		}
+		logger.Debug("requested balance",
+			log.F(log.FieldMethod, "GetAmount"),
+			log.F(log.FieldBalanceId, balanceId),
+			log.F("amount", resp.Amount),
+			log.F(log.FieldLatency, time.Since(start)))
 
-		_, err := client.AddAmount(ctx, &api.AddRequest{BalanceId: int32(balanceId), Value: amount})
-		log.Infof("[%d]\taccount_%d\tadd amount %d", c.id, balanceId, amount)
+		return balanceId, resp.Amount, nil
+	case OpWrite:
+		amount := c.nextAmount()
+
+		_, err := c.client.AddAmount(ctx, &api.AddRequest{
+			BalanceId:      int32(balanceId),
+			Value:          amount,
+			IdempotencyKey: newIdempotencyKey(),
+		})
+		if err == nil {
+			logger.Debug("added amount",
+				log.F(log.FieldMethod, "AddAmount"),
+				log.F(log.FieldBalanceId, balanceId),
+				log.F("amount", amount),
+				log.F(log.FieldLatency, time.Since(start)))
+		}
 
-		return err
+		return balanceId, amount, err
 	}
 
-	return errors.New("unknown operation")
+	return balanceId, 0, errors.New("unknown operation")
 }
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+
+	"github.com/vps2/accounttesttask/internal/api"
+
+	"google.golang.org/grpc"
+)
+
+type AdminServiceClient struct {
+	client api.AdminServiceClient
+}
+
+func NewAdminServiceClient(conn *grpc.ClientConn) *AdminServiceClient {
+	return &AdminServiceClient{
+		client: api.NewAdminServiceClient(conn),
+	}
+}
+
+func (c *AdminServiceClient) SetMinBalance(ctx context.Context, id int32, minBalance int64) error {
+	_, err := c.client.SetMinBalance(ctx, &api.SetMinBalanceRequest{BalanceId: id, MinBalance: minBalance})
+
+	return err
+}
+
+//Freeze замораживает счёт. Если debitsOnly, то запрещаются только списания.
+func (c *AdminServiceClient) Freeze(ctx context.Context, id int32, debitsOnly bool, reason string) error {
+	_, err := c.client.Freeze(ctx, &api.FreezeRequest{BalanceId: id, DebitsOnly: debitsOnly, Reason: reason})
+
+	return err
+}
+
+func (c *AdminServiceClient) Unfreeze(ctx context.Context, id int32, reason string) error {
+	_, err := c.client.Unfreeze(ctx, &api.UnfreezeRequest{BalanceId: id, Reason: reason})
+
+	return err
+}
+
+func (c *AdminServiceClient) Close(ctx context.Context, id int32, reason string) error {
+	_, err := c.client.Close(ctx, &api.CloseRequest{BalanceId: id, Reason: reason})
+
+	return err
+}
+
+//SetMetadata заменяет владельца и метки счёта.
+func (c *AdminServiceClient) SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) error {
+	_, err := c.client.SetMetadata(ctx, &api.SetMetadataRequest{BalanceId: id, Owner: owner, Labels: labels})
+
+	return err
+}
+
+//CreateSchedule регистрирует отложенную или повторяющуюся операцию и возвращает созданное расписание.
+func (c *AdminServiceClient) CreateSchedule(ctx context.Context, req *api.CreateScheduleRequest) (*api.Schedule, error) {
+	resp, err := c.client.CreateSchedule(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Schedule, nil
+}
+
+//ListSchedules возвращает расписания счёта balanceId, а если он не задан - все расписания.
+func (c *AdminServiceClient) ListSchedules(ctx context.Context, balanceId *int32) ([]*api.Schedule, error) {
+	resp, err := c.client.ListSchedules(ctx, &api.ListSchedulesRequest{BalanceId: balanceId})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Schedules, nil
+}
+
+func (c *AdminServiceClient) CancelSchedule(ctx context.Context, id string) error {
+	_, err := c.client.CancelSchedule(ctx, &api.CancelScheduleRequest{ScheduleId: id})
+
+	return err
+}
+
+//ListScheduleRuns возвращает не более limit последних запусков расписания.
+func (c *AdminServiceClient) ListScheduleRuns(ctx context.Context, id string, limit int32) ([]*api.ScheduleRun, error) {
+	resp, err := c.client.ListScheduleRuns(ctx, &api.ListScheduleRunsRequest{ScheduleId: id, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Runs, nil
+}
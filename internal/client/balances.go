@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+
+	"github.com/vps2/accounttesttask/internal/api"
+
+	"google.golang.org/grpc"
+)
+
+//BalancesClient выполняет единичные запросы к сервису счетов.
+type BalancesClient struct {
+	client api.AccountsServiceClient
+}
+
+func NewBalancesClient(conn *grpc.ClientConn) *BalancesClient {
+	return &BalancesClient{
+		client: api.NewAccountsServiceClient(conn),
+	}
+}
+
+//GetBalances возвращает балансы счетов в порядке ids. Запросы выполняются до первой ошибки.
+func (c *BalancesClient) GetBalances(ctx context.Context, ids []int32) ([]*api.GetResponse, error) {
+	balances := make([]*api.GetResponse, 0, len(ids))
+	for _, id := range ids {
+		resp, err := c.client.GetAmount(ctx, &api.GetRequest{BalanceId: id})
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, resp)
+	}
+
+	return balances, nil
+}
+
+//AddAmount изменяет баланс счёта на amount в минорных единицах валюты currency. Запрос передаётся с ключом
+//идемпотентности, поэтому при временной ошибке он повторяется.
+func (c *BalancesClient) AddAmount(ctx context.Context, id int32, amount int64, currency string) error {
+	_, err := c.client.AddAmount(ctx, &api.AddRequest{
+		BalanceId:      id,
+		Value:          amount,
+		Currency:       currency,
+		IdempotencyKey: newIdempotencyKey(),
+	})
+
+	return err
+}
+
+//Transfer переводит сумму между счетами и возвращает зачисленную сумму в минорных единицах валюты счёта зачисления.
+//Если в req не задан ключ идемпотентности, то он создаётся, чтобы при временной ошибке запрос повторялся.
+func (c *BalancesClient) Transfer(ctx context.Context, req *api.TransferRequest) (int64, error) {
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = newIdempotencyKey()
+	}
+	resp, err := c.client.Transfer(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Credited, nil
+}
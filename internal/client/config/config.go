@@ -1,33 +1,510 @@
 package config
 
 import (
+	"fmt"
+	"io"
+	"math"
+	"net"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+//Профили частоты запросов
+const (
+	RateNone     = "none"
+	RateConstant = "constant"
+	RateRamp     = "ramp"
+)
+
+//Распределения ключей запросов
+const (
+	KeysUniform = "uniform"
+	KeysZipfian = "zipfian"
+	KeysHotspot = "hotspot"
+)
+
+//MinKeepaliveTime - минимальный период проверки соединения, который допускает сервер
+const MinKeepaliveTime = 10 * time.Second
+
+//Переменные окружения. Настройка клиента переопределяется переменной EnvPrefix и пути к настройке в файле
+//в верхнем регистре, например ACCOUNTS_CLIENT_CONNECTION_TIMEOUT для connection.timeout.
+const (
+	EnvPrefix  = "ACCOUNTS_CLIENT_"
+	ProfileEnv = EnvPrefix + "PROFILE"
+)
+
 type Config struct {
-	Client struct {
-		Addr    string `yaml:"addr"`
-		Readers int    `yaml:"readers"`
-		Writers int    `yaml:"writers"`
-		Keys    []int  `yaml:"keys"`
-	}	`yaml:"client"`
+	Client Client `yaml:"client"`
+	//Server - настройки сервера из общего файла конфигурации. Клиентом не используются.
+	Server interface{} `yaml:"server"`
+}
+
+//ValidationError содержит все найденные ошибки в настройках.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e, "; ")
+}
+
+type Client struct {
+	Addr string `yaml:"addr"`
+	//Addrs - адреса нескольких серверов, между которыми распределяются вызовы. Если заданы, то Addr не используется.
+	Addrs      []string   `yaml:"addrs"`
+	Connection Connection `yaml:"connection"`
+	Readers    int        `yaml:"readers"`
+	Writers    int        `yaml:"writers"`
+	Keys       []int      `yaml:"keys"`
+	Workload   Workload   `yaml:"workload"`
+	//Profiles - именованные наборы настроек клиента, которые заменяют настройки из файла при выборе профиля
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+//Addresses возвращает адреса серверов.
+func (c *Client) Addresses() []string {
+	if len(c.Addrs) > 0 {
+		return c.Addrs
+	}
+	if c.Addr != "" {
+		return []string{c.Addr}
+	}
+
+	return nil
+}
+
+//Connection - параметры общего соединения клиентов с серверами
+type Connection struct {
+	//Timeout - таймаут одной попытки вызова. Ноль отключает таймаут.
+	Timeout time.Duration `yaml:"timeout"`
+	//KeepaliveTime - период проверки соединения при отсутствии активности. Ноль отключает проверку.
+	KeepaliveTime time.Duration `yaml:"keepalive_time"`
+	//KeepaliveTimeout - время ожидания ответа на проверку соединения, после которого оно считается разорванным
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout"`
+	Retry            Retry         `yaml:"retry"`
+}
+
+//Retry - политика повтора вызовов, которые можно безопасно повторить: чтений и изменений с ключом идемпотентности.
+//Остальные изменения не повторяются: если первая попытка была выполнена, но ответ потерян, повтор был бы отклонён
+//и успешное изменение выглядело бы ошибкой.
+type Retry struct {
+	//MaxAttempts - максимальное количество попыток, включая первую. Единица отключает повторы.
+	MaxAttempts int `yaml:"max_attempts"`
+	//InitialBackoff - пауза перед первым повтором. Каждая следующая пауза увеличивается в Multiplier раз,
+	//но не превышает MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Multiplier     float64       `yaml:"multiplier"`
+}
+
+//Workload - профиль нагрузки команды update-accounts
+type Workload struct {
+	Rate Rate `yaml:"rate"`
+	//Keys - распределение ключей запросов по списку keys
+	Keys Keys `yaml:"keys"`
+	//Amount - границы (включительно) суммы изменения баланса
+	Amount Amount `yaml:"amount"`
+	//ReadRatio - доля запросов чтения каждого воркера от 0 до 1. Если не задана, то воркеры readers только читают,
+	//а воркеры writers только изменяют балансы.
+	ReadRatio *float64 `yaml:"read_ratio"`
+	//Duration - длительность теста
+	Duration time.Duration `yaml:"duration"`
+	//RequestTimeout - таймаут запроса. Если не задан, то запрос ожидает ответа до завершения теста.
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+//Rate - частота запросов всех воркеров. При заданной частоте моменты запросов не зависят от времени ответов
+//сервера (открытая модель нагрузки).
+type Rate struct {
+	//Profile - none (каждый воркер выполняет запросы без пауз), constant или ramp
+	Profile string `yaml:"profile"`
+	//RPS - количество запросов в секунду, для ramp - конечное
+	RPS float64 `yaml:"rps"`
+	//StartRPS - начальное количество запросов в секунду для ramp
+	StartRPS float64 `yaml:"start_rps"`
+	//RampDuration - время линейного изменения частоты от StartRPS до RPS для ramp
+	RampDuration time.Duration `yaml:"ramp_duration"`
+}
+
+type Keys struct {
+	//Distribution - uniform, zipfian или hotspot
+	Distribution string `yaml:"distribution"`
+	//ZipfS - параметр s (больше 1) распределения Ципфа. Чем он больше, тем чаще запрашиваются первые ключи.
+	ZipfS float64 `yaml:"zipf_s"`
+	//HotFraction - доля горячих ключей (первых в списке) для hotspot
+	HotFraction float64 `yaml:"hot_fraction"`
+	//HotProbability - доля запросов к горячим ключам для hotspot
+	HotProbability float64 `yaml:"hot_probability"`
+}
+
+type Amount struct {
+	Min int64 `yaml:"min"`
+	Max int64 `yaml:"max"`
+}
+
+//Default возвращает настройки по умолчанию.
+func Default() *Config {
+	cfg := &Config{}
+	cfg.Client.Connection = Connection{
+		Timeout:          5 * time.Second,
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 10 * time.Second,
+		Retry: Retry{
+			MaxAttempts:    4,
+			InitialBackoff: 50 * time.Millisecond,
+			MaxBackoff:     2 * time.Second,
+			Multiplier:     2,
+		},
+	}
+	cfg.Client.Workload = Workload{
+		Rate: Rate{Profile: RateNone},
+		Keys: Keys{
+			Distribution:   KeysUniform,
+			ZipfS:          1.1,
+			HotFraction:    0.2,
+			HotProbability: 0.8,
+		},
+		Amount:   Amount{Min: -10, Max: 10},
+		Duration: 15 * time.Second,
+	}
+
+	return cfg
+}
+
+//Load собирает настройки из значений по умолчанию, файла path (если задан), профиля profile и переменных
+//окружения ACCOUNTS_CLIENT_*. Если профиль не задан, то используется профиль из переменной ACCOUNTS_CLIENT_PROFILE.
+//Неизвестные поля в файле и профилях считаются ошибкой. Ошибки профилей и значений переменных окружения
+//возвращаются все сразу в виде ValidationError.
+func Load(path, profile string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewDecoder(file)
+		decoder.SetStrict(true)
+		if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var errs ValidationError
+
+	names := make([]string, 0, len(cfg.Client.Profiles))
+	for name := range cfg.Client.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := (&Client{}).applyProfile(cfg.Client.Profiles[name]); err != nil {
+			errs = append(errs, fmt.Sprintf("profiles.%s: %s", name, err))
+		}
+	}
+
+	if profile == "" && lookupEnv != nil {
+		profile, _ = lookupEnv(ProfileEnv)
+	}
+	if profile != "" {
+		if p, ok := cfg.Client.Profiles[profile]; !ok {
+			errs = append(errs, fmt.Sprintf("profile %q is not defined, available profiles: [%s]", profile,
+				strings.Join(names, ", ")))
+		} else if len(errs) == 0 {
+			cfg.Client.applyProfile(p)
+		}
+	}
+
+	if lookupEnv != nil {
+		for _, s := range envSettings {
+			if v, ok := lookupEnv(EnvPrefix + s.name); ok && v != "" {
+				if err := s.set(&cfg.Client, v); err != nil {
+					errs = append(errs, fmt.Sprintf("environment variable %s%s: invalid value %q", EnvPrefix, s.name, v))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return cfg, nil
 }
 
-func New(path string) (*Config, error) {
-	file, err := os.Open(path)
+//applyProfile заменяет настройки клиента заданными в профиле.
+func (c *Client) applyProfile(profile map[string]interface{}) error {
+	if _, ok := profile["profiles"]; ok {
+		return fmt.Errorf("profiles must not be nested")
+	}
+
+	data, err := yaml.Marshal(profile)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	return yaml.UnmarshalStrict(data, c)
+}
+
+//envSetting описывает настройку клиента, которую можно переопределить переменной окружения.
+type envSetting struct {
+	//name - имя переменной окружения без префикса EnvPrefix
+	name string
+	set  func(c *Client, value string) error
+}
+
+var envSettings = []envSetting{
+	{"ADDR", func(c *Client, v string) error {
+		c.Addr, c.Addrs = v, nil
+		return nil
+	}},
+	{"ADDRS", func(c *Client, v string) error {
+		c.Addrs = strings.Split(v, ",")
+		return nil
+	}},
+	intEnv("READERS", func(c *Client) *int { return &c.Readers }),
+	intEnv("WRITERS", func(c *Client) *int { return &c.Writers }),
+	{"KEYS", func(c *Client, v string) error {
+		keys := make([]int, 0)
+		for _, k := range strings.Split(v, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(k))
+			if err != nil {
+				return err
+			}
+			keys = append(keys, n)
+		}
+		c.Keys = keys
+		return nil
+	}},
+	durationEnv("CONNECTION_TIMEOUT", func(c *Client) *time.Duration { return &c.Connection.Timeout }),
+	durationEnv("CONNECTION_KEEPALIVE_TIME", func(c *Client) *time.Duration { return &c.Connection.KeepaliveTime }),
+	durationEnv("CONNECTION_KEEPALIVE_TIMEOUT", func(c *Client) *time.Duration { return &c.Connection.KeepaliveTimeout }),
+	intEnv("CONNECTION_RETRY_MAX_ATTEMPTS", func(c *Client) *int { return &c.Connection.Retry.MaxAttempts }),
+	durationEnv("CONNECTION_RETRY_INITIAL_BACKOFF", func(c *Client) *time.Duration { return &c.Connection.Retry.InitialBackoff }),
+	durationEnv("CONNECTION_RETRY_MAX_BACKOFF", func(c *Client) *time.Duration { return &c.Connection.Retry.MaxBackoff }),
+	floatEnv("CONNECTION_RETRY_MULTIPLIER", func(c *Client) *float64 { return &c.Connection.Retry.Multiplier }),
+	{"WORKLOAD_RATE_PROFILE", func(c *Client, v string) error {
+		c.Workload.Rate.Profile = v
+		return nil
+	}},
+	floatEnv("WORKLOAD_RATE_RPS", func(c *Client) *float64 { return &c.Workload.Rate.RPS }),
+	floatEnv("WORKLOAD_RATE_START_RPS", func(c *Client) *float64 { return &c.Workload.Rate.StartRPS }),
+	durationEnv("WORKLOAD_RATE_RAMP_DURATION", func(c *Client) *time.Duration { return &c.Workload.Rate.RampDuration }),
+	{"WORKLOAD_KEYS_DISTRIBUTION", func(c *Client, v string) error {
+		c.Workload.Keys.Distribution = v
+		return nil
+	}},
+	floatEnv("WORKLOAD_KEYS_ZIPF_S", func(c *Client) *float64 { return &c.Workload.Keys.ZipfS }),
+	floatEnv("WORKLOAD_KEYS_HOT_FRACTION", func(c *Client) *float64 { return &c.Workload.Keys.HotFraction }),
+	floatEnv("WORKLOAD_KEYS_HOT_PROBABILITY", func(c *Client) *float64 { return &c.Workload.Keys.HotProbability }),
+	int64Env("WORKLOAD_AMOUNT_MIN", func(c *Client) *int64 { return &c.Workload.Amount.Min }),
+	int64Env("WORKLOAD_AMOUNT_MAX", func(c *Client) *int64 { return &c.Workload.Amount.Max }),
+	{"WORKLOAD_READ_RATIO", func(c *Client, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			c.Workload.ReadRatio = &f
+		}
+		return err
+	}},
+	durationEnv("WORKLOAD_DURATION", func(c *Client) *time.Duration { return &c.Workload.Duration }),
+	durationEnv("WORKLOAD_REQUEST_TIMEOUT", func(c *Client) *time.Duration { return &c.Workload.RequestTimeout }),
+}
+
+func intEnv(name string, field func(c *Client) *int) envSetting {
+	return envSetting{name, func(c *Client, v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			*field(c) = n
+		}
+		return err
+	}}
+}
+
+func int64Env(name string, field func(c *Client) *int64) envSetting {
+	return envSetting{name, func(c *Client, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			*field(c) = n
+		}
+		return err
+	}}
+}
+
+func floatEnv(name string, field func(c *Client) *float64) envSetting {
+	return envSetting{name, func(c *Client, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			*field(c) = f
+		}
+		return err
+	}}
+}
+
+func durationEnv(name string, field func(c *Client) *time.Duration) envSetting {
+	return envSetting{name, func(c *Client, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			*field(c) = d
+		}
+		return err
+	}}
+}
+
+//Validate проверяет адреса серверов и параметры соединения, общие для всех команд, и возвращает ValidationError
+//со всеми найденными ошибками.
+func (cfg *Config) Validate() error {
+	var errs ValidationError
+
+	addrs := cfg.Client.Addresses()
+	if len(addrs) == 0 {
+		errs = append(errs, "addr or addrs must be set")
+	}
+	for _, addr := range addrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, fmt.Sprintf("server address must be host:port, %s", err))
+		}
+	}
+	errs = append(errs, cfg.Client.Connection.validate()...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+//ValidateWorkload проверяет воркеров, ключи и профиль нагрузки команды update-accounts и возвращает
+//ValidationError со всеми найденными ошибками.
+func (cfg *Config) ValidateWorkload() error {
+	var errs ValidationError
+
+	c := &cfg.Client
+	if c.Readers < 0 {
+		errs = append(errs, fmt.Sprintf("readers must not be negative, got %d", c.Readers))
+	}
+	if c.Writers < 0 {
+		errs = append(errs, fmt.Sprintf("writers must not be negative, got %d", c.Writers))
+	}
+	if c.Readers <= 0 && c.Writers <= 0 {
+		errs = append(errs, "at least one of readers and writers must be positive")
+	}
+
+	if len(c.Keys) == 0 {
+		errs = append(errs, "keys must not be empty")
+	}
+	seen := make(map[int]bool, len(c.Keys))
+	for _, k := range c.Keys {
+		if k < 0 || k > math.MaxInt32 {
+			errs = append(errs, fmt.Sprintf("keys must be account ids from 0 to %d, got %d", math.MaxInt32, k))
+		} else if seen[k] {
+			errs = append(errs, fmt.Sprintf("keys must be unique, got %d more than once", k))
+		}
+		seen[k] = true
+	}
+
+	errs = append(errs, c.Workload.validate()...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+//validate возвращает все найденные ошибки в параметрах соединения.
+func (c *Connection) validate() []string {
+	var errs []string
+
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Sprintf("connection.timeout must not be negative, got %s", c.Timeout))
+	}
+	if c.KeepaliveTime < 0 || c.KeepaliveTime > 0 && c.KeepaliveTime < MinKeepaliveTime {
+		errs = append(errs, fmt.Sprintf("connection.keepalive_time must be zero or at least %s, got %s", MinKeepaliveTime,
+			c.KeepaliveTime))
+	}
+	if c.KeepaliveTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("connection.keepalive_timeout must not be negative, got %s", c.KeepaliveTimeout))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Sprintf("connection.retry.max_attempts must be positive, got %d", c.Retry.MaxAttempts))
+	}
+	if c.Retry.MaxAttempts > 1 {
+		if c.Retry.InitialBackoff <= 0 {
+			errs = append(errs, fmt.Sprintf("connection.retry.initial_backoff must be positive, got %s",
+				c.Retry.InitialBackoff))
+		}
+		if c.Retry.MaxBackoff < c.Retry.InitialBackoff {
+			errs = append(errs, fmt.Sprintf("connection.retry.max_backoff must not be less than initial_backoff,"+
+				" got %s < %s", c.Retry.MaxBackoff, c.Retry.InitialBackoff))
+		}
+		if c.Retry.Multiplier < 1 {
+			errs = append(errs, fmt.Sprintf("connection.retry.multiplier must not be less than 1, got %v",
+				c.Retry.Multiplier))
+		}
 	}
-	defer file.Close()
 
-	decoder := yaml.NewDecoder(file)
+	return errs
+}
+
+//validate возвращает все найденные ошибки в профиле нагрузки.
+func (w *Workload) validate() []string {
+	var errs []string
 
-	cfg := Config{}
-	if err := decoder.Decode(&cfg); err != nil {
-		return nil, err
+	switch w.Rate.Profile {
+	case RateNone:
+	case RateConstant, RateRamp:
+		if w.Rate.RPS <= 0 {
+			errs = append(errs, fmt.Sprintf("workload.rate.rps must be positive, got %v", w.Rate.RPS))
+		}
+		if w.Rate.Profile == RateRamp {
+			if w.Rate.StartRPS < 0 {
+				errs = append(errs, fmt.Sprintf("workload.rate.start_rps must not be negative, got %v", w.Rate.StartRPS))
+			}
+			if w.Rate.RampDuration <= 0 {
+				errs = append(errs, fmt.Sprintf("workload.rate.ramp_duration must be positive, got %s", w.Rate.RampDuration))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("workload.rate.profile must be %q, %q or %q, got %q", RateNone, RateConstant,
+			RateRamp, w.Rate.Profile))
+	}
+
+	switch w.Keys.Distribution {
+	case KeysUniform:
+	case KeysZipfian:
+		if w.Keys.ZipfS <= 1 {
+			errs = append(errs, fmt.Sprintf("workload.keys.zipf_s must be greater than 1, got %v", w.Keys.ZipfS))
+		}
+	case KeysHotspot:
+		if w.Keys.HotFraction <= 0 || w.Keys.HotFraction > 1 {
+			errs = append(errs, fmt.Sprintf("workload.keys.hot_fraction must be in (0, 1], got %v", w.Keys.HotFraction))
+		}
+		if w.Keys.HotProbability < 0 || w.Keys.HotProbability > 1 {
+			errs = append(errs, fmt.Sprintf("workload.keys.hot_probability must be in [0, 1], got %v", w.Keys.HotProbability))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("workload.keys.distribution must be %q, %q or %q, got %q", KeysUniform,
+			KeysZipfian, KeysHotspot, w.Keys.Distribution))
+	}
+
+	if w.Amount.Min > w.Amount.Max {
+		errs = append(errs, fmt.Sprintf("workload.amount.min must not be greater than workload.amount.max, got %d > %d",
+			w.Amount.Min, w.Amount.Max))
+	}
+	if w.ReadRatio != nil && (*w.ReadRatio < 0 || *w.ReadRatio > 1) {
+		errs = append(errs, fmt.Sprintf("workload.read_ratio must be in [0, 1], got %v", *w.ReadRatio))
+	}
+	if w.Duration <= 0 {
+		errs = append(errs, fmt.Sprintf("workload.duration must be positive, got %s", w.Duration))
+	}
+	if w.RequestTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("workload.request_timeout must not be negative, got %s", w.RequestTimeout))
 	}
 
-	return &cfg, nil
+	return errs
 }
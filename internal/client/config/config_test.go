@@ -0,0 +1,211 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+//writeConfig записывает content во временный файл конфигурации и возвращает путь к нему.
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfig(t, `
+client:
+ addr: "localhost:8080"
+ readers: 3
+ keys: [1, 2]
+ connection:
+  timeout: 1s
+ profiles:
+  staging:
+   addrs: ["staging-1:8080", "staging-2:8080"]
+   readers: 5
+   connection:
+    retry:
+     max_attempts: 2
+server:
+ addr: ":8080"
+`)
+
+	env := map[string]string{
+		"ACCOUNTS_CLIENT_PROFILE":             "staging",
+		"ACCOUNTS_CLIENT_READERS":             "7",
+		"ACCOUNTS_CLIENT_WORKLOAD_READ_RATIO": "0.5",
+		"ACCOUNTS_CLIENT_WRITERS":             "",
+	}
+
+	cfg, err := Load(path, "", lookupEnv(env))
+	assert.NilError(t, err)
+
+	c := cfg.Client
+	assert.DeepEqual(t, []string{"staging-1:8080", "staging-2:8080"}, c.Addresses())
+	assert.Equal(t, 7, c.Readers)
+	assert.Equal(t, 0, c.Writers)
+	assert.DeepEqual(t, []int{1, 2}, c.Keys)
+	assert.Equal(t, time.Second, c.Connection.Timeout)
+	assert.Equal(t, 2, c.Connection.Retry.MaxAttempts)
+	assert.Equal(t, 2*time.Second, c.Connection.Retry.MaxBackoff)
+	assert.Equal(t, 0.5, *c.Workload.ReadRatio)
+
+	cfg, err = Load(path, "", nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"localhost:8080"}, cfg.Client.Addresses())
+	assert.Equal(t, 3, cfg.Client.Readers)
+
+	env = map[string]string{"ACCOUNTS_CLIENT_ADDR": "localhost:9000", "ACCOUNTS_CLIENT_KEYS": "3, 4"}
+	cfg, err = Load(path, "staging", lookupEnv(env))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"localhost:9000"}, cfg.Client.Addresses())
+	assert.DeepEqual(t, []int{3, 4}, cfg.Client.Keys)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown field",
+			content: "client:\n adr: \"localhost:8080\"\n",
+			wantErr: "field adr not found",
+		},
+		{
+			name:    "unknown profile",
+			content: "client:\n profiles:\n  local: {}\n  staging: {}\n",
+			profile: "prod",
+			wantErr: `profile "prod" is not defined, available profiles: [local, staging]`,
+		},
+		{
+			name:    "unknown field in unused profile",
+			content: "client:\n profiles:\n  local: {}\n  staging:\n   readerz: 1\n",
+			profile: "local",
+			wantErr: "profiles.staging: yaml: unmarshal errors",
+		},
+		{
+			name:    "nested profiles",
+			content: "client:\n profiles:\n  local:\n   profiles: {}\n",
+			wantErr: "profiles.local: profiles must not be nested",
+		},
+		{
+			name:    "invalid environment variables",
+			content: "client:\n addr: \"localhost:8080\"\n",
+			env:     map[string]string{"ACCOUNTS_CLIENT_READERS": "many", "ACCOUNTS_CLIENT_KEYS": "1,x"},
+			wantErr: `invalid configuration: environment variable ACCOUNTS_CLIENT_READERS: invalid value "many";` +
+				` environment variable ACCOUNTS_CLIENT_KEYS: invalid value "1,x"`,
+		},
+		{
+			name:    "empty file",
+			content: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content), tt.profile, lookupEnv(tt.env))
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		modify          func(c *Client)
+		wantErr         string
+		wantWorkloadErr string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:    "no address",
+			modify:  func(c *Client) { c.Addr = "" },
+			wantErr: "invalid configuration: addr or addrs must be set",
+		},
+		{
+			name:    "address without port",
+			modify:  func(c *Client) { c.Addrs = []string{"localhost:8080", "localhost"} },
+			wantErr: "server address must be host:port, address localhost: missing port in address",
+		},
+		{
+			name:    "invalid connection",
+			modify:  func(c *Client) { c.Connection.KeepaliveTime = time.Second },
+			wantErr: "connection.keepalive_time must be zero or at least 10s, got 1s",
+		},
+		{
+			name:            "no workers",
+			modify:          func(c *Client) { c.Readers, c.Writers = 0, 0 },
+			wantWorkloadErr: "at least one of readers and writers must be positive",
+		},
+		{
+			name:            "no keys",
+			modify:          func(c *Client) { c.Keys = nil },
+			wantWorkloadErr: "keys must not be empty",
+		},
+		{
+			name:            "invalid keys",
+			modify:          func(c *Client) { c.Keys = []int{1, -1, 1} },
+			wantWorkloadErr: "keys must be account ids from 0 to 2147483647, got -1; keys must be unique, got 1 more than once",
+		},
+		{
+			name:            "invalid workload",
+			modify:          func(c *Client) { c.Workload.Duration = 0 },
+			wantWorkloadErr: "workload.duration must be positive, got 0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Client.Addr = "localhost:8080"
+			cfg.Client.Readers = 1
+			cfg.Client.Keys = []int{1, 2}
+			if tt.modify != nil {
+				tt.modify(&cfg.Client)
+			}
+
+			if err := cfg.Validate(); tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			if err := cfg.ValidateWorkload(); tt.wantWorkloadErr != "" {
+				assert.ErrorContains(t, err, tt.wantWorkloadErr)
+			} else {
+				assert.NilError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/vps2/accounttesttask/internal/client/config"
+	"github.com/vps2/accounttesttask/pkg/tracing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+//roundRobinConfig - конфигурация сервиса, распределяющая вызовы между всеми адресами по очереди
+const roundRobinConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+//resolverId - счётчик для уникальных схем адресов соединений с несколькими серверами
+var resolverId int32
+
+//Dial создаёт соединение с серверами addrs, общее для всех клиентов. Вызовы распределяются между серверами
+//по очереди, а вызовы, которые можно безопасно повторить, повторяются согласно cfg.Retry. Соединение
+//устанавливается в фоне, поэтому ошибка возвращается только для неверных параметров.
+func Dial(addrs []string, cfg config.Connection) (*grpc.ClientConn, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("server address is not set")
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			retryInterceptor(cfg.Retry, cfg.Timeout),
+		),
+	}
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+
+	if len(addrs) == 1 {
+		return grpc.Dial(addrs[0], opts...)
+	}
+
+	r := manual.NewBuilderWithScheme(fmt.Sprintf("accounts%d", atomic.AddInt32(&resolverId, 1)))
+	state := resolver.State{}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	r.InitialState(state)
+
+	opts = append(opts, grpc.WithResolvers(r), grpc.WithDefaultServiceConfig(roundRobinConfig))
+
+	return grpc.Dial(r.Scheme()+":///servers", opts...)
+}
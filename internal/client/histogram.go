@@ -0,0 +1,158 @@
+package client
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+//subBucketBits определяет точность гистограммы: значения хранятся с относительной погрешностью не более
+//1/2^(subBucketBits-1), то есть около 1,6%.
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+)
+
+//Histogram - гистограмма задержек в стиле HDR: интервалы значений растут логарифмически, а внутри каждого
+//интервала делятся на равные части, поэтому относительная погрешность процентилей постоянна во всём диапазоне.
+//Значения хранятся в микросекундах. Методы типа не потокобезопасны.
+type Histogram struct {
+	counts []int64
+	total  int64
+	sum    float64
+	min    uint64
+	max    uint64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]int64, subBucketCount),
+		min:    math.MaxUint64,
+	}
+}
+
+//Record добавляет значение в гистограмму. Отрицательные значения считаются нулевыми.
+func (h *Histogram) Record(d time.Duration) {
+	var v uint64
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+
+	idx := bucketIndex(v)
+	if idx >= len(h.counts) {
+		counts := make([]int64, idx+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[idx]++
+
+	h.total++
+	h.sum += float64(v)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+//Merge добавляет к гистограмме значения other.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.total == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]int64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+
+	h.total += other.total
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+func (h *Histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	return time.Duration(h.min) * time.Microsecond
+}
+
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max) * time.Microsecond
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	return time.Duration(h.sum / float64(h.total) * float64(time.Microsecond))
+}
+
+//Percentile возвращает значение, не превышающее которое имеют p процентов записанных значений, с точностью
+//до погрешности гистограммы.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := bucketUpperBound(i)
+			if v > h.max {
+				v = h.max
+			}
+
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+
+	return h.Max()
+}
+
+//bucketIndex возвращает индекс интервала значения v. Значения меньше subBucketCount хранятся точно, а для
+//больших значений интервал определяется старшими subBucketBits битами.
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+
+	shift := bits.Len64(v) - subBucketBits
+
+	return shift*subBucketHalf + int(v>>uint(shift))
+}
+
+//bucketUpperBound возвращает наибольшее значение интервала с индексом idx.
+func bucketUpperBound(idx int) uint64 {
+	if idx < subBucketCount {
+		return uint64(idx)
+	}
+
+	shift := idx/subBucketHalf - 1
+	m := uint64(idx - shift*subBucketHalf)
+
+	return (m+1)<<uint(shift) - 1
+}
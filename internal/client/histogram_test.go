@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	h := NewHistogram()
+	for v := 1; v <= 100000; v++ {
+		h.Record(time.Duration(v) * time.Microsecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, time.Microsecond},
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{99.9, 99900 * time.Microsecond},
+		{100, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		got := h.Percentile(tt.p)
+		relErr := math.Abs(float64(got-tt.want)) / float64(tt.want)
+		assert.Assert(t, relErr <= 1.0/subBucketHalf, "Percentile(%v) = %s, expected %s", tt.p, got, tt.want)
+	}
+
+	assert.Equal(t, int64(100000), h.Count())
+	assert.Equal(t, time.Microsecond, h.Min())
+	assert.Equal(t, 100*time.Millisecond, h.Max())
+	assert.Equal(t, 50000500*time.Nanosecond, h.Mean())
+}
+
+func TestHistogram_bucketBounds(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 129, 255, 256, 1000, 123456789, math.MaxUint32, math.MaxUint64 >> 1} {
+		idx := bucketIndex(v)
+		upper := bucketUpperBound(idx)
+
+		assert.Assert(t, v <= upper, "value %d, upper bound %d", v, upper)
+		assert.Assert(t, float64(upper-v) <= float64(v)/subBucketHalf, "value %d, upper bound %d", v, upper)
+		if idx > 0 {
+			assert.Assert(t, bucketUpperBound(idx-1) < v, "value %d, previous upper bound %d", v, bucketUpperBound(idx-1))
+		}
+	}
+}
+
+func TestCollector_Report(t *testing.T) {
+	collector := NewCollector()
+
+	reader, writer := collector.NewRecorder(), collector.NewRecorder()
+	reader.Record(OpRead, time.Millisecond, nil)
+	reader.Record(OpRead, 3*time.Millisecond, nil)
+	writer.Record(OpWrite, 2*time.Millisecond, nil)
+	writer.Record(OpWrite, 2*time.Millisecond, status.Error(codes.FailedPrecondition, "insufficient funds"))
+	writer.Record(OpWrite, 2*time.Millisecond, errors.New("connection refused"))
+
+	report := collector.Report(collector.start.Add(time.Second))
+
+	assert.Equal(t, 3, len(report.Operations))
+	assert.Equal(t, "Reader", report.Operations[0].Operation)
+	assert.Equal(t, int64(2), report.Operations[0].Succeeded)
+	assert.Equal(t, 3.0, report.Operations[0].Latency.Max)
+	assert.DeepEqual(t, map[string]int64{"FailedPrecondition": 1, "Unknown": 1}, report.Operations[1].Errors)
+
+	total := report.Operations[2]
+	assert.Equal(t, "total", total.Operation)
+	assert.Equal(t, int64(5), total.Requests)
+	assert.Equal(t, int64(3), total.Succeeded)
+	assert.Equal(t, 5.0, total.Throughput)
+	assert.Assert(t, total.Latency.P50 >= 2 && total.Latency.P50 <= 2*(1+1.0/subBucketHalf), "p50 %v", total.Latency.P50)
+}
@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+//CheckLinearizability проверяет, что история операций history линеаризуема относительно модели счёта, баланс
+//которого изменяется только операциями add: каждое чтение возвращает сумму начального баланса и изменений,
+//линеаризованных до него. Ключи проверяются независимо. Итоговый баланс из final учитывается как чтение после
+//завершения всех операций, поэтому изменения с неизвестным результатом могут как вступить в силу, так
+//и не вступить. Отклонённые операции и чтения с неизвестным результатом не влияют на баланс и пропускаются.
+//Возвращает упорядоченный список ключей, история которых не линеаризуема, или ошибку ctx, если проверка прервана.
+func CheckLinearizability(ctx context.Context, history []HistoryEntry, initial, final map[int]int64) ([]int, error) {
+	var end int64
+	perKey := make(map[int][]linearOp)
+	for _, h := range history {
+		if h.Call > end {
+			end = h.Call
+		}
+		if h.Return > end {
+			end = h.Return
+		}
+
+		switch {
+		case h.Outcome == OutcomeFailed:
+			continue
+		case h.Outcome == OutcomeUnknown && h.Operation == HistoryRead:
+			continue
+		}
+
+		op := linearOp{read: h.Operation == HistoryRead, value: h.Value, call: h.Call, ret: h.Return}
+		if h.Outcome == OutcomeUnknown {
+			op.ret = math.MaxInt64
+		}
+		perKey[h.Key] = append(perKey[h.Key], op)
+	}
+
+	keys := make([]int, 0, len(perKey))
+	for key := range perKey {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	var res []int
+	for _, key := range keys {
+		ops := perKey[key]
+		if balance, ok := final[key]; ok {
+			ops = append(ops, linearOp{read: true, value: balance, call: end + 1, ret: end + 2})
+		}
+
+		ok, err := checkKey(ctx, ops, initial[key])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			res = append(res, key)
+		}
+	}
+
+	return res, nil
+}
+
+//linearOp - операция над балансом одного ключа. Для операции с неизвестным результатом момент ответа равен
+//math.MaxInt64.
+type linearOp struct {
+	read  bool
+	value int64
+	call  int64
+	ret   int64
+}
+
+//step применяет операцию к балансу state и возвращает новый баланс и признак того, что операция допустима.
+func (op linearOp) step(state int64) (int64, bool) {
+	if op.read {
+		return state, op.value == state
+	}
+
+	return state + op.value, true
+}
+
+//event - вызов или ответ операции в двусвязном списке событий истории
+type event struct {
+	op         int
+	call       bool
+	match      *event //ответ для вызова
+	prev, next *event
+}
+
+//lift удаляет из списка вызов e и соответствующий ему ответ.
+func (e *event) lift() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	m := e.match
+	m.prev.next = m.next
+	if m.next != nil {
+		m.next.prev = m.prev
+	}
+}
+
+//unlift возвращает в список вызов e и соответствующий ему ответ.
+func (e *event) unlift() {
+	m := e.match
+	m.prev.next = m
+	if m.next != nil {
+		m.next.prev = m
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+//cacheKey - множество линеаризованных операций и баланс после них
+type cacheKey struct {
+	linearized string
+	state      int64
+}
+
+//bitset - множество номеров операций
+type bitset []uint64
+
+func (b bitset) set(i int)   { b[i/64] |= 1 << uint(i%64) }
+func (b bitset) clear(i int) { b[i/64] &^= 1 << uint(i%64) }
+
+func (b bitset) key() string {
+	buf := make([]byte, 0, len(b)*8)
+	for _, w := range b {
+		for i := uint(0); i < 64; i += 8 {
+			buf = append(buf, byte(w>>i))
+		}
+	}
+
+	return string(buf)
+}
+
+//checkKey проверяет линеаризуемость операций над одним балансом с начальным значением initial алгоритмом
+//Wing и Gong с отсечением повторяющихся состояний (Lowe): операции линеаризуются в порядке вызовов, пока
+//не встретится ответ ещё не линеаризованной операции, после чего выполняется возврат к последнему выбору.
+func checkKey(ctx context.Context, ops []linearOp, initial int64) (bool, error) {
+	events := make([]*event, 0, 2*len(ops))
+	for i := range ops {
+		call := &event{op: i, call: true}
+		ret := &event{op: i}
+		call.match = ret
+		events = append(events, call, ret)
+	}
+	//при равных моментах вызов предшествует ответу, то есть такие операции считаются одновременными
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, tj := eventTime(ops, events[i]), eventTime(ops, events[j])
+		if ti != tj {
+			return ti < tj
+		}
+
+		return events[i].call && !events[j].call
+	})
+
+	head := &event{}
+	prev := head
+	for _, e := range events {
+		e.prev = prev
+		prev.next = e
+		prev = e
+	}
+
+	type frame struct {
+		e     *event
+		state int64
+	}
+
+	var (
+		stack      []frame
+		state      = initial
+		linearized = make(bitset, (len(ops)+63)/64)
+		cache      = make(map[cacheKey]struct{})
+		steps      int
+	)
+
+	e := head.next
+	for head.next != nil {
+		if steps++; steps%10000 == 0 && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+
+		if e.call {
+			if next, ok := ops[e.op].step(state); ok {
+				linearized.set(e.op)
+				key := cacheKey{linearized: linearized.key(), state: next}
+				if _, seen := cache[key]; !seen {
+					cache[key] = struct{}{}
+					stack = append(stack, frame{e: e, state: state})
+					state = next
+					e.lift()
+					e = head.next
+					continue
+				}
+				linearized.clear(e.op)
+			}
+			e = e.next
+			continue
+		}
+
+		//ответ операции, которую не удалось линеаризовать раньше
+		if len(stack) == 0 {
+			return false, nil
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		state = top.state
+		linearized.clear(top.e.op)
+		top.e.unlift()
+		e = top.e.next
+	}
+
+	return true, nil
+}
+
+func eventTime(ops []linearOp, e *event) int64 {
+	if e.call {
+		return ops[e.op].call
+	}
+
+	return ops[e.op].ret
+}
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vps2/accounttesttask/pkg/traffic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//ReplayResult - результат повторного выполнения записанного вызова
+type ReplayResult struct {
+	//Index - номер вызова в записи, начиная с 1
+	Index  int
+	Method string
+	//RecordedCode и Code - коды состояния записанного и повторного вызовов
+	RecordedCode string
+	Code         string
+	Message      string
+	//Diff - описание отличия результата от записанного, пустое при совпадении
+	Diff string
+}
+
+//Replayer повторно выполняет записанные вызовы и сравнивает их результаты с записанными.
+type Replayer struct {
+	conn *grpc.ClientConn
+	//speed - множитель скорости воспроизведения относительно записи. Ноль означает воспроизведение без пауз.
+	speed   float64
+	ignored map[protoreflect.Name]bool
+}
+
+//NewReplayer создаёт воспроизведение с исходными интервалами между вызовами.
+func NewReplayer(conn *grpc.ClientConn) *Replayer {
+	return &Replayer{
+		conn:    conn,
+		speed:   1,
+		ignored: make(map[protoreflect.Name]bool),
+	}
+}
+
+//WithSpeed задаёт множитель скорости воспроизведения, например 2 для воспроизведения вдвое быстрее записи.
+//При нулевой скорости вызовы выполняются последовательно без пауз в порядке записи.
+func (r *Replayer) WithSpeed(speed float64) *Replayer {
+	r.speed = speed
+
+	return r
+}
+
+//WithIgnoredFields задаёт наименования полей ответов (на любом уровне вложенности), которые не сравниваются,
+//например "version".
+func (r *Replayer) WithIgnoredFields(names ...string) *Replayer {
+	for _, name := range names {
+		r.ignored[protoreflect.Name(name)] = true
+	}
+
+	return r
+}
+
+//replayCall - подготовленный к воспроизведению вызов
+type replayCall struct {
+	rec *traffic.Record
+	//method - наименование метода при вызове, которое может отличаться от записанного регистром первой буквы
+	method   string
+	req      proto.Message
+	recorded proto.Message
+	resp     proto.Message
+}
+
+//Replay выполняет вызовы records и возвращает их результаты в порядке записи. При воспроизведении с заданной
+//скоростью вызов выполняется в момент, соответствующий его смещению от первого вызова записи, независимо
+//от завершения предыдущих. Если ctx завершается раньше, то возвращаются результаты выполненных вызовов
+//и ошибка ctx.
+func (r *Replayer) Replay(ctx context.Context, records []traffic.Record) ([]ReplayResult, error) {
+	calls := make([]replayCall, 0, len(records))
+	for i := range records {
+		rec := &records[i]
+		md, err := traffic.FindMethod(rec.Method)
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i+1, err)
+		}
+		req, recorded, err := rec.Messages()
+		if err != nil {
+			return nil, fmt.Errorf("call %d: %w", i+1, err)
+		}
+		_, resp, _ := traffic.NewMessages(rec.Method)
+		calls = append(calls, replayCall{
+			rec:      rec,
+			method:   fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name()),
+			req:      req,
+			recorded: recorded,
+			resp:     resp,
+		})
+	}
+
+	results := make([]ReplayResult, len(calls))
+	done := make([]bool, len(calls))
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range calls {
+		if r.speed > 0 {
+			offset := calls[i].rec.Time.Sub(calls[0].rec.Time)
+			if !sleep(ctx, time.Until(start.Add(time.Duration(float64(offset)/r.speed)))) {
+				break
+			}
+		} else if ctx.Err() != nil {
+			break
+		}
+
+		replay := func(i int) {
+			results[i] = r.call(ctx, i, &calls[i])
+			done[i] = true
+		}
+		if r.speed > 0 {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				replay(i)
+			}(i)
+		} else {
+			replay(i)
+		}
+	}
+	wg.Wait()
+
+	var res []ReplayResult
+	for i, result := range results {
+		if done[i] {
+			res = append(res, result)
+		}
+	}
+
+	return res, ctx.Err()
+}
+
+//call выполняет вызов c с номером i и сравнивает его результат с записанным.
+func (r *Replayer) call(ctx context.Context, i int, c *replayCall) ReplayResult {
+	err := r.conn.Invoke(ctx, c.method, c.req, c.resp)
+
+	st := status.Convert(err)
+	result := ReplayResult{
+		Index:        i + 1,
+		Method:       c.rec.Method,
+		RecordedCode: c.rec.Code,
+		Code:         st.Code().String(),
+		Message:      st.Message(),
+	}
+
+	switch {
+	case result.Code != result.RecordedCode:
+		result.Diff = fmt.Sprintf("code %s, recorded %s", result.Code, result.RecordedCode)
+	case err == nil:
+		r.clearIgnored(c.recorded.ProtoReflect())
+		r.clearIgnored(c.resp.ProtoReflect())
+		if !proto.Equal(c.recorded, c.resp) {
+			result.Diff = fmt.Sprintf("response %s, recorded %s", formatMessage(c.resp), formatMessage(c.recorded))
+		}
+	}
+
+	return result
+}
+
+//clearIgnored очищает в сообщении m и вложенных в него сообщениях поля, которые не сравниваются.
+func (r *Replayer) clearIgnored(m protoreflect.Message) {
+	if len(r.ignored) == 0 {
+		return
+	}
+
+	var cleared []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case r.ignored[fd.Name()]:
+			cleared = append(cleared, fd)
+		case fd.Message() == nil || fd.IsMap():
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.clearIgnored(list.Get(i).Message())
+			}
+		default:
+			r.clearIgnored(v.Message())
+		}
+
+		return true
+	})
+
+	for _, fd := range cleared {
+		m.Clear(fd)
+	}
+}
+
+func formatMessage(m proto.Message) string {
+	return protojson.MarshalOptions{}.Format(m)
+}
+
+//sleep ожидает d или завершения ctx и возвращает false, если ctx завершён.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"google.golang.org/grpc/status"
+)
+
+//Recorder собирает статистику запросов одного воркера.
+type Recorder struct {
+	mu  sync.Mutex
+	ops map[Operation]*operationStats
+}
+
+//operationStats - статистика запросов одного вида
+type operationStats struct {
+	latency   *Histogram
+	succeeded int64
+	//errors - количество ошибок по кодам состояния gRPC
+	errors map[string]int64
+}
+
+func newOperationStats() *operationStats {
+	return &operationStats{
+		latency: NewHistogram(),
+		errors:  make(map[string]int64),
+	}
+}
+
+func (s *operationStats) merge(other *operationStats) {
+	s.latency.Merge(other.latency)
+	s.succeeded += other.succeeded
+	for code, n := range other.errors {
+		s.errors[code] += n
+	}
+}
+
+//Record учитывает запрос op с задержкой latency, завершившийся ошибкой err.
+func (r *Recorder) Record(op Operation, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.ops[op]
+	if !ok {
+		stats = newOperationStats()
+		r.ops[op] = stats
+	}
+
+	stats.latency.Record(latency)
+	if err == nil {
+		stats.succeeded++
+	} else {
+		stats.errors[status.Code(err).String()]++
+	}
+}
+
+//Collector объединяет статистику воркеров нагрузочного теста.
+type Collector struct {
+	mu        sync.Mutex
+	recorders []*Recorder
+	start     time.Time
+}
+
+//NewCollector создаёт сборщик статистики. Продолжительность теста отсчитывается от момента создания.
+func NewCollector() *Collector {
+	return &Collector{
+		start: time.Now(),
+	}
+}
+
+//NewRecorder возвращает сборщик статистики для очередного воркера.
+func (c *Collector) NewRecorder() *Recorder {
+	r := &Recorder{ops: make(map[Operation]*operationStats)}
+
+	c.mu.Lock()
+	c.recorders = append(c.recorders, r)
+	c.mu.Unlock()
+
+	return r
+}
+
+//snapshot возвращает объединённую статистику всех воркеров по видам запросов.
+func (c *Collector) snapshot() map[Operation]*operationStats {
+	c.mu.Lock()
+	recorders := c.recorders
+	c.mu.Unlock()
+
+	res := make(map[Operation]*operationStats)
+	for _, r := range recorders {
+		r.mu.Lock()
+		for op, stats := range r.ops {
+			total, ok := res[op]
+			if !ok {
+				total = newOperationStats()
+				res[op] = total
+			}
+			total.merge(stats)
+		}
+		r.mu.Unlock()
+	}
+
+	return res
+}
+
+//StartProgress выводит в лог ход теста с интервалом interval до завершения ctx: количество запросов и ошибок,
+//частоту запросов за интервал и 99-й процентиль задержки с начала теста.
+func (c *Collector) StartProgress(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var prev int64
+		for {
+			select {
+			case now := <-ticker.C:
+				report := c.Report(now)
+				total := report.Operations[len(report.Operations)-1]
+				log.Info("progress",
+					log.F("elapsed", now.Sub(c.start).Round(time.Second)),
+					log.F("requests", total.Requests),
+					log.F("errors", total.Requests-total.Succeeded),
+					log.F("rps", fmt.Sprintf("%.1f", float64(total.Requests-prev)/interval.Seconds())),
+					log.F("p99_ms", total.Latency.P99))
+				prev = total.Requests
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+//Report - итоги нагрузочного теста
+type Report struct {
+	Duration   Duration          `json:"duration"`
+	Operations []OperationReport `json:"operations"`
+}
+
+//OperationReport - итоги запросов одного вида. Задержки выражены в миллисекундах.
+type OperationReport struct {
+	Operation  string           `json:"operation"`
+	Requests   int64            `json:"requests"`
+	Succeeded  int64            `json:"succeeded"`
+	Errors     map[string]int64 `json:"errors"`
+	Throughput float64          `json:"throughput_rps"`
+	Latency    LatencyReport    `json:"latency_ms"`
+}
+
+type LatencyReport struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p99_9"`
+	Max  float64 `json:"max"`
+}
+
+//Duration выводится в JSON в формате time.Duration, например "15s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+//Report возвращает итоги теста к моменту now. Запросы всех видов суммируются в строке total.
+func (c *Collector) Report(now time.Time) *Report {
+	elapsed := now.Sub(c.start)
+	report := &Report{Duration: Duration(elapsed)}
+
+	ops := c.snapshot()
+	total := newOperationStats()
+	for _, op := range []Operation{OpRead, OpWrite} {
+		if stats, ok := ops[op]; ok {
+			report.Operations = append(report.Operations, newOperationReport(op.String(), stats, elapsed))
+			total.merge(stats)
+		}
+	}
+	report.Operations = append(report.Operations, newOperationReport("total", total, elapsed))
+
+	return report
+}
+
+func newOperationReport(name string, stats *operationStats, elapsed time.Duration) OperationReport {
+	h := stats.latency
+	report := OperationReport{
+		Operation: name,
+		Requests:  h.Count(),
+		Succeeded: stats.succeeded,
+		Errors:    stats.errors,
+		Latency: LatencyReport{
+			Min:  milliseconds(h.Min()),
+			Mean: milliseconds(h.Mean()),
+			P50:  milliseconds(h.Percentile(50)),
+			P90:  milliseconds(h.Percentile(90)),
+			P99:  milliseconds(h.Percentile(99)),
+			P999: milliseconds(h.Percentile(99.9)),
+			Max:  milliseconds(h.Max()),
+		},
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+
+	return report
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+//Print выводит итоги таблицей, за которой следует количество ошибок по кодам состояния gRPC.
+func (r *Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tSUCCEEDED\tERRORS\tRPS\tMIN\tMEAN\tP50\tP90\tP99\tP99.9\tMAX\t")
+	for _, op := range r.Operations {
+		l := op.Latency
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n", op.Operation, op.Requests,
+			op.Succeeded, op.Requests-op.Succeeded, op.Throughput, l.Min, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "duration %s, latencies in ms\n", time.Duration(r.Duration).Round(time.Millisecond))
+
+	for _, op := range r.Operations[:len(r.Operations)-1] {
+		codes := make([]string, 0, len(op.Errors))
+		for code := range op.Errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "%s errors %s: %d\n", op.Operation, code, op.Errors[code])
+		}
+	}
+
+	return nil
+}
+
+//WriteJSON выводит итоги в формате JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
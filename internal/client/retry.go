@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/client/config"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//retryInterceptor ограничивает каждую попытку вызова таймаутом timeout и повторяет с экспоненциально растущими
+//паузами вызовы, которые можно безопасно повторить, если они завершились временной ошибкой. Повторы прекращаются
+//при завершении контекста вызова.
+func retryInterceptor(policy config.Retry, timeout time.Duration) grpc.UnaryClientInterceptor {
+	var (
+		mu  sync.Mutex
+		rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	)
+	jitter := func(d time.Duration) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		//пауза выбирается случайно от d/2 до d, чтобы повторы разных клиентов не совпадали по времени
+		return d/2 + time.Duration(rnd.Int63n(int64(d/2)+1))
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts := 1
+		if isRetryable(req) && policy.MaxAttempts > 1 {
+			attempts = policy.MaxAttempts
+		}
+
+		backoff := policy.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := invokeAttempt(ctx, timeout, method, req, reply, cc, invoker, opts...)
+			if err == nil || attempt >= attempts || !isTemporary(err) || ctx.Err() != nil {
+				return err
+			}
+
+			log.FromContext(ctx).Debug("retrying call",
+				log.F(log.FieldMethod, method),
+				log.F("attempt", attempt),
+				log.F(log.FieldError, err))
+
+			if !sleep(ctx, jitter(backoff)) {
+				return err
+			}
+			backoff = time.Duration(float64(backoff) * policy.Multiplier)
+			if backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+	}
+}
+
+//invokeAttempt выполняет одну попытку вызова с таймаутом timeout, если он задан.
+func invokeAttempt(ctx context.Context, timeout time.Duration, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+//isRetryable сообщает, можно ли повторить вызов с запросом req без риска повторного изменения. Повторяются чтения
+//и изменения с ключом идемпотентности: сервер не выполняет их повторно и возвращает результат первой попытки.
+//Остальные изменения, в том числе с ожидаемой версией счёта и над резервированием, не повторяются: если первая
+//попытка была выполнена, но ответ не получен, то повтор отклоняется с кодом Aborted или NotFound, и выполненное
+//изменение выглядит для вызывающего неудачным.
+func isRetryable(req interface{}) bool {
+	switch r := req.(type) {
+	case *api.GetRequest, *api.ListAccountsRequest, *api.ListSchedulesRequest, *api.ListScheduleRunsRequest:
+		return true
+	case *api.AddRequest:
+		return r.IdempotencyKey != ""
+	case *api.AuthorizeRequest:
+		return r.IdempotencyKey != ""
+	case *api.TransferRequest:
+		return r.IdempotencyKey != ""
+	}
+
+	return false
+}
+
+//newIdempotencyKey возвращает случайный ключ идемпотентности изменения. Все попытки одного вызова передают один
+//и тот же ключ.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	crand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+//isTemporary сообщает, что вызов завершился ошибкой, которая может не повториться: сервер недоступен, перегружен
+//или не ответил за время попытки.
+func isTemporary(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/client/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func TestRetryInterceptor(t *testing.T) {
+	version := int64(3)
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	deadline := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	aborted := status.Error(codes.Aborted, "version mismatch")
+
+	tests := []struct {
+		name         string
+		req          interface{}
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "read succeeds after retries",
+			req:          &api.GetRequest{BalanceId: 1},
+			errs:         []error{unavailable, unavailable, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "read fails after max attempts",
+			req:          &api.GetRequest{BalanceId: 1},
+			errs:         []error{unavailable, unavailable, unavailable, unavailable, nil},
+			wantAttempts: 4,
+			wantErr:      unavailable,
+		},
+		{
+			name:         "write without idempotency key is not retried",
+			req:          &api.AddRequest{BalanceId: 1, Value: 10},
+			errs:         []error{unavailable, nil},
+			wantAttempts: 1,
+			wantErr:      unavailable,
+		},
+		{
+			name:         "write with version is not retried",
+			req:          &api.AddRequest{BalanceId: 1, Value: 10, ExpectedVersion: &version},
+			errs:         []error{unavailable, nil},
+			wantAttempts: 1,
+			wantErr:      unavailable,
+		},
+		{
+			name:         "write with idempotency key is retried",
+			req:          &api.AddRequest{BalanceId: 1, Value: 10, ExpectedVersion: &version, IdempotencyKey: "k"},
+			errs:         []error{unavailable, deadline, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "authorize with idempotency key is retried",
+			req:          &api.AuthorizeRequest{BalanceId: 1, Amount: 10, TtlSeconds: 60, IdempotencyKey: "k"},
+			errs:         []error{unavailable, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "transfer with idempotency key is retried",
+			req:          &api.TransferRequest{FromBalanceId: 1, ToBalanceId: 2, Amount: 10, IdempotencyKey: "k"},
+			errs:         []error{deadline, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "transfer without idempotency key is not retried",
+			req:          &api.TransferRequest{FromBalanceId: 1, ToBalanceId: 2, Amount: 10},
+			errs:         []error{deadline, nil},
+			wantAttempts: 1,
+			wantErr:      deadline,
+		},
+		{
+			name:         "set amount is not retried",
+			req:          &api.SetAmountRequest{BalanceId: 1, Amount: 10, Version: version},
+			errs:         []error{deadline, nil},
+			wantAttempts: 1,
+			wantErr:      deadline,
+		},
+		{
+			name:         "capture is not retried",
+			req:          &api.CaptureRequest{HoldId: "hold", Amount: 10},
+			errs:         []error{unavailable, nil},
+			wantAttempts: 1,
+			wantErr:      unavailable,
+		},
+		{
+			name:         "permanent error is not retried",
+			req:          &api.ListAccountsRequest{},
+			errs:         []error{aborted, nil},
+			wantAttempts: 1,
+			wantErr:      aborted,
+		},
+	}
+
+	policy := config.Retry{
+		MaxAttempts:    4,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				deadline, ok := ctx.Deadline()
+				assert.Assert(t, ok && time.Until(deadline) <= time.Second, "attempt deadline is not set")
+
+				attempts++
+				return tt.errs[attempts-1]
+			}
+
+			err := retryInterceptor(policy, time.Second)(context.Background(), "/api.AccountsService/Method", tt.req,
+				nil, nil, invoker)
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRetryInterceptor_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var attempts int
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		cancel()
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+
+	policy := config.Retry{MaxAttempts: 4, InitialBackoff: time.Hour, MaxBackoff: time.Hour, Multiplier: 2}
+	err := retryInterceptor(policy, 0)(ctx, "/api.AccountsService/GetAmount", &api.GetRequest{}, nil, nil, invoker)
+
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,100 @@
+package scenario
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+//junitProblem - описание невыполненного ожидания или ошибки в отчёте
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+//WriteJUnit записывает результаты suites в w в формате JUnit XML. Каждый файл сценариев является набором тестов,
+//а каждый сценарий - тестом. Если в сценарии есть ошибки, то он отмечается как ошибочный, иначе при невыполненных
+//ожиданиях - как непройденный.
+func WriteJUnit(w io.Writer, suites []*Suite) error {
+	report := junitTestSuites{}
+
+	var total time.Duration
+	for _, s := range suites {
+		suite := junitTestSuite{
+			Name:      s.Name,
+			Tests:     len(s.Results),
+			Time:      seconds(s.Duration),
+			Timestamp: s.Start.UTC().Format("2006-01-02T15:04:05"),
+		}
+
+		for _, r := range s.Results {
+			tc := junitTestCase{Name: r.Name, Classname: s.Name, Time: seconds(r.Duration)}
+			switch {
+			case len(r.Errors) > 0:
+				tc.Error = newJUnitProblem(append(append([]string(nil), r.Errors...), r.Failures...))
+				suite.Errors++
+			case len(r.Failures) > 0:
+				tc.Failure = newJUnitProblem(r.Failures)
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Suites = append(report.Suites, suite)
+		total += s.Duration
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+//newJUnitProblem возвращает описание, сообщением которого является первое нарушение, а текстом - все нарушения.
+func newJUnitProblem(problems []string) *junitProblem {
+	return &junitProblem{Message: problems[0], Text: strings.Join(problems, "\n")}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,504 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/api"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Client - вызовы сервиса счетов, которые выполняют шаги сценариев
+type Client interface {
+	GetBalances(ctx context.Context, ids []int32) ([]*api.GetResponse, error)
+	AddAmount(ctx context.Context, id int32, amount int64, currency string) error
+	Transfer(ctx context.Context, req *api.TransferRequest) (int64, error)
+}
+
+//Result - результат выполнения сценария
+type Result struct {
+	Name     string
+	Duration time.Duration
+	//Failures - невыполненные ожидания
+	Failures []string
+	//Errors - ошибки в шагах, из-за которых их не удалось выполнить, например неизвестная переменная
+	Errors []string
+}
+
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0 && len(r.Errors) == 0
+}
+
+//Suite - результаты сценариев одного файла
+type Suite struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Results  []Result
+}
+
+//Runner выполняет сценарии.
+type Runner struct {
+	client Client
+	vars   map[string]string
+}
+
+func NewRunner(client Client) *Runner {
+	return &Runner{client: client}
+}
+
+//WithVars задаёт переменные, которые переопределяют переменные файлов и сценариев.
+func (r *Runner) WithVars(vars map[string]string) *Runner {
+	r.vars = vars
+	return r
+}
+
+//Run выполняет сценарии файла f по порядку. Сценарий завершается на первом шаге, который не удалось выполнить
+//или результат которого не совпал с ожидаемым. Ветви параллельного блока выполняются до конца независимо друг
+//от друга. После завершения ctx оставшиеся сценарии не выполняются.
+func (r *Runner) Run(ctx context.Context, f *File) *Suite {
+	suite := &Suite{Name: f.Name, Start: time.Now()}
+
+	for _, sc := range f.Scenarios {
+		if ctx.Err() != nil {
+			break
+		}
+
+		vars := make(map[string]string)
+		for _, src := range []map[string]string{f.Vars, sc.Vars, r.vars} {
+			for name, value := range src {
+				vars[name] = value
+			}
+		}
+
+		ex := &execution{}
+		start := time.Now()
+		r.runSteps(ctx, sc.Steps, vars, "", ex)
+
+		suite.Results = append(suite.Results, Result{
+			Name:     sc.Name,
+			Duration: time.Since(start),
+			Failures: ex.failures,
+			Errors:   ex.errors,
+		})
+	}
+	suite.Duration = time.Since(suite.Start)
+
+	return suite
+}
+
+//execution собирает нарушения одного сценария из всех его ветвей.
+type execution struct {
+	mu       sync.Mutex
+	failures []string
+	errors   []string
+}
+
+func (ex *execution) add(path string, err error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	var failure *failureError
+	if errors.As(err, &failure) {
+		ex.failures = append(ex.failures, path+": "+err.Error())
+	} else {
+		ex.errors = append(ex.errors, path+": "+err.Error())
+	}
+}
+
+//failureError - несовпадение результата вызова с ожидаемым
+type failureError struct {
+	msg string
+}
+
+func (e *failureError) Error() string {
+	return e.msg
+}
+
+func failuref(format string, v ...interface{}) error {
+	return &failureError{fmt.Sprintf(format, v...)}
+}
+
+//runSteps выполняет шаги по порядку до первого нарушения и сообщает, были ли выполнены все шаги.
+func (r *Runner) runSteps(ctx context.Context, steps []Step, vars map[string]string, path string, ex *execution) bool {
+	for i := range steps {
+		step := &steps[i]
+		stepPath := fmt.Sprintf("%sstep %d (%s)", path, i+1, step)
+
+		if ctx.Err() != nil {
+			ex.add(stepPath, ctx.Err())
+			return false
+		}
+
+		switch {
+		case step.Loop != nil:
+			if !r.runLoop(ctx, step.Loop, vars, stepPath, ex) {
+				return false
+			}
+		case step.Parallel != nil:
+			if !r.runParallel(ctx, step.Parallel, vars, stepPath, ex) {
+				return false
+			}
+		default:
+			if err := r.runStep(ctx, step, vars); err != nil {
+				ex.add(stepPath, err)
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *Runner) runLoop(ctx context.Context, loop *Loop, vars map[string]string, path string, ex *execution) bool {
+	values := append([]string(nil), loop.Values...)
+	for i := 1; i <= loop.Times; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+
+	for i, value := range values {
+		iterVars := copyVars(vars)
+		iterPath := fmt.Sprintf("%s > iteration %d > ", path, i+1)
+		if loop.Var != "" {
+			iterVars[loop.Var] = value
+			iterPath = fmt.Sprintf("%s > %s=%s > ", path, loop.Var, value)
+		}
+		if !r.runSteps(ctx, loop.Steps, iterVars, iterPath, ex) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Runner) runParallel(ctx context.Context, branches []Branch, vars map[string]string, path string, ex *execution) bool {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		passed = true
+	)
+	for i, b := range branches {
+		name := b.Name
+		if name == "" {
+			name = fmt.Sprintf("branch %d", i+1)
+		}
+		times := b.Times
+		if times == 0 {
+			times = 1
+		}
+
+		for n := 1; n <= times; n++ {
+			branchVars := copyVars(vars)
+			if b.Var != "" {
+				branchVars[b.Var] = strconv.Itoa(n)
+			}
+			branchPath := fmt.Sprintf("%s > %s > ", path, name)
+			if times > 1 {
+				branchPath = fmt.Sprintf("%s > %s #%d > ", path, name, n)
+			}
+
+			wg.Add(1)
+			go func(steps []Step) {
+				defer wg.Done()
+
+				if !r.runSteps(ctx, steps, branchVars, branchPath, ex) {
+					mu.Lock()
+					passed = false
+					mu.Unlock()
+				}
+			}(b.Steps)
+		}
+	}
+	wg.Wait()
+
+	return passed
+}
+
+//runStep выполняет шаг без вложенных шагов.
+func (r *Runner) runStep(ctx context.Context, step *Step, vars map[string]string) error {
+	switch {
+	case step.Set != nil:
+		for name, value := range step.Set {
+			expanded, err := expand(value, vars)
+			if err != nil {
+				return err
+			}
+			if n, err := evalInt(expanded); err == nil {
+				expanded = strconv.FormatInt(n, 10)
+			}
+			vars[name] = expanded
+		}
+		return nil
+	case step.Sleep != "":
+		d, _ := time.ParseDuration(step.Sleep)
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	fields, err := r.call(ctx, step, vars)
+	if err = checkResult(step.Expect, fields, err, vars); err != nil {
+		return err
+	}
+	for name, field := range step.Save {
+		vars[name] = fields[field]
+	}
+
+	return nil
+}
+
+//callError - ошибка вызова сервера, результат которого проверяется ожиданиями шага
+type callError struct {
+	err error
+}
+
+func (e *callError) Error() string {
+	return e.err.Error()
+}
+
+//call выполняет вызов сервера и возвращает поля ответа по именам. Ошибка вызова возвращается как *callError.
+func (r *Runner) call(ctx context.Context, step *Step, vars map[string]string) (map[string]string, error) {
+	switch {
+	case step.Get != nil:
+		id, err := evalId(step.Get.Id, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		balances, err := r.client.GetBalances(ctx, []int32{id})
+		if err != nil {
+			return nil, &callError{err}
+		}
+		b := balances[0]
+
+		return map[string]string{
+			FieldAmount:    strconv.FormatInt(b.Amount, 10),
+			FieldAvailable: strconv.FormatInt(b.Available, 10),
+			FieldCurrency:  b.Currency,
+			FieldVersion:   strconv.FormatInt(b.Version, 10),
+		}, nil
+	case step.Transfer != nil:
+		t := step.Transfer
+		from, err := evalId(t.From, vars)
+		if err != nil {
+			return nil, err
+		}
+		to, err := evalId(t.To, vars)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := eval(t.Amount, vars)
+		if err != nil {
+			return nil, err
+		}
+		currency, err := expand(t.Currency, vars)
+		if err != nil {
+			return nil, err
+		}
+		fxRate, err := expand(t.FXRate, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		credited, err := r.client.Transfer(ctx, &api.TransferRequest{
+			FromBalanceId: from,
+			ToBalanceId:   to,
+			Amount:        amount,
+			Currency:      currency,
+			FxRate:        fxRate,
+		})
+		if err != nil {
+			return nil, &callError{err}
+		}
+
+		return map[string]string{FieldCredited: strconv.FormatInt(credited, 10)}, nil
+	}
+
+	add := step.addStep()
+	id, err := evalId(add.Id, vars)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := eval(add.Amount, vars)
+	if err != nil {
+		return nil, err
+	}
+	if (step.Credit != nil || step.Debit != nil) && amount <= 0 {
+		return nil, fmt.Errorf("%s amount must be positive, got %d", step.action(), amount)
+	}
+	if step.Debit != nil {
+		amount = -amount
+	}
+	currency, err := expand(add.Currency, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.client.AddAmount(ctx, id, amount, currency); err != nil {
+		return nil, &callError{err}
+	}
+
+	return map[string]string{}, nil
+}
+
+//checkResult сравнивает результат вызова с ожиданиями expect. Ошибки, не связанные с вызовом, возвращаются без
+//изменений.
+func checkResult(expect *Expect, fields map[string]string, err error, vars map[string]string) error {
+	var callErr *callError
+	if err != nil && !errors.As(err, &callErr) {
+		return err
+	}
+
+	if expect == nil {
+		expect = &Expect{}
+	}
+	want := codeNames[expect.expectedCode()]
+	if callErr != nil {
+		st := status.Convert(callErr.err)
+		if st.Code() != want {
+			return failuref("expected code %s, got %s: %s", want, st.Code(), st.Message())
+		}
+		return nil
+	}
+	if want != codes.OK {
+		return failuref("expected code %s, got %s", want, codes.OK)
+	}
+
+	for _, field := range []string{FieldAmount, FieldAvailable, FieldCurrency, FieldVersion, FieldCredited} {
+		wantValue := expect.fields()[field]
+		if wantValue == nil {
+			continue
+		}
+
+		value, err := expand(*wantValue, vars)
+		if err != nil {
+			return err
+		}
+		if field != FieldCurrency {
+			n, err := evalInt(value)
+			if err != nil {
+				return err
+			}
+			value = strconv.FormatInt(n, 10)
+		}
+
+		if fields[field] != value {
+			return failuref("expected %s %s, got %s", field, value, fields[field])
+		}
+	}
+
+	return nil
+}
+
+//varPattern - ссылка на переменную в значении шага
+var varPattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+//expand подставляет в s значения переменных vars.
+func expand(s string, vars map[string]string) (string, error) {
+	var err error
+	result := varPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		value, ok := vars[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable %q", name)
+		}
+		return value
+	})
+
+	return result, err
+}
+
+//eval подставляет переменные в выражение s и вычисляет его.
+func eval(s string, vars map[string]string) (int64, error) {
+	expanded, err := expand(s, vars)
+	if err != nil {
+		return 0, err
+	}
+
+	return evalInt(expanded)
+}
+
+func evalId(s string, vars map[string]string) (int32, error) {
+	id, err := eval(s, vars)
+	if err != nil {
+		return 0, err
+	}
+	if id < 0 || id > 1<<31-1 {
+		return 0, fmt.Errorf("invalid account id %d", id)
+	}
+
+	return int32(id), nil
+}
+
+//evalInt вычисляет целочисленное выражение из чисел, сложения и вычитания, например "100 + 50 - -3".
+func evalInt(s string) (int64, error) {
+	var (
+		sum int64
+		i   int
+	)
+	skipSpaces := func() {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+	}
+
+	for first := true; ; first = false {
+		sign := int64(1)
+		skipSpaces()
+		if !first {
+			if i == len(s) {
+				return sum, nil
+			}
+			if s[i] != '+' && s[i] != '-' {
+				return 0, fmt.Errorf("invalid integer expression %q", s)
+			}
+			if s[i] == '-' {
+				sign = -1
+			}
+			i++
+			skipSpaces()
+		}
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			if s[i] == '-' {
+				sign = -sign
+			}
+			i++
+		}
+
+		j := i
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j == i {
+			return 0, fmt.Errorf("invalid integer expression %q", s)
+		}
+		n, err := strconv.ParseInt(s[i:j], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer expression %q: %w", s, err)
+		}
+		sum += sign * n
+		i = j
+	}
+}
+
+func copyVars(vars map[string]string) map[string]string {
+	c := make(map[string]string, len(vars))
+	for name, value := range vars {
+		c[name] = value
+	}
+
+	return c
+}
@@ -0,0 +1,379 @@
+//Пакет scenario описывает сценарии проверки сервера счетов в YAML и выполняет их.
+//
+//Файл сценариев содержит переменные и список сценариев, которые выполняются по порядку:
+//
+//	name: overdraft
+//	vars:
+//	  account: 1
+//	scenarios:
+//	  - name: debit beyond the balance is rejected
+//	    steps:
+//	      - get: {id: "${account}"}
+//	        save: {before: amount}
+//	      - credit: {id: "${account}", amount: 100}
+//	      - debit: {id: "${account}", amount: "${before} + 150"}
+//	        expect: {code: FailedPrecondition}
+//	      - get: {id: "${account}"}
+//	        expect: {amount: "${before} + 100"}
+//
+//Значения шагов и ожиданий могут содержать переменные ${name} и целочисленные выражения со сложением и
+//вычитанием. Переменные, заданные внутри loop и parallel, видны только внутри них.
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v2"
+)
+
+//Поля ответов, которые можно сохранить в переменные и проверить
+const (
+	FieldAmount    = "amount"
+	FieldAvailable = "available"
+	FieldCurrency  = "currency"
+	FieldVersion   = "version"
+	FieldCredited  = "credited"
+)
+
+//File - файл сценариев
+type File struct {
+	//Name - имя набора сценариев. По умолчанию используется имя файла.
+	Name string `yaml:"name"`
+	//Vars - переменные, доступные во всех сценариях
+	Vars      map[string]string `yaml:"vars"`
+	Scenarios []Scenario        `yaml:"scenarios"`
+}
+
+type Scenario struct {
+	Name string `yaml:"name"`
+	//Vars - переменные сценария, дополняющие и переопределяющие переменные файла
+	Vars  map[string]string `yaml:"vars"`
+	Steps []Step            `yaml:"steps"`
+}
+
+//Step - шаг сценария. В шаге задаётся ровно одно действие.
+type Step struct {
+	//Name - имя шага в отчёте. По умолчанию шаг описывается действием.
+	Name string `yaml:"name"`
+
+	Get      *GetStep      `yaml:"get"`
+	Add      *AddStep      `yaml:"add"`
+	Credit   *AddStep      `yaml:"credit"`
+	Debit    *AddStep      `yaml:"debit"`
+	Transfer *TransferStep `yaml:"transfer"`
+	//Set задаёт значения переменных
+	Set   map[string]string `yaml:"set"`
+	Sleep string            `yaml:"sleep"`
+	Loop  *Loop             `yaml:"loop"`
+	//Parallel - ветви, выполняемые одновременно
+	Parallel []Branch `yaml:"parallel"`
+
+	//Expect - ожидаемый результат вызова. Если он не задан, то вызов должен завершиться успешно.
+	Expect *Expect `yaml:"expect"`
+	//Save сохраняет поля ответа в переменные: имя переменной -> имя поля
+	Save map[string]string `yaml:"save"`
+}
+
+type GetStep struct {
+	Id string `yaml:"id"`
+}
+
+//AddStep изменяет баланс счёта. Для credit и debit сумма должна быть положительной, для add отрицательная сумма
+//списывается.
+type AddStep struct {
+	Id       string `yaml:"id"`
+	Amount   string `yaml:"amount"`
+	Currency string `yaml:"currency"`
+}
+
+type TransferStep struct {
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	Amount   string `yaml:"amount"`
+	Currency string `yaml:"currency"`
+	FXRate   string `yaml:"fx_rate"`
+}
+
+//Loop повторяет шаги Times раз или по разу для каждого из Values. Номер повторения, начиная с 1, или значение
+//доступны в переменной Var.
+type Loop struct {
+	Var    string   `yaml:"var"`
+	Times  int      `yaml:"times"`
+	Values []string `yaml:"values"`
+	Steps  []Step   `yaml:"steps"`
+}
+
+//Branch - ветвь параллельного блока. Ветвь выполняется в Times копиях одновременно, номер копии, начиная с 1,
+//доступен в переменной Var.
+type Branch struct {
+	Name  string `yaml:"name"`
+	Var   string `yaml:"var"`
+	Times int    `yaml:"times"`
+	Steps []Step `yaml:"steps"`
+}
+
+//Expect - ожидаемый результат вызова. Не заданные поля не проверяются.
+type Expect struct {
+	//Code - имя кода состояния gRPC, например FailedPrecondition. По умолчанию OK.
+	Code      string  `yaml:"code"`
+	Amount    *string `yaml:"amount"`
+	Available *string `yaml:"available"`
+	Currency  *string `yaml:"currency"`
+	Version   *string `yaml:"version"`
+	Credited  *string `yaml:"credited"`
+}
+
+//codeNames - коды состояния gRPC по именам
+var codeNames = func() map[string]codes.Code {
+	names := make(map[string]codes.Code)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		names[c.String()] = c
+	}
+
+	return names
+}()
+
+//Load читает файл сценариев и проверяет его.
+func Load(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return f, nil
+}
+
+//Parse читает сценарии из r и проверяет их. Неизвестные поля считаются ошибкой.
+func Parse(r io.Reader) (*File, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.SetStrict(true)
+
+	f := &File{}
+	if err := decoder.Decode(f); err != nil {
+		return nil, err
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File) validate() error {
+	if len(f.Scenarios) == 0 {
+		return errors.New("no scenarios")
+	}
+
+	names := make(map[string]bool)
+	for i, sc := range f.Scenarios {
+		if sc.Name == "" {
+			return fmt.Errorf("scenario %d: name is not set", i+1)
+		}
+		if names[sc.Name] {
+			return fmt.Errorf("scenario %q is defined more than once", sc.Name)
+		}
+		names[sc.Name] = true
+
+		if err := validateSteps(sc.Steps); err != nil {
+			return fmt.Errorf("scenario %q: %w", sc.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateSteps(steps []Step) error {
+	if len(steps) == 0 {
+		return errors.New("no steps")
+	}
+	for i := range steps {
+		if err := steps[i].validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) validate() error {
+	actions := 0
+	for _, set := range []bool{s.Get != nil, s.Add != nil, s.Credit != nil, s.Debit != nil, s.Transfer != nil,
+		s.Set != nil, s.Sleep != "", s.Loop != nil, s.Parallel != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return errors.New("exactly one of get, add, credit, debit, transfer, set, sleep, loop and parallel must be set")
+	}
+
+	//поля ответа, которые можно сохранить и проверить для действия шага
+	var fields []string
+	switch {
+	case s.Get != nil:
+		fields = []string{FieldAmount, FieldAvailable, FieldCurrency, FieldVersion}
+		if s.Get.Id == "" {
+			return errors.New("get: id is not set")
+		}
+	case s.Add != nil, s.Credit != nil, s.Debit != nil:
+		step := s.addStep()
+		if step.Id == "" || step.Amount == "" {
+			return fmt.Errorf("%s: id and amount must be set", s.action())
+		}
+	case s.Transfer != nil:
+		fields = []string{FieldCredited}
+		if s.Transfer.From == "" || s.Transfer.To == "" || s.Transfer.Amount == "" {
+			return errors.New("transfer: from, to and amount must be set")
+		}
+	case s.Sleep != "":
+		if d, err := time.ParseDuration(s.Sleep); err != nil || d < 0 {
+			return fmt.Errorf("sleep: invalid duration %q", s.Sleep)
+		}
+	case s.Loop != nil:
+		if (s.Loop.Times > 0) == (len(s.Loop.Values) > 0) {
+			return errors.New("loop: exactly one of times and values must be set")
+		}
+		if err := validateSteps(s.Loop.Steps); err != nil {
+			return fmt.Errorf("loop: %w", err)
+		}
+	case s.Parallel != nil:
+		if len(s.Parallel) == 0 {
+			return errors.New("parallel: no branches")
+		}
+		for i, b := range s.Parallel {
+			if b.Times < 0 {
+				return fmt.Errorf("parallel: branch %d: times must not be negative, got %d", i+1, b.Times)
+			}
+			if err := validateSteps(b.Steps); err != nil {
+				return fmt.Errorf("parallel: branch %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if !s.isCall() && (s.Expect != nil || s.Save != nil) {
+		return fmt.Errorf("%s: expect and save are allowed only for get, add, credit, debit and transfer", s.action())
+	}
+	if s.Expect != nil {
+		if _, ok := codeNames[s.Expect.expectedCode()]; !ok {
+			return fmt.Errorf("expect: unknown code %q", s.Expect.Code)
+		}
+		for field, value := range s.Expect.fields() {
+			if value != nil && !contains(fields, field) {
+				return fmt.Errorf("expect: %s has no field %s", s.action(), field)
+			}
+		}
+	}
+	for name, field := range s.Save {
+		if !contains(fields, field) {
+			return fmt.Errorf("save: %s has no field %s to save in %s", s.action(), field, name)
+		}
+	}
+
+	return nil
+}
+
+//isCall сообщает, что шаг выполняет вызов сервера.
+func (s *Step) isCall() bool {
+	return s.Get != nil || s.Add != nil || s.Credit != nil || s.Debit != nil || s.Transfer != nil
+}
+
+func (s *Step) addStep() *AddStep {
+	switch {
+	case s.Credit != nil:
+		return s.Credit
+	case s.Debit != nil:
+		return s.Debit
+	}
+
+	return s.Add
+}
+
+//action возвращает имя действия шага.
+func (s *Step) action() string {
+	switch {
+	case s.Get != nil:
+		return "get"
+	case s.Add != nil:
+		return "add"
+	case s.Credit != nil:
+		return "credit"
+	case s.Debit != nil:
+		return "debit"
+	case s.Transfer != nil:
+		return "transfer"
+	case s.Set != nil:
+		return "set"
+	case s.Sleep != "":
+		return "sleep"
+	case s.Loop != nil:
+		return "loop"
+	}
+
+	return "parallel"
+}
+
+//String возвращает имя шага или описание его действия.
+func (s *Step) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	switch {
+	case s.Get != nil:
+		return "get " + s.Get.Id
+	case s.Add != nil, s.Credit != nil, s.Debit != nil:
+		step := s.addStep()
+		return strings.TrimSpace(fmt.Sprintf("%s %s %s %s", s.action(), step.Id, step.Amount, step.Currency))
+	case s.Transfer != nil:
+		return fmt.Sprintf("transfer %s %s %s", s.Transfer.From, s.Transfer.To, s.Transfer.Amount)
+	case s.Sleep != "":
+		return "sleep " + s.Sleep
+	}
+
+	return s.action()
+}
+
+func (e *Expect) expectedCode() string {
+	if e.Code == "" {
+		return codes.OK.String()
+	}
+
+	return e.Code
+}
+
+//fields возвращает ожидаемые значения полей ответа по именам полей.
+func (e *Expect) fields() map[string]*string {
+	return map[string]*string{
+		FieldAmount:    e.Amount,
+		FieldAvailable: e.Available,
+		FieldCurrency:  e.Currency,
+		FieldVersion:   e.Version,
+		FieldCredited:  e.Credited,
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,252 @@
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vps2/accounttesttask/internal/api"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+//fakeClient - сервис счетов в памяти, отклоняющий списания сверх баланса
+type fakeClient struct {
+	mu       sync.Mutex
+	balances map[int32]int64
+	versions map[int32]int64
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{balances: make(map[int32]int64), versions: make(map[int32]int64)}
+}
+
+func (c *fakeClient) GetBalances(_ context.Context, ids []int32) ([]*api.GetResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	balances := make([]*api.GetResponse, 0, len(ids))
+	for _, id := range ids {
+		balances = append(balances, &api.GetResponse{
+			BalanceId: id,
+			Amount:    c.balances[id],
+			Available: c.balances[id],
+			Version:   c.versions[id],
+		})
+	}
+
+	return balances, nil
+}
+
+func (c *fakeClient) AddAmount(_ context.Context, id int32, amount int64, _ string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.balances[id]+amount < 0 {
+		return status.Error(codes.FailedPrecondition, "insufficient funds")
+	}
+	c.balances[id] += amount
+	c.versions[id]++
+
+	return nil
+}
+
+func (c *fakeClient) Transfer(_ context.Context, req *api.TransferRequest) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.balances[req.FromBalanceId] < req.Amount {
+		return 0, status.Error(codes.FailedPrecondition, "insufficient funds")
+	}
+	c.balances[req.FromBalanceId] -= req.Amount
+	c.balances[req.ToBalanceId] += req.Amount
+
+	return req.Amount, nil
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			yaml: `
+scenarios:
+  - name: ok
+    steps:
+      - get: {id: 1}
+        expect: {amount: 0, code: OK}
+        save: {v: version}
+      - loop: {times: 2, steps: [{sleep: 1ms}]}
+      - parallel: [{times: 2, steps: [{set: {a: 1}}]}]
+`,
+		},
+		{name: "no scenarios", yaml: "vars: {a: 1}", wantErr: "no scenarios"},
+		{name: "unknown field", yaml: "scenarios: [{name: a, steps: [{gett: {id: 1}}]}]", wantErr: "gett"},
+		{
+			name:    "two actions",
+			yaml:    "scenarios: [{name: a, steps: [{get: {id: 1}, sleep: 1s}]}]",
+			wantErr: `scenario "a": step 1: exactly one of`,
+		},
+		{
+			name:    "unknown code",
+			yaml:    "scenarios: [{name: a, steps: [{get: {id: 1}, expect: {code: Broken}}]}]",
+			wantErr: `unknown code "Broken"`,
+		},
+		{
+			name:    "field of another action",
+			yaml:    "scenarios: [{name: a, steps: [{credit: {id: 1, amount: 1}, expect: {amount: 1}}]}]",
+			wantErr: "credit has no field amount",
+		},
+		{
+			name:    "loop without count",
+			yaml:    "scenarios: [{name: a, steps: [{loop: {steps: [{get: {id: 1}}]}}]}]",
+			wantErr: "step 1: loop: exactly one of times and values",
+		},
+		{
+			name:    "nested error",
+			yaml:    "scenarios: [{name: a, steps: [{parallel: [{steps: [{sleep: soon}]}]}]}]",
+			wantErr: `parallel: branch 1: step 1: sleep: invalid duration "soon"`,
+		},
+		{
+			name:    "duplicate scenario",
+			yaml:    "scenarios: [{name: a, steps: [{sleep: 1s}]}, {name: a, steps: [{sleep: 1s}]}]",
+			wantErr: `scenario "a" is defined more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.yaml))
+			if tt.wantErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	f, err := Parse(strings.NewReader(`
+name: suite
+vars:
+  account: 1
+scenarios:
+  - name: overdraft
+    steps:
+      - credit: {id: "${account}", amount: 100}
+      - debit: {id: "${account}", amount: 150}
+        expect: {code: FailedPrecondition}
+      - get: {id: "${account}"}
+        expect: {amount: 100}
+        save: {before: amount}
+      - set: {next: "${before} + 1"}
+      - get: {id: "${account}"}
+        expect: {amount: "${next} - 1", version: 1}
+  - name: parallel credits
+    steps:
+      - parallel:
+          - times: 3
+            var: w
+            steps:
+              - loop:
+                  var: i
+                  values: [1, 2]
+                  steps:
+                    - credit: {id: "${w}0", amount: "${i}"}
+          - steps:
+              - credit: {id: 2, amount: 10}
+      - get: {id: 10}
+        expect: {amount: 3}
+      - get: {id: 30}
+        expect: {amount: 3}
+  - name: unexpected result
+    steps:
+      - get: {id: "${account}"}
+        expect: {amount: 1}
+      - credit: {id: 1, amount: 1}
+  - name: undefined variable
+    steps:
+      - get: {id: "${missing}"}
+  - name: failed branches
+    steps:
+      - parallel:
+          - steps: [{debit: {id: 5, amount: 1}}]
+          - name: second
+            steps: [{debit: {id: 5, amount: 1}}]
+`))
+	assert.NilError(t, err)
+
+	suite := NewRunner(newFakeClient()).WithVars(map[string]string{"account": "1"}).Run(context.Background(), f)
+	assert.Equal(t, "suite", suite.Name)
+	assert.Equal(t, 5, len(suite.Results))
+
+	for _, r := range suite.Results[:2] {
+		assert.Assert(t, r.Passed(), "%s: %v %v", r.Name, r.Failures, r.Errors)
+	}
+
+	unexpected := suite.Results[2]
+	assert.DeepEqual(t, []string{"step 1 (get ${account}): expected amount 1, got 100"}, unexpected.Failures)
+	assert.Equal(t, 0, len(unexpected.Errors))
+
+	undefined := suite.Results[3]
+	assert.DeepEqual(t, []string{`step 1 (get ${missing}): undefined variable "missing"`}, undefined.Errors)
+
+	branches := suite.Results[4]
+	assert.Equal(t, 2, len(branches.Failures))
+	for _, failure := range branches.Failures {
+		assert.Assert(t, strings.Contains(failure, "expected code OK, got FailedPrecondition: insufficient funds"), failure)
+	}
+}
+
+func TestEvalInt(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    int64
+		wantErr bool
+	}{
+		{expr: "42", want: 42},
+		{expr: "-5", want: -5},
+		{expr: " 100 + 50 - 3 ", want: 147},
+		{expr: "10 - -5", want: 15},
+		{expr: "1 2", wantErr: true},
+		{expr: "", wantErr: true},
+		{expr: "1 +", wantErr: true},
+		{expr: "1 * 2", wantErr: true},
+		{expr: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalInt(tt.expr)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	suites := []*Suite{{
+		Name: "suite",
+		Results: []Result{
+			{Name: "passed"},
+			{Name: "failed", Failures: []string{"step 1: expected amount 1, got 2"}},
+			{Name: "broken", Errors: []string{`step 2: undefined variable "a"`}},
+		},
+	}}
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, WriteJUnit(buf, suites))
+
+	report := buf.String()
+	assert.Assert(t, strings.Contains(report, `<testsuites tests="3" failures="1" errors="1"`), report)
+	assert.Assert(t, strings.Contains(report, `<failure message="step 1: expected amount 1, got 2">`), report)
+	assert.Assert(t, strings.Contains(report, `<error message="step 2: undefined variable &#34;a&#34;">`), report)
+}
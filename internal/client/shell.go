@@ -0,0 +1,357 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/api"
+
+	"google.golang.org/grpc"
+)
+
+//defaultWatchInterval - период опроса балансов командой watch, если он не задан
+const defaultWatchInterval = time.Second
+
+//shellCommands - команды оболочки в порядке вывода командой help
+var shellCommands = []struct {
+	name  string
+	usage string
+}{
+	{"get", "get <id...> - print the balances of the accounts"},
+	{"add", "add <id> <amount> [currency] - add the amount in minor currency units to the balance"},
+	{"stats", "stats - print the counters of operations on the accounts and the progress of accruals"},
+	{"reset", "reset - reset the statistics"},
+	{"watch", "watch [interval] <id...> - print the balances whenever they change until Ctrl+C, every second by default"},
+	{`\timing`, `\timing - toggle printing the latency of every call`},
+	{"help", "help - print this help"},
+	{"exit", "exit - leave the shell"},
+	{"quit", "quit - leave the shell"},
+}
+
+//usageError возвращает ошибку с описанием команды name.
+func usageError(name string) error {
+	for _, c := range shellCommands {
+		if c.name == name {
+			return errors.New("usage: " + c.usage)
+		}
+	}
+
+	return fmt.Errorf("unknown command %q", name)
+}
+
+//callTiming - задержка одного вызова, выполненного командой оболочки
+type callTiming struct {
+	call    string
+	latency time.Duration
+}
+
+//Shell выполняет команды интерактивной оболочки. Все команды используют одно соединение с сервером.
+type Shell struct {
+	balances   *BalancesClient
+	statistics *StatisticsServiceClient
+	out        io.Writer
+
+	//timing - выводить задержку вызовов после каждой команды
+	timing  bool
+	timings []callTiming
+}
+
+func NewShell(conn *grpc.ClientConn, out io.Writer) *Shell {
+	return &Shell{
+		balances:   NewBalancesClient(conn),
+		statistics: NewStatisticsServiceClient(conn),
+		out:        out,
+	}
+}
+
+//Exec выполняет строку line. Пустая строка игнорируется. Возвращает true, если требуется выйти из оболочки.
+func (s *Shell) Exec(ctx context.Context, line string) (bool, error) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	s.timings = s.timings[:0]
+	defer s.printTimings()
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "get":
+		return false, s.get(ctx, args)
+	case "add":
+		return false, s.add(ctx, args)
+	case "stats":
+		return false, s.stats(ctx, args)
+	case "reset":
+		return false, s.reset(ctx, args)
+	case "watch":
+		return false, s.watch(ctx, args)
+	case `\timing`:
+		return false, s.toggleTiming(args)
+	case "help":
+		s.help()
+		return false, nil
+	case "exit", "quit":
+		return true, nil
+	}
+
+	return false, fmt.Errorf("unknown command %q, type help for the list of commands", cmd)
+}
+
+//Complete дополняет имя команды в строке line, если курсор pos находится в конце первого слова. Если подходящих
+//команд несколько, то имя дополняется до их общего префикса, а сами команды возвращаются в candidates.
+func (s *Shell) Complete(line string, pos int) (newLine string, newPos int, candidates []string) {
+	prefix, rest := line[:pos], line[pos:]
+	if strings.ContainsAny(prefix, " \t") || rest != "" && rest[0] != ' ' {
+		return line, pos, nil
+	}
+
+	for _, c := range shellCommands {
+		if strings.HasPrefix(c.name, prefix) {
+			candidates = append(candidates, c.name)
+		}
+	}
+	if len(candidates) == 0 {
+		return line, pos, nil
+	}
+	sort.Strings(candidates)
+
+	completion := candidates[0]
+	for _, c := range candidates[1:] {
+		completion = commonPrefix(completion, c)
+	}
+	if len(candidates) == 1 && rest == "" {
+		completion += " "
+	}
+
+	return completion + rest, len(completion), candidates
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return a[:n]
+}
+
+func (s *Shell) get(ctx context.Context, args []string) error {
+	ids, err := parseIds(args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usageError("get")
+	}
+
+	balances, err := s.getBalances(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	return s.printBalances(balances)
+}
+
+//getBalances запрашивает балансы по одному, чтобы учесть задержку каждого вызова.
+func (s *Shell) getBalances(ctx context.Context, ids []int32) ([]*api.GetResponse, error) {
+	balances := make([]*api.GetResponse, 0, len(ids))
+	for _, id := range ids {
+		var resp []*api.GetResponse
+		err := s.call(fmt.Sprintf("get %d", id), func() (err error) {
+			resp, err = s.balances.GetBalances(ctx, []int32{id})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, resp...)
+	}
+
+	return balances, nil
+}
+
+func (s *Shell) add(ctx context.Context, args []string) error {
+	if len(args) != 2 && len(args) != 3 {
+		return usageError("add")
+	}
+	ids, err := parseIds(args[:1])
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", args[1])
+	}
+	currency := ""
+	if len(args) == 3 {
+		currency = args[2]
+	}
+
+	return s.call(fmt.Sprintf("add %d", ids[0]), func() error {
+		return s.balances.AddAmount(ctx, ids[0], amount, currency)
+	})
+}
+
+func (s *Shell) stats(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("stats")
+	}
+
+	var resp *api.StatisticsResponse
+	err := s.call("stats", func() (err error) {
+		resp, err = s.statistics.GetStatistics(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "READS\tWRITES\tREADS/S\tWRITES/S")
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\n", resp.ReadOperations, resp.WriteOperations, resp.ReadOperationsPerSecond,
+		resp.WriteOperationsPerSecond)
+
+	if len(resp.Accruals) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "ACCRUAL\tPERIOD\tPROCESSED\tPOSTED\tSKIPPED\tFAILED\tDONE")
+		for _, a := range resp.Accruals {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%t\n", a.Rule, a.Period, a.Processed, a.Posted, a.Skipped,
+				a.Failed, a.Done)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (s *Shell) reset(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("reset")
+	}
+
+	return s.call("reset", func() error {
+		return s.statistics.ResetStatistics(ctx)
+	})
+}
+
+//watch опрашивает балансы с заданным периодом и выводит их при изменении версии любого из счетов. Команда
+//завершается при завершении контекста ctx.
+func (s *Shell) watch(ctx context.Context, args []string) error {
+	interval := defaultWatchInterval
+	if len(args) > 0 {
+		if d, err := time.ParseDuration(args[0]); err == nil {
+			if d <= 0 {
+				return fmt.Errorf("interval must be positive, got %s", d)
+			}
+			interval, args = d, args[1:]
+		}
+	}
+	ids, err := parseIds(args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usageError("watch")
+	}
+
+	versions := make(map[int32]int64, len(ids))
+	for {
+		balances, err := s.getBalances(ctx, ids)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		changed := false
+		for _, b := range balances {
+			if v, ok := versions[b.BalanceId]; !ok || v != b.Version {
+				versions[b.BalanceId] = b.Version
+				changed = true
+			}
+		}
+		//задержка выводится только вместе с изменившимися балансами, чтобы не засорять вывод
+		if changed {
+			fmt.Fprintln(s.out, time.Now().Format("15:04:05.000"))
+			if err = s.printBalances(balances); err != nil {
+				return err
+			}
+			s.printTimings()
+		}
+		s.timings = s.timings[:0]
+
+		if !sleep(ctx, interval) {
+			return nil
+		}
+	}
+}
+
+func (s *Shell) toggleTiming(args []string) error {
+	if len(args) != 0 {
+		return usageError(`\timing`)
+	}
+
+	s.timing = !s.timing
+	if s.timing {
+		fmt.Fprintln(s.out, "Timing is on.")
+	} else {
+		fmt.Fprintln(s.out, "Timing is off.")
+	}
+
+	return nil
+}
+
+func (s *Shell) help() {
+	for _, c := range shellCommands {
+		fmt.Fprintln(s.out, c.usage)
+	}
+}
+
+//call выполняет вызов f и запоминает его задержку под именем name, если включён вывод задержек.
+func (s *Shell) call(name string, f func() error) error {
+	start := time.Now()
+	err := f()
+	if s.timing {
+		s.timings = append(s.timings, callTiming{name, time.Since(start)})
+	}
+
+	return err
+}
+
+func (s *Shell) printTimings() {
+	for _, t := range s.timings {
+		fmt.Fprintf(s.out, "Time: %.3f ms (%s)\n", float64(t.latency)/float64(time.Millisecond), t.call)
+	}
+	s.timings = s.timings[:0]
+}
+
+func (s *Shell) printBalances(balances []*api.GetResponse) error {
+	tw := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BALANCE_ID\tAMOUNT\tAVAILABLE\tCURRENCY\tVERSION")
+	for _, b := range balances {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%d\n", b.BalanceId, b.Amount, b.Available, b.Currency, b.Version)
+	}
+
+	return tw.Flush()
+}
+
+func parseIds(args []string) ([]int32, error) {
+	ids := make([]int32, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account id %q", arg)
+		}
+		ids = append(ids, int32(id))
+	}
+
+	return ids, nil
+}
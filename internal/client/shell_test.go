@@ -0,0 +1,83 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestShell_Complete(t *testing.T) {
+	tests := []struct {
+		line           string
+		pos            int
+		wantLine       string
+		wantPos        int
+		wantCandidates []string
+	}{
+		{line: "ge", pos: 2, wantLine: "get ", wantPos: 4, wantCandidates: []string{"get"}},
+		{line: "s", pos: 1, wantLine: "stats ", wantPos: 6, wantCandidates: []string{"stats"}},
+		{line: "e", pos: 1, wantLine: "exit ", wantPos: 5, wantCandidates: []string{"exit"}},
+		{
+			line:           "",
+			pos:            0,
+			wantLine:       "",
+			wantPos:        0,
+			wantCandidates: []string{`\timing`, "add", "exit", "get", "help", "quit", "reset", "stats", "watch"},
+		},
+		{line: `\t`, pos: 2, wantLine: `\timing `, wantPos: 8, wantCandidates: []string{`\timing`}},
+		{line: "wa 1 2", pos: 2, wantLine: "watch 1 2", wantPos: 5, wantCandidates: []string{"watch"}},
+		{line: "get 1", pos: 5, wantLine: "get 1", wantPos: 5},
+		{line: "getx", pos: 2, wantLine: "getx", wantPos: 2},
+		{line: "x", pos: 1, wantLine: "x", wantPos: 1},
+	}
+
+	shell := NewShell(nil, &bytes.Buffer{})
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			line, pos, candidates := shell.Complete(tt.line, tt.pos)
+			assert.Equal(t, tt.wantLine, line)
+			assert.Equal(t, tt.wantPos, pos)
+			if tt.wantCandidates != nil {
+				assert.DeepEqual(t, tt.wantCandidates, candidates)
+			}
+		})
+	}
+}
+
+func TestShell_Exec(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantQuit bool
+		wantOut  string
+		wantErr  string
+	}{
+		{line: "  "},
+		{line: `\timing`, wantOut: "Timing is on.\n"},
+		{line: "exit", wantQuit: true},
+		{line: "quit", wantQuit: true},
+		{line: "get", wantErr: "usage: get"},
+		{line: "get x", wantErr: `invalid account id "x"`},
+		{line: "add 1", wantErr: "usage: add"},
+		{line: "add 1 x", wantErr: `invalid amount "x"`},
+		{line: "stats 1", wantErr: "usage: stats"},
+		{line: "watch 1s", wantErr: "usage: watch"},
+		{line: "watch -1s 1", wantErr: "interval must be positive"},
+		{line: "drop", wantErr: `unknown command "drop"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			quit, err := NewShell(nil, out).Exec(context.Background(), tt.line)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NilError(t, err)
+			}
+			assert.Equal(t, tt.wantQuit, quit)
+			assert.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
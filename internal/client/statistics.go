@@ -9,28 +9,22 @@ import (
 )
 
 type StatisticsServiceClient struct {
-	addr string
+	client api.StatisticsServiceClient
 }
 
-func NewStatisticsServiceClient(addr string) *StatisticsServiceClient {
+func NewStatisticsServiceClient(conn *grpc.ClientConn) *StatisticsServiceClient {
 	return &StatisticsServiceClient{
-		addr: addr,
+		client: api.NewStatisticsServiceClient(conn),
 	}
 }
 
 func (c *StatisticsServiceClient) ResetStatistics(ctx context.Context) error {
-	conn, err := grpc.Dial(c.addr, grpc.WithInsecure())
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	client := api.NewStatisticsServiceClient(conn)
+	_, err := c.client.Reset(ctx, &api.Empty{})
 
-	_, err = client.Reset(ctx, &api.Empty{})
-	if err != nil {
-		return err
-	}
+	return err
+}
 
-	return nil
+//GetStatistics возвращает счётчики операций над счетами.
+func (c *StatisticsServiceClient) GetStatistics(ctx context.Context) (*api.StatisticsResponse, error) {
+	return c.client.Get(ctx, &api.Empty{})
 }
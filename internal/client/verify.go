@@ -0,0 +1,227 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Outcome - результат операции с точки зрения клиента
+type Outcome string
+
+const (
+	//OutcomeOk - операция подтверждена сервером
+	OutcomeOk Outcome = "ok"
+	//OutcomeFailed - сервер отклонил операцию, и она не изменила баланс
+	OutcomeFailed Outcome = "failed"
+	//OutcomeUnknown - ответ не получен (истёк таймаут, разорвано соединение), и операция могла как выполниться,
+	//так и не выполниться
+	OutcomeUnknown Outcome = "unknown"
+)
+
+//Виды операций в истории
+const (
+	HistoryRead = "read"
+	HistoryAdd  = "add"
+)
+
+//HistoryEntry - операция в истории нагрузочного теста. Моменты вызова и ответа отсчитываются в наносекундах от
+//начала теста. Для операции с неизвестным результатом момент ответа равен -1.
+type HistoryEntry struct {
+	Worker    int32  `json:"worker"`
+	Key       int    `json:"key"`
+	Operation string `json:"operation"`
+	//Value - изменение баланса для add или прочитанный баланс для read
+	Value   int64   `json:"value"`
+	Call    int64   `json:"call"`
+	Return  int64   `json:"return"`
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+}
+
+//Discrepancy - расхождение итогового баланса с подтверждёнными изменениями
+type Discrepancy struct {
+	Key     int
+	Initial int64
+	//Acked - сумма подтверждённых изменений
+	Acked int64
+	//Unknown - количество изменений с неизвестным результатом. Допустимое отклонение баланса от Initial+Acked
+	//лежит в границах [UnknownMin, UnknownMax] - суммах отрицательных и положительных таких изменений.
+	Unknown    int
+	UnknownMin int64
+	UnknownMax int64
+	Final      int64
+}
+
+func (d Discrepancy) String() string {
+	expected := d.Initial + d.Acked
+	if d.Unknown == 0 {
+		return fmt.Sprintf("key %d: final balance %d, expected %d (initial %d, acknowledged %+d)", d.Key, d.Final,
+			expected, d.Initial, d.Acked)
+	}
+
+	return fmt.Sprintf("key %d: final balance %d, expected from %d to %d (initial %d, acknowledged %+d, %d updates"+
+		" with unknown outcome)", d.Key, d.Final, expected+d.UnknownMin, expected+d.UnknownMax, d.Initial, d.Acked, d.Unknown)
+}
+
+//keyBalance - изменения баланса по ключу
+type keyBalance struct {
+	acked      int64
+	unknown    int
+	unknownMin int64
+	unknownMax int64
+}
+
+//Verifier проверяет, что сервер не теряет и не дублирует изменения балансов: сумма подтверждённых изменений по
+//каждому ключу должна совпадать с разностью итогового и начального балансов с точностью до изменений
+//с неизвестным результатом. Балансы ключей не должны изменяться никем, кроме воркеров теста.
+type Verifier struct {
+	mu       sync.Mutex
+	start    time.Time
+	initial  map[int]int64
+	balances map[int]*keyBalance
+	//history - история операций, если она записывается
+	history []HistoryEntry
+	record  bool
+}
+
+//NewVerifier создаёт проверку для начальных балансов initial. Если record, то записывается история операций.
+func NewVerifier(initial map[int]int64, record bool) *Verifier {
+	return &Verifier{
+		start:    time.Now(),
+		initial:  initial,
+		balances: make(map[int]*keyBalance),
+		record:   record,
+	}
+}
+
+//RecordRead учитывает чтение баланса ключа key, вызванное в момент call и завершившееся в момент ret.
+func (v *Verifier) RecordRead(worker int32, key int, value int64, call, ret time.Time, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.appendHistory(worker, key, HistoryRead, value, call, ret, err)
+}
+
+//RecordAdd учитывает изменение баланса ключа key на amount, вызванное в момент call и завершившееся в момент ret.
+func (v *Verifier) RecordAdd(worker int32, key int, amount int64, call, ret time.Time, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	b, ok := v.balances[key]
+	if !ok {
+		b = &keyBalance{}
+		v.balances[key] = b
+	}
+
+	switch outcome(err) {
+	case OutcomeOk:
+		b.acked += amount
+	case OutcomeUnknown:
+		b.unknown++
+		if amount < 0 {
+			b.unknownMin += amount
+		} else {
+			b.unknownMax += amount
+		}
+	}
+
+	v.appendHistory(worker, key, HistoryAdd, amount, call, ret, err)
+}
+
+func (v *Verifier) appendHistory(worker int32, key int, op string, value int64, call, ret time.Time, err error) {
+	if !v.record {
+		return
+	}
+
+	entry := HistoryEntry{
+		Worker:    worker,
+		Key:       key,
+		Operation: op,
+		Value:     value,
+		Call:      int64(call.Sub(v.start)),
+		Return:    int64(ret.Sub(v.start)),
+		Outcome:   outcome(err),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if entry.Outcome == OutcomeUnknown {
+		entry.Return = -1
+	}
+	v.history = append(v.history, entry)
+}
+
+//Initial возвращает начальные балансы.
+func (v *Verifier) Initial() map[int]int64 {
+	return v.initial
+}
+
+//History возвращает записанную историю операций, упорядоченную по моменту вызова.
+func (v *Verifier) History() []HistoryEntry {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	history := make([]HistoryEntry, len(v.history))
+	copy(history, v.history)
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Call < history[j].Call })
+
+	return history
+}
+
+//CheckBalances сравнивает итоговые балансы final с начальными и подтверждёнными изменениями и возвращает
+//расхождения, упорядоченные по ключу.
+func (v *Verifier) CheckBalances(final map[int]int64) []Discrepancy {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]int, 0, len(final))
+	for key := range final {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	var res []Discrepancy
+	for _, key := range keys {
+		b, ok := v.balances[key]
+		if !ok {
+			b = &keyBalance{}
+		}
+
+		diff := final[key] - v.initial[key] - b.acked
+		if diff < b.unknownMin || diff > b.unknownMax {
+			res = append(res, Discrepancy{
+				Key:        key,
+				Initial:    v.initial[key],
+				Acked:      b.acked,
+				Unknown:    b.unknown,
+				UnknownMin: b.unknownMin,
+				UnknownMax: b.unknownMax,
+				Final:      final[key],
+			})
+		}
+	}
+
+	return res
+}
+
+//outcome определяет результат операции по ошибке. Операция считается не выполненной, только если код состояния
+//означает, что сервер отклонил её до изменения баланса.
+func outcome(err error) Outcome {
+	if err == nil {
+		return OutcomeOk
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.ResourceExhausted, codes.Aborted, codes.OutOfRange, codes.Unimplemented,
+		codes.Unauthenticated:
+		return OutcomeFailed
+	}
+
+	return OutcomeUnknown
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func TestVerifier_CheckBalances(t *testing.T) {
+	timeout := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	rejected := status.Error(codes.FailedPrecondition, "insufficient funds")
+
+	type add struct {
+		amount int64
+		err    error
+	}
+
+	tests := []struct {
+		name  string
+		adds  []add
+		final int64
+		want  int
+	}{
+		{
+			name:  "acknowledged",
+			adds:  []add{{amount: 10}, {amount: -3}, {amount: 5, err: rejected}},
+			final: 107,
+		},
+		{
+			name:  "lost update",
+			adds:  []add{{amount: 10}, {amount: -3}},
+			final: 110,
+			want:  1,
+		},
+		{
+			name:  "unknown outcome applied",
+			adds:  []add{{amount: 10}, {amount: -3, err: timeout}, {amount: 5, err: errors.New("connection reset")}},
+			final: 112,
+		},
+		{
+			name:  "unknown outcome not applied",
+			adds:  []add{{amount: 10}, {amount: -3, err: timeout}, {amount: 5, err: timeout}},
+			final: 107,
+		},
+		{
+			name:  "out of unknown range",
+			adds:  []add{{amount: 10}, {amount: -3, err: timeout}},
+			final: 111,
+			want:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(map[int]int64{1: 100, 2: 50}, false)
+			now := time.Now()
+			for _, a := range tt.adds {
+				v.RecordAdd(1, 1, a.amount, now, now, a.err)
+			}
+
+			res := v.CheckBalances(map[int]int64{1: tt.final, 2: 50})
+			assert.Equal(t, tt.want, len(res), "%v", res)
+			for _, d := range res {
+				assert.Equal(t, 1, d.Key)
+			}
+		})
+	}
+}
+
+func TestCheckLinearizability(t *testing.T) {
+	add := func(value, call, ret int64) HistoryEntry {
+		return HistoryEntry{Key: 1, Operation: HistoryAdd, Value: value, Call: call, Return: ret, Outcome: OutcomeOk}
+	}
+	read := func(value, call, ret int64) HistoryEntry {
+		return HistoryEntry{Key: 1, Operation: HistoryRead, Value: value, Call: call, Return: ret, Outcome: OutcomeOk}
+	}
+	unknown := func(e HistoryEntry) HistoryEntry {
+		e.Outcome, e.Return = OutcomeUnknown, -1
+		return e
+	}
+
+	tests := []struct {
+		name    string
+		history []HistoryEntry
+		final   int64
+		want    []int
+	}{
+		{
+			name:    "sequential",
+			history: []HistoryEntry{add(5, 0, 1), read(15, 2, 3), add(-2, 4, 5), read(13, 6, 7)},
+			final:   13,
+		},
+		{
+			name: "concurrent",
+			//первое чтение пересекается с обоими изменениями и может вернуть 10, 15, 7 или 12
+			history: []HistoryEntry{add(5, 0, 10), add(-3, 1, 10), read(7, 2, 3), read(12, 11, 12)},
+			final:   12,
+		},
+		{
+			name:    "stale read",
+			history: []HistoryEntry{add(5, 0, 1), read(10, 2, 3)},
+			final:   15,
+			want:    []int{1},
+		},
+		{
+			name:    "read of a value that never existed",
+			history: []HistoryEntry{add(5, 0, 10), add(-3, 1, 10), read(8, 2, 3)},
+			final:   12,
+			want:    []int{1},
+		},
+		{
+			name:    "unknown add applied",
+			history: []HistoryEntry{unknown(add(5, 0, 0)), read(15, 2, 3)},
+			final:   15,
+		},
+		{
+			name:    "unknown add not applied",
+			history: []HistoryEntry{unknown(add(5, 0, 0)), read(10, 2, 3)},
+			final:   10,
+		},
+		{
+			name:    "unknown add applied after final read",
+			history: []HistoryEntry{unknown(add(5, 0, 0)), read(15, 2, 3)},
+			final:   10,
+			want:    []int{1},
+		},
+		{
+			name:    "failed operations are ignored",
+			history: []HistoryEntry{{Key: 1, Operation: HistoryAdd, Value: 5, Call: 0, Return: 1, Outcome: OutcomeFailed}, read(10, 2, 3)},
+			final:   10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := CheckLinearizability(context.Background(), tt.history, map[int]int64{1: 10}, map[int]int64{1: tt.final})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.want, res)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/client/config"
+)
+
+const (
+	//pacerQueueSize - количество моментов запросов, ожидающих свободного воркера. Моменты сверх него
+	//отбрасываются, чтобы очередь не росла без ограничения при перегрузке сервера.
+	pacerQueueSize = 1000
+	//pacerIdleStep - интервал проверки частоты, пока она равна нулю (в начале ramp с нулевой частоты)
+	pacerIdleStep = 10 * time.Millisecond
+)
+
+//Pacer выдаёт воркерам моменты запросов с частотой профиля независимо от времени ответов сервера
+//(открытая модель нагрузки). Задержка запроса отсчитывается от назначенного момента, поэтому учитывает и время
+//ожидания свободного воркера.
+type Pacer struct {
+	rate  config.Rate
+	ticks chan time.Time
+	//dropped - количество отброшенных из-за переполнения очереди моментов
+	dropped int64
+}
+
+func NewPacer(rate config.Rate) *Pacer {
+	return &Pacer{
+		rate:  rate,
+		ticks: make(chan time.Time, pacerQueueSize),
+	}
+}
+
+//Start начинает выдачу моментов запросов до завершения ctx.
+func (p *Pacer) Start(ctx context.Context) {
+	go func() {
+		start := time.Now()
+		next := start
+
+		for {
+			if d := time.Until(next); d > 0 {
+				timer := time.NewTimer(d)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+			} else if ctx.Err() != nil {
+				return
+			}
+
+			rps := p.rateAt(next.Sub(start))
+			if rps <= 0 {
+				next = next.Add(pacerIdleStep)
+				continue
+			}
+
+			select {
+			case p.ticks <- next:
+			default:
+				atomic.AddInt64(&p.dropped, 1)
+			}
+			next = next.Add(time.Duration(float64(time.Second) / rps))
+		}
+	}()
+}
+
+//Next ожидает очередной момент запроса. Возвращает false после завершения ctx.
+func (p *Pacer) Next(ctx context.Context) (time.Time, bool) {
+	select {
+	case t := <-p.ticks:
+		return t, true
+	case <-ctx.Done():
+		return time.Time{}, false
+	}
+}
+
+//Dropped возвращает количество запросов, не выполненных из-за того, что все воркеры были заняты.
+func (p *Pacer) Dropped() int64 {
+	return atomic.LoadInt64(&p.dropped)
+}
+
+//rateAt возвращает частоту запросов через elapsed после начала теста.
+func (p *Pacer) rateAt(elapsed time.Duration) float64 {
+	if p.rate.Profile != config.RateRamp || elapsed >= p.rate.RampDuration {
+		return p.rate.RPS
+	}
+
+	progress := float64(elapsed) / float64(p.rate.RampDuration)
+
+	return p.rate.StartRPS + (p.rate.RPS-p.rate.StartRPS)*progress
+}
+
+//keyChooser выбирает ключ очередного запроса. Экземпляр используется одним воркером.
+type keyChooser interface {
+	next() int
+}
+
+//newKeyChooser возвращает выбор ключей из keys с распределением, заданным в настройках.
+func newKeyChooser(keys []int, cfg config.Keys, rnd *rand.Rand) keyChooser {
+	switch cfg.Distribution {
+	case config.KeysZipfian:
+		if len(keys) > 1 {
+			return &zipfianKeys{keys: keys, zipf: rand.NewZipf(rnd, cfg.ZipfS, 1, uint64(len(keys)-1))}
+		}
+	case config.KeysHotspot:
+		hot := int(math.Ceil(cfg.HotFraction * float64(len(keys))))
+		if hot > 0 && hot < len(keys) {
+			return &hotspotKeys{keys: keys, hot: hot, probability: cfg.HotProbability, rnd: rnd}
+		}
+	}
+
+	return &uniformKeys{keys: keys, rnd: rnd}
+}
+
+type uniformKeys struct {
+	keys []int
+	rnd  *rand.Rand
+}
+
+func (k *uniformKeys) next() int {
+	return k.keys[k.rnd.Intn(len(k.keys))]
+}
+
+//zipfianKeys выбирает ключи по закону Ципфа: первый ключ списка запрашивается чаще всего.
+type zipfianKeys struct {
+	keys []int
+	zipf *rand.Zipf
+}
+
+func (k *zipfianKeys) next() int {
+	return k.keys[k.zipf.Uint64()]
+}
+
+//hotspotKeys выбирает с вероятностью probability один из первых hot ключей, иначе - один из остальных.
+type hotspotKeys struct {
+	keys        []int
+	hot         int
+	probability float64
+	rnd         *rand.Rand
+}
+
+func (k *hotspotKeys) next() int {
+	if k.rnd.Float64() < k.probability {
+		return k.keys[k.rnd.Intn(k.hot)]
+	}
+
+	return k.keys[k.hot+k.rnd.Intn(len(k.keys)-k.hot)]
+}
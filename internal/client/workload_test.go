@@ -0,0 +1,109 @@
+package client
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/client/config"
+
+	"gotest.tools/assert"
+)
+
+func TestPacer_rateAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    config.Rate
+		elapsed time.Duration
+		want    float64
+	}{
+		{
+			name:    "constant",
+			rate:    config.Rate{Profile: config.RateConstant, RPS: 100},
+			elapsed: time.Minute,
+			want:    100,
+		},
+		{
+			name:    "ramp start",
+			rate:    config.Rate{Profile: config.RateRamp, StartRPS: 10, RPS: 110, RampDuration: 10 * time.Second},
+			elapsed: 0,
+			want:    10,
+		},
+		{
+			name:    "ramp middle",
+			rate:    config.Rate{Profile: config.RateRamp, StartRPS: 10, RPS: 110, RampDuration: 10 * time.Second},
+			elapsed: 5 * time.Second,
+			want:    60,
+		},
+		{
+			name:    "after ramp",
+			rate:    config.Rate{Profile: config.RateRamp, StartRPS: 10, RPS: 110, RampDuration: 10 * time.Second},
+			elapsed: time.Minute,
+			want:    110,
+		},
+		{
+			name:    "ramp down",
+			rate:    config.Rate{Profile: config.RateRamp, StartRPS: 100, RPS: 0.5, RampDuration: 10 * time.Second},
+			elapsed: 10 * time.Second,
+			want:    0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewPacer(tt.rate).rateAt(tt.elapsed))
+		})
+	}
+}
+
+func TestKeyChooser(t *testing.T) {
+	keys := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	const samples = 100000
+
+	tests := []struct {
+		name  string
+		cfg   config.Keys
+		check func(t *testing.T, counts map[int]int)
+	}{
+		{
+			name: "uniform",
+			cfg:  config.Keys{Distribution: config.KeysUniform},
+			check: func(t *testing.T, counts map[int]int) {
+				for _, key := range keys {
+					assert.Assert(t, counts[key] > samples/10*9/10, "key %d: %d", key, counts[key])
+				}
+			},
+		},
+		{
+			name: "zipfian",
+			cfg:  config.Keys{Distribution: config.KeysZipfian, ZipfS: 1.5},
+			check: func(t *testing.T, counts map[int]int) {
+				for i := 1; i < len(keys); i++ {
+					assert.Assert(t, counts[keys[i-1]] > counts[keys[i]], "key %d: %d", keys[i], counts[keys[i]])
+				}
+			},
+		},
+		{
+			name: "hotspot",
+			cfg:  config.Keys{Distribution: config.KeysHotspot, HotFraction: 0.2, HotProbability: 0.8},
+			check: func(t *testing.T, counts map[int]int) {
+				hot := counts[1] + counts[2]
+				assert.Assert(t, hot > samples*78/100 && hot < samples*82/100, "hot keys: %d", hot)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chooser := newKeyChooser(keys, tt.cfg, rand.New(rand.NewSource(1)))
+
+			counts := make(map[int]int)
+			for i := 0; i < samples; i++ {
+				counts[chooser.next()]++
+			}
+
+			assert.Equal(t, len(keys), len(counts))
+			tt.check(t, counts)
+		})
+	}
+}
@@ -0,0 +1,654 @@
+//Пакет config содержит настройки сервера. Итоговые настройки собираются из нескольких источников в порядке
+//возрастания приоритета: значения по умолчанию, файл конфигурации, переменные окружения, флаги командной строки.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vps2/accounttesttask/pkg/currency"
+	"github.com/vps2/accounttesttask/pkg/labels"
+	"github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/tracing"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Допустимые значения настроек
+const (
+	CachePolicyLRU  = "lru"
+	CachePolicyNone = "none"
+
+	StorageInMem = "inmem"
+	StoragePg    = "pg"
+
+	AccrualInterest = "interest"
+	AccrualFee      = "fee"
+
+	AccrualDaily   = "daily"
+	AccrualMonthly = "monthly"
+)
+
+type Config struct {
+	Server Server `yaml:"server"`
+}
+
+type Server struct {
+	Addr            string        `yaml:"addr"`
+	HTTPAddr        string        `yaml:"http_addr"`
+	Cache           Cache         `yaml:"cache"`
+	Storage         Storage       `yaml:"storage"`
+	PollingInterval time.Duration `yaml:"polling_interval"`
+	//HoldSweepInterval - интервал удаления истёкших резервирований
+	HoldSweepInterval time.Duration `yaml:"hold_sweep_interval"`
+	Scheduler         Scheduler     `yaml:"scheduler"`
+	Accrual           Accrual       `yaml:"accrual"`
+	TLS               TLS           `yaml:"tls"`
+	Limits            Limits        `yaml:"limits"`
+	LogLevel          string        `yaml:"log_level"`
+	LogFormat         string        `yaml:"log_format"`
+	//SlowCallThreshold - длительность вызова, после которой он выводится в лог как медленный. Ноль отключает проверку.
+	SlowCallThreshold time.Duration `yaml:"slow_call_threshold"`
+	Tracing           Tracing       `yaml:"tracing"`
+	//DefaultCurrency - валюта новых счетов, для которых в запросе не указана валюта, и счетов, созданных до
+	//поддержки нескольких валют.
+	DefaultCurrency string `yaml:"default_currency"`
+	//RecordFile - файл, в конец которого записываются вызовы сервиса счетов для последующего воспроизведения
+	//командой replay клиента. Пустое значение отключает запись.
+	RecordFile string `yaml:"record_file"`
+}
+
+type Cache struct {
+	Size   int    `yaml:"size"`
+	Policy string `yaml:"policy"`
+}
+
+type Storage struct {
+	//Backend - тип хранилища. Если не задан, то при заданном PgURL используется postgresql, иначе хранилище в памяти.
+	Backend string `yaml:"backend"`
+	PgURL   string `yaml:"pg_url"`
+}
+
+type Scheduler struct {
+	//Interval - интервал проверки наступивших запусков расписаний операций
+	Interval time.Duration `yaml:"interval"`
+	//MisfireThreshold - опоздание запуска, например из-за простоя сервера, после которого к нему применяется
+	//политика пропущенных запусков расписания.
+	MisfireThreshold time.Duration `yaml:"misfire_threshold"`
+}
+
+type Accrual struct {
+	//Interval - интервал запуска начислений. Каждый запуск обрабатывает последний завершённый период каждого правила.
+	Interval time.Duration `yaml:"interval"`
+	//Rules - правила начисления процентов и списания комиссий. Задаются только в файле конфигурации.
+	Rules []AccrualRule `yaml:"rules"`
+}
+
+type AccrualRule struct {
+	//Name - уникальное имя правила. Входит в ключ идемпотентности проводок, поэтому не должно изменяться.
+	Name string `yaml:"name"`
+	//Kind - interest (проценты на положительный баланс) или fee (списание фиксированной комиссии).
+	Kind string `yaml:"kind"`
+	//Period - daily или monthly.
+	Period string `yaml:"period"`
+	//AnnualRate - годовая ставка процентов в виде десятичной дроби, например "0.035".
+	AnnualRate string `yaml:"annual_rate"`
+	//Amount - комиссия в минорных единицах валюты счёта.
+	Amount int64 `yaml:"amount"`
+	//Selector - селектор меток счетов, к которым применяется правило. Пустой селектор означает все счета.
+	Selector string `yaml:"selector"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type Tracing struct {
+	//Exporter - тип экспортёра span'ов: none, stdout, file или otlp.
+	Exporter string `yaml:"exporter"`
+	//Target - путь к файлу для экспортёра file или адрес коллектора для экспортёра otlp.
+	Target string `yaml:"target"`
+}
+
+type Limits struct {
+	//RateLimit - допустимое количество запросов в секунду. Ноль отключает ограничение.
+	RateLimit            float64 `yaml:"rate_limit"`
+	RateBurst            int     `yaml:"rate_burst"`
+	MaxConcurrentStreams uint32  `yaml:"max_concurrent_streams"`
+	MaxRecvMsgSize       int     `yaml:"max_recv_msg_size"`
+	//MaxOperationAmount - максимальная сумма одной операции в минорных единицах валюты. Ноль отключает ограничение.
+	MaxOperationAmount int64 `yaml:"max_operation_amount"`
+	//MaxBalance - максимальный баланс счёта в минорных единицах валюты. Ноль отключает ограничение.
+	MaxBalance int64 `yaml:"max_balance"`
+}
+
+//Default возвращает настройки по умолчанию.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Addr: ":8080",
+			Cache: Cache{
+				Size:   10,
+				Policy: CachePolicyLRU,
+			},
+			PollingInterval:   30 * time.Second,
+			HoldSweepInterval: 10 * time.Second,
+			Scheduler: Scheduler{
+				Interval:         10 * time.Second,
+				MisfireThreshold: time.Minute,
+			},
+			Accrual: Accrual{
+				Interval: time.Hour,
+			},
+			Limits: Limits{
+				RateBurst: 100,
+			},
+			LogLevel:          log.LevelInfo.String(),
+			LogFormat:         log.FormatText.String(),
+			SlowCallThreshold: time.Second,
+			Tracing: Tracing{
+				Exporter: tracing.ExporterNone,
+			},
+			DefaultCurrency: currency.Default,
+		},
+	}
+}
+
+//ValidationError содержит все найденные ошибки в настройках.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e, "; ")
+}
+
+//setting описывает настройку, которую можно переопределить переменной окружения или флагом.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(srv *Server, value string) error
+}
+
+var settings = []setting{
+	{"addr", "ADDR", "listening address", func(srv *Server, v string) error {
+		srv.Addr = v
+		return nil
+	}},
+	{"http-addr", "HTTP_ADDR", "listening address of the HTTP/JSON gateway. If empty, the gateway is disabled", func(srv *Server, v string) error {
+		srv.HTTPAddr = v
+		return nil
+	}},
+	{"cache-size", "CACHE_SIZE", "cache size", func(srv *Server, v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			srv.Cache.Size = n
+		}
+		return err
+	}},
+	{"cache-policy", "CACHE_POLICY", "cache policy: lru or none", func(srv *Server, v string) error {
+		srv.Cache.Policy = v
+		return nil
+	}},
+	{"storage", "STORAGE", "storage backend: inmem or pg. If omitted, pg is used when the postgresql connection" +
+		" string is specified", func(srv *Server, v string) error {
+		srv.Storage.Backend = v
+		return nil
+	}},
+	{"pg-url", "PG_URL", "the postgresql connection string", func(srv *Server, v string) error {
+		srv.Storage.PgURL = v
+		return nil
+	}},
+	{"polling-interval", "POLLING_INTERVAL", "polling interval by the statistics collection service", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.PollingInterval = d
+		}
+		return err
+	}},
+	{"hold-sweep-interval", "HOLD_SWEEP_INTERVAL", "interval of releasing expired holds", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.HoldSweepInterval = d
+		}
+		return err
+	}},
+	{"scheduler-interval", "SCHEDULER_INTERVAL", "interval of checking schedules for due runs", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.Scheduler.Interval = d
+		}
+		return err
+	}},
+	{"scheduler-misfire-threshold", "SCHEDULER_MISFIRE_THRESHOLD", "delay after which a scheduled run is considered" +
+		" missed and handled by the catch-up policy of the schedule", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.Scheduler.MisfireThreshold = d
+		}
+		return err
+	}},
+	{"accrual-interval", "ACCRUAL_INTERVAL", "interval of running interest and fee accruals", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.Accrual.Interval = d
+		}
+		return err
+	}},
+	{"tls-cert-file", "TLS_CERT_FILE", "path to the TLS certificate file", func(srv *Server, v string) error {
+		srv.TLS.CertFile = v
+		return nil
+	}},
+	{"tls-key-file", "TLS_KEY_FILE", "path to the TLS key file", func(srv *Server, v string) error {
+		srv.TLS.KeyFile = v
+		return nil
+	}},
+	{"rate-limit", "RATE_LIMIT", "allowed number of requests per second. Zero disables the limit", func(srv *Server, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			srv.Limits.RateLimit = f
+		}
+		return err
+	}},
+	{"rate-burst", "RATE_BURST", "maximum burst of requests above the rate limit", func(srv *Server, v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			srv.Limits.RateBurst = n
+		}
+		return err
+	}},
+	{"max-concurrent-streams", "MAX_CONCURRENT_STREAMS", "maximum number of concurrent streams per connection." +
+		" Zero means no limit", func(srv *Server, v string) error {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err == nil {
+			srv.Limits.MaxConcurrentStreams = uint32(n)
+		}
+		return err
+	}},
+	{"max-recv-msg-size", "MAX_RECV_MSG_SIZE", "maximum size of a received message in bytes. Zero means the gRPC" +
+		" default", func(srv *Server, v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			srv.Limits.MaxRecvMsgSize = n
+		}
+		return err
+	}},
+	{"max-operation-amount", "MAX_OPERATION_AMOUNT", "maximum amount of a single operation in minor currency units." +
+		" Zero means no limit", func(srv *Server, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			srv.Limits.MaxOperationAmount = n
+		}
+		return err
+	}},
+	{"max-balance", "MAX_BALANCE", "maximum balance of an account in minor currency units. Zero means no" +
+		" limit", func(srv *Server, v string) error {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			srv.Limits.MaxBalance = n
+		}
+		return err
+	}},
+	{"log-level", "LOG_LEVEL", "minimum level of log messages: debug, info, warning or error", func(srv *Server, v string) error {
+		srv.LogLevel = v
+		return nil
+	}},
+	{"log-format", "LOG_FORMAT", "format of log messages: text or json", func(srv *Server, v string) error {
+		srv.LogFormat = v
+		return nil
+	}},
+	{"slow-call-threshold", "SLOW_CALL_THRESHOLD", "duration after which a call is logged as slow. Zero disables" +
+		" the check", func(srv *Server, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			srv.SlowCallThreshold = d
+		}
+		return err
+	}},
+	{"trace-exporter", "TRACE_EXPORTER", "trace exporter: none, stdout, file or otlp", func(srv *Server, v string) error {
+		srv.Tracing.Exporter = v
+		return nil
+	}},
+	{"trace-target", "TRACE_TARGET", "path to the trace file for the file exporter or the collector address for" +
+		" the otlp exporter", func(srv *Server, v string) error {
+		srv.Tracing.Target = v
+		return nil
+	}},
+	{"default-currency", "DEFAULT_CURRENCY", "ISO 4217 code of the currency of new accounts when a request does not" +
+		" specify one", func(srv *Server, v string) error {
+		srv.DefaultCurrency = v
+		return nil
+	}},
+	{"record-file", "RECORD_FILE", "path to a file to append the calls of the accounts service to for a later" +
+		" replay. Empty value disables the recording", func(srv *Server, v string) error {
+		srv.RecordFile = v
+		return nil
+	}},
+}
+
+//Flags хранит значения флагов командной строки, переопределяющих настройки.
+type Flags struct {
+	fs     *flag.FlagSet
+	values map[string]*flagValue
+}
+
+type flagValue struct {
+	value string
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+//BindFlags регистрирует в fs флаги для всех настроек сервера.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{
+		fs:     fs,
+		values: make(map[string]*flagValue, len(settings)),
+	}
+
+	for _, s := range settings {
+		val := &flagValue{}
+		flags.values[s.flag] = val
+		fs.Var(val, s.flag, fmt.Sprintf("%s. Overrides the %s environment variable", s.usage, s.env))
+	}
+
+	return flags
+}
+
+//Load собирает настройки из значений по умолчанию, файла path (если задан), переменных окружения и флагов
+//(если заданы). Ошибки разбора значений и проверки настроек возвращаются все сразу в виде ValidationError.
+func Load(path string, lookupEnv func(string) (string, bool), flags *Flags) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		//пустой файл конфигурации не является ошибкой
+		if err := yaml.NewDecoder(file).Decode(cfg); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	var errs ValidationError
+
+	if lookupEnv != nil {
+		for _, s := range settings {
+			if v, ok := lookupEnv(s.env); ok && v != "" {
+				if err := s.set(&cfg.Server, v); err != nil {
+					errs = append(errs, fmt.Sprintf("environment variable %s: invalid value %q", s.env, v))
+				}
+			}
+		}
+	}
+
+	if flags != nil {
+		set := make(map[string]bool)
+		flags.fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+
+		for _, s := range settings {
+			if set[s.flag] {
+				v := flags.values[s.flag].value
+				if err := s.set(&cfg.Server, v); err != nil {
+					errs = append(errs, fmt.Sprintf("flag --%s: invalid value %q", s.flag, v))
+				}
+			}
+		}
+	}
+
+	if cfg.Server.Storage.Backend == "" {
+		if cfg.Server.Storage.PgURL != "" {
+			cfg.Server.Storage.Backend = StoragePg
+		} else {
+			cfg.Server.Storage.Backend = StorageInMem
+		}
+	}
+
+	cfg.Server.DefaultCurrency = strings.ToUpper(cfg.Server.DefaultCurrency)
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err.(ValidationError)...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return cfg, nil
+}
+
+//Validate проверяет настройки и возвращает ValidationError со всеми найденными ошибками.
+func (cfg *Config) Validate() error {
+	var errs ValidationError
+
+	srv := &cfg.Server
+	if srv.Addr == "" {
+		errs = append(errs, "addr must not be empty")
+	}
+	if srv.HTTPAddr != "" && srv.HTTPAddr == srv.Addr {
+		errs = append(errs, "http_addr must differ from addr")
+	}
+
+	switch srv.Cache.Policy {
+	case CachePolicyLRU:
+		if srv.Cache.Size <= 0 {
+			errs = append(errs, fmt.Sprintf("cache.size must be positive for the %q policy, got %d", CachePolicyLRU, srv.Cache.Size))
+		}
+	case CachePolicyNone:
+	default:
+		errs = append(errs, fmt.Sprintf("cache.policy must be %q or %q, got %q", CachePolicyLRU, CachePolicyNone, srv.Cache.Policy))
+	}
+
+	switch srv.Storage.Backend {
+	case StorageInMem:
+	case StoragePg:
+		if srv.Storage.PgURL == "" {
+			errs = append(errs, "storage.pg_url must be set for the pg backend")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("storage.backend must be %q or %q, got %q", StorageInMem, StoragePg, srv.Storage.Backend))
+	}
+
+	if srv.PollingInterval < time.Second {
+		errs = append(errs, fmt.Sprintf("polling_interval must be at least 1s, got %s", srv.PollingInterval))
+	}
+
+	if srv.HoldSweepInterval <= 0 {
+		errs = append(errs, fmt.Sprintf("hold_sweep_interval must be positive, got %s", srv.HoldSweepInterval))
+	}
+
+	if srv.Scheduler.Interval <= 0 {
+		errs = append(errs, fmt.Sprintf("scheduler.interval must be positive, got %s", srv.Scheduler.Interval))
+	} else if srv.Scheduler.MisfireThreshold < srv.Scheduler.Interval {
+		errs = append(errs, fmt.Sprintf("scheduler.misfire_threshold must not be less than scheduler.interval, got %s",
+			srv.Scheduler.MisfireThreshold))
+	}
+
+	if srv.Accrual.Interval <= 0 {
+		errs = append(errs, fmt.Sprintf("accrual.interval must be positive, got %s", srv.Accrual.Interval))
+	}
+	names := make(map[string]bool, len(srv.Accrual.Rules))
+	for i, rule := range srv.Accrual.Rules {
+		errs = append(errs, rule.validate(i, names)...)
+	}
+
+	if (srv.TLS.CertFile == "") != (srv.TLS.KeyFile == "") {
+		errs = append(errs, "tls.cert_file and tls.key_file must be set together")
+	}
+
+	if srv.Limits.RateLimit < 0 {
+		errs = append(errs, fmt.Sprintf("limits.rate_limit must not be negative, got %v", srv.Limits.RateLimit))
+	}
+	if srv.Limits.RateLimit > 0 && srv.Limits.RateBurst <= 0 {
+		errs = append(errs, fmt.Sprintf("limits.rate_burst must be positive when the rate limit is set, got %d", srv.Limits.RateBurst))
+	}
+	if srv.Limits.MaxRecvMsgSize < 0 {
+		errs = append(errs, fmt.Sprintf("limits.max_recv_msg_size must not be negative, got %d", srv.Limits.MaxRecvMsgSize))
+	}
+	if srv.Limits.MaxOperationAmount < 0 {
+		errs = append(errs, fmt.Sprintf("limits.max_operation_amount must not be negative, got %d", srv.Limits.MaxOperationAmount))
+	}
+	if srv.Limits.MaxBalance < 0 {
+		errs = append(errs, fmt.Sprintf("limits.max_balance must not be negative, got %d", srv.Limits.MaxBalance))
+	}
+
+	if _, err := log.ParseLevel(srv.LogLevel); err != nil {
+		errs = append(errs, fmt.Sprintf("log_level: %s", err))
+	}
+	if _, err := log.ParseFormat(srv.LogFormat); err != nil {
+		errs = append(errs, fmt.Sprintf("log_format: %s", err))
+	}
+	if srv.SlowCallThreshold < 0 {
+		errs = append(errs, fmt.Sprintf("slow_call_threshold must not be negative, got %s", srv.SlowCallThreshold))
+	}
+
+	switch srv.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterFile, tracing.ExporterOTLP:
+		if srv.Tracing.Target == "" {
+			errs = append(errs, fmt.Sprintf("tracing.target must be set for the %q exporter", srv.Tracing.Exporter))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("tracing.exporter must be %q, %q, %q or %q, got %q", tracing.ExporterNone,
+			tracing.ExporterStdout, tracing.ExporterFile, tracing.ExporterOTLP, srv.Tracing.Exporter))
+	}
+
+	if _, err := currency.Lookup(srv.DefaultCurrency); err != nil {
+		errs = append(errs, fmt.Sprintf("default_currency: %s", err))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+//validate проверяет i-е правило начисления. Имена уже проверенных правил накапливаются в names.
+func (rule *AccrualRule) validate(i int, names map[string]bool) []string {
+	var errs []string
+
+	prefix := fmt.Sprintf("accrual.rules[%d]", i)
+	switch {
+	case rule.Name == "":
+		errs = append(errs, prefix+".name must not be empty")
+	case names[rule.Name]:
+		errs = append(errs, fmt.Sprintf("%s.name must be unique, got %q", prefix, rule.Name))
+	}
+	names[rule.Name] = true
+
+	switch rule.Kind {
+	case AccrualInterest:
+		if rate, ok := new(big.Rat).SetString(rule.AnnualRate); !ok || rate.Sign() <= 0 {
+			errs = append(errs, fmt.Sprintf("%s.annual_rate must be a positive decimal number, got %q", prefix, rule.AnnualRate))
+		}
+	case AccrualFee:
+		if rule.Amount <= 0 {
+			errs = append(errs, fmt.Sprintf("%s.amount must be positive, got %d", prefix, rule.Amount))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("%s.kind must be %q or %q, got %q", prefix, AccrualInterest, AccrualFee, rule.Kind))
+	}
+
+	if rule.Period != AccrualDaily && rule.Period != AccrualMonthly {
+		errs = append(errs, fmt.Sprintf("%s.period must be %q or %q, got %q", prefix, AccrualDaily, AccrualMonthly, rule.Period))
+	}
+	if _, err := labels.Parse(rule.Selector); err != nil {
+		errs = append(errs, fmt.Sprintf("%s.selector: %s", prefix, err))
+	}
+
+	return errs
+}
+
+//StaticChanges возвращает наименования настроек, значения которых в other отличаются от текущих, но не могут быть
+//применены без перезапуска сервера. Во время работы могут изменяться только размер кэша, интервал сбора
+//статистики, ограничение количества запросов, уровень и формат логирования, порог медленного вызова.
+func (cfg *Config) StaticChanges(other *Config) []string {
+	var changes []string
+
+	prev, next := &cfg.Server, &other.Server
+	if prev.Addr != next.Addr {
+		changes = append(changes, "addr")
+	}
+	if prev.HTTPAddr != next.HTTPAddr {
+		changes = append(changes, "http_addr")
+	}
+	if prev.Cache.Policy != next.Cache.Policy {
+		changes = append(changes, "cache.policy")
+	}
+	if prev.Storage != next.Storage {
+		changes = append(changes, "storage")
+	}
+	if prev.HoldSweepInterval != next.HoldSweepInterval {
+		changes = append(changes, "hold_sweep_interval")
+	}
+	if prev.Scheduler != next.Scheduler {
+		changes = append(changes, "scheduler")
+	}
+	if !reflect.DeepEqual(prev.Accrual, next.Accrual) {
+		changes = append(changes, "accrual")
+	}
+	if prev.TLS != next.TLS {
+		changes = append(changes, "tls")
+	}
+	if prev.Limits.MaxConcurrentStreams != next.Limits.MaxConcurrentStreams {
+		changes = append(changes, "limits.max_concurrent_streams")
+	}
+	if prev.Limits.MaxRecvMsgSize != next.Limits.MaxRecvMsgSize {
+		changes = append(changes, "limits.max_recv_msg_size")
+	}
+	if prev.Limits.MaxOperationAmount != next.Limits.MaxOperationAmount {
+		changes = append(changes, "limits.max_operation_amount")
+	}
+	if prev.Limits.MaxBalance != next.Limits.MaxBalance {
+		changes = append(changes, "limits.max_balance")
+	}
+	if prev.Tracing != next.Tracing {
+		changes = append(changes, "tracing")
+	}
+	if prev.DefaultCurrency != next.DefaultCurrency {
+		changes = append(changes, "default_currency")
+	}
+	if prev.RecordFile != next.RecordFile {
+		changes = append(changes, "record_file")
+	}
+
+	return changes
+}
+
+//String возвращает настройки в формате YAML. Пароль в строке подключения к postgresql скрывается.
+func (cfg *Config) String() string {
+	c := *cfg
+	if u, err := url.Parse(c.Server.Storage.PgURL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			c.Server.Storage.PgURL = u.String()
+		}
+	}
+
+	out, err := yaml.Marshal(&c)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(out)
+}
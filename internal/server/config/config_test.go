@@ -0,0 +1,148 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestLoad_Precedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yml")
+	content := "server:\n addr: \":9000\"\n cache:\n  size: 20\n polling_interval: 10s\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"ADDR":       ":9001",
+		"CACHE_SIZE": "30",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	if err := fs.Parse([]string{"--addr", ":9002"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path, lookupEnv, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, ":9002", cfg.Server.Addr)                   //флаг
+	assert.Equal(t, 30, cfg.Server.Cache.Size)                  //переменная окружения
+	assert.Equal(t, 10*time.Second, cfg.Server.PollingInterval) //файл
+	assert.Equal(t, CachePolicyLRU, cfg.Server.Cache.Policy)    //по умолчанию
+	assert.Equal(t, StorageInMem, cfg.Server.Storage.Backend)   //по умолчанию
+}
+
+func TestLoad_EmptyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Default().Server.Addr, cfg.Server.Addr)
+	assert.Equal(t, Default().Server.PollingInterval, cfg.Server.PollingInterval)
+	assert.Equal(t, StorageInMem, cfg.Server.Storage.Backend)
+}
+
+func TestLoad_Validation(t *testing.T) {
+	env := map[string]string{
+		"CACHE_SIZE":                  "abc",
+		"STORAGE":                     StoragePg,
+		"POLLING_INTERVAL":            "100ms",
+		"TLS_CERT_FILE":               "cert.pem",
+		"DEFAULT_CURRENCY":            "XYZ",
+		"MAX_BALANCE":                 "-1",
+		"SCHEDULER_MISFIRE_THRESHOLD": "1s",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	_, err := Load("", lookupEnv, nil)
+
+	errs, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Load(): expected ValidationError, got %v", err)
+	}
+
+	assert.DeepEqual(t, ValidationError{
+		`environment variable CACHE_SIZE: invalid value "abc"`,
+		"storage.pg_url must be set for the pg backend",
+		"polling_interval must be at least 1s, got 100ms",
+		"scheduler.misfire_threshold must not be less than scheduler.interval, got 1s",
+		"tls.cert_file and tls.key_file must be set together",
+		"limits.max_balance must not be negative, got -1",
+		`default_currency: unknown currency "XYZ"`,
+	}, errs)
+}
+
+func TestConfig_ValidateAccrualRules(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Storage.Backend = StorageInMem
+	cfg.Server.Accrual.Rules = []AccrualRule{
+		{Name: "interest", Kind: AccrualInterest, Period: AccrualDaily, AnnualRate: "0.035", Selector: "product=savings"},
+		{Name: "fee", Kind: AccrualFee, Period: AccrualMonthly, Amount: 100},
+		{Name: "fee", Kind: AccrualFee, Period: "weekly"},
+		{Kind: AccrualInterest, Period: AccrualDaily, AnnualRate: "-1", Selector: "a=="},
+		{Name: "bonus", Kind: "bonus", Period: AccrualDaily},
+	}
+
+	errs, ok := cfg.Validate().(ValidationError)
+	if !ok {
+		t.Fatalf("Validate(): expected ValidationError")
+	}
+
+	assert.DeepEqual(t, ValidationError{
+		`accrual.rules[2].name must be unique, got "fee"`,
+		"accrual.rules[2].amount must be positive, got 0",
+		`accrual.rules[2].period must be "daily" or "monthly", got "weekly"`,
+		"accrual.rules[3].name must not be empty",
+		`accrual.rules[3].annual_rate must be a positive decimal number, got "-1"`,
+		`accrual.rules[3].selector: invalid label selector: invalid value in "a=="`,
+		`accrual.rules[4].kind must be "interest" or "fee", got "bonus"`,
+	}, errs)
+}
+
+func TestConfig_StaticChanges(t *testing.T) {
+	prev := Default()
+
+	next := Default()
+	next.Server.Addr = ":9000"
+	next.Server.Cache.Size = 100
+	next.Server.Storage.PgURL = "postgres://localhost/accounts"
+	next.Server.PollingInterval = time.Minute
+	next.Server.Limits.RateLimit = 10
+	next.Server.Limits.MaxRecvMsgSize = 1024
+
+	assert.DeepEqual(t, []string{"addr", "storage", "limits.max_recv_msg_size"}, prev.StaticChanges(next))
+}
@@ -0,0 +1,126 @@
+//Пакет errcode содержит общее для всех транспортов (gRPC, HTTP) сопоставление ошибок сервисов кодам
+//состояния gRPC.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/internal/server/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//Code возвращает код состояния gRPC, соответствующий ошибке err.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+
+	switch {
+	case errors.Is(err, service.ErrNonPositiveHoldAmount),
+		errors.Is(err, service.ErrNonPositiveHoldTTL),
+		errors.Is(err, service.ErrNonPositiveCaptureValue),
+		errors.Is(err, service.ErrUnknownCurrency),
+		errors.Is(err, service.ErrInvalidFXRate),
+		errors.Is(err, service.ErrNonPositiveTransfer),
+		errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrAmountOutOfRange),
+		errors.Is(err, service.ErrInvalidBalanceId),
+		errors.Is(err, service.ErrZeroAmount),
+		errors.Is(err, service.ErrAmountTooLarge),
+		errors.Is(err, service.ErrBalanceLimitExceeded),
+		errors.Is(err, repository.ErrAmountOutOfRange),
+		errors.Is(err, service.ErrUnknownStatus),
+		errors.Is(err, service.ErrInvalidLabels),
+		errors.Is(err, service.ErrInvalidLabelSelector),
+		errors.Is(err, service.ErrInvalidBalanceRange),
+		errors.Is(err, service.ErrInvalidOrderBy),
+		errors.Is(err, service.ErrInvalidPageSize),
+		errors.Is(err, service.ErrInvalidPageToken),
+		errors.Is(err, service.ErrInvalidCron),
+		errors.Is(err, service.ErrUnknownCatchUpPolicy),
+		errors.Is(err, service.ErrMissingStartTime),
+		errors.Is(err, service.ErrScheduleNeverRuns):
+		return codes.InvalidArgument
+	case errors.Is(err, service.ErrInsufficientFunds),
+		errors.Is(err, service.ErrNonPositiveInitialValue),
+		errors.Is(err, service.ErrBalanceBelowMinimum),
+		errors.Is(err, service.ErrCurrencyMismatch),
+		errors.Is(err, repository.ErrMinBalanceViolation),
+		errors.Is(err, repository.ErrCaptureExceedsHold),
+		errors.Is(err, repository.ErrAccountNotEmpty),
+		errors.Is(err, service.ErrScheduleNotActive),
+		errors.Is(err, repository.ErrScheduleNotActive):
+		return codes.FailedPrecondition
+	case errors.Is(err, service.ErrAccountFrozen),
+		errors.Is(err, service.ErrAccountClosed):
+		return codes.PermissionDenied
+	case errors.Is(err, repository.ErrAccountNotFound),
+		errors.Is(err, repository.ErrHoldNotFound),
+		errors.Is(err, repository.ErrScheduleNotFound):
+		return codes.NotFound
+	case errors.Is(err, repository.ErrAccountAlreadyExists),
+		errors.Is(err, repository.ErrDuplicateEntry):
+		return codes.AlreadyExists
+	case errors.Is(err, service.ErrVersionMismatch):
+		return codes.Aborted
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+
+	return codes.Internal
+}
+
+//Error преобразует ошибку err в ошибку gRPC с кодом состояния, полученным с помощью Code.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(Code(err), err.Error())
+}
+
+//HTTPStatus возвращает код ответа HTTP, соответствующий коду состояния gRPC. Сопоставление совпадает с
+//используемым в grpc-gateway.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
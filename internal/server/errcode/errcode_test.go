@@ -0,0 +1,47 @@
+package errcode
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/internal/server/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: nil, want: codes.OK},
+		{err: service.ErrAmountTooLarge, want: codes.InvalidArgument},
+		{err: service.ErrBalanceLimitExceeded, want: codes.InvalidArgument},
+		{err: fmt.Errorf("transfer: %w", service.ErrBalanceLimitExceeded), want: codes.InvalidArgument},
+		{err: service.ErrInsufficientFunds, want: codes.FailedPrecondition},
+		{err: service.ErrAccountFrozen, want: codes.PermissionDenied},
+		{err: repository.ErrAccountNotFound, want: codes.NotFound},
+		{err: service.ErrVersionMismatch, want: codes.Aborted},
+		{err: context.DeadlineExceeded, want: codes.DeadlineExceeded},
+		{err: status.Error(codes.Unavailable, "unavailable"), want: codes.Unavailable},
+		{err: fmt.Errorf("unknown"), want: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.err), func(t *testing.T) {
+			assert.Equal(t, tt.want, Code(tt.err))
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(Code(service.ErrBalanceLimitExceeded)))
+	assert.Equal(t, http.StatusForbidden, HTTPStatus(Code(service.ErrAccountClosed)))
+	assert.Equal(t, http.StatusConflict, HTTPStatus(Code(repository.ErrAccountAlreadyExists)))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(codes.DataLoss))
+}
@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/errcode"
+	"github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/tracing"
+	"github.com/vps2/accounttesttask/pkg/traffic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//RequestIdKey - ключ метаданных запроса и ответа, содержащий идентификатор запроса
+const RequestIdKey = "x-request-id"
+
+type requestIdKey struct{}
+
+//RequestIdFromContext возвращает идентификатор запроса, сохранённый RequestIdInterceptor.
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+
+	return id
+}
+
+//RequestIdInterceptor берёт идентификатор запроса из метаданных x-request-id или создаёт новый, если его там нет.
+//Идентификатор возвращается клиенту в заголовке ответа и добавляется к сообщениям логера из контекста запроса.
+func RequestIdInterceptor() UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIdKey); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		if id == "" {
+			id = newRequestId()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIdKey, id))
+
+		ctx = context.WithValue(ctx, requestIdKey{}, id)
+		ctx = log.NewContext(ctx, log.FromContext(ctx).With(log.F(log.FieldRequestId, id)))
+
+		return handler(ctx, req)
+	}
+}
+
+//AccessLogInterceptor выводит в лог одну строку на каждый вызов с наименованием метода, адресом клиента, кодом
+//состояния и длительностью. Вызовы дольше порога, возвращаемого slowThreshold, выводятся с уровнем WARNING.
+//Нулевой порог отключает выделение медленных вызовов.
+func AccessLogInterceptor(slowThreshold func() time.Duration) UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		duration := time.Since(start)
+
+		var addr string
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+		}
+
+		fields := []log.Field{
+			log.F(log.FieldMethod, info.FullMethod),
+			log.F("peer", addr),
+			log.F("code", errcode.Code(err)),
+			log.F(log.FieldLatency, duration),
+		}
+
+		logger := log.FromContext(ctx)
+		if threshold := slowThreshold(); threshold > 0 && duration > threshold {
+			logger.Warning("slow call", fields...)
+		} else {
+			logger.Info("call", fields...)
+		}
+
+		return resp, err
+	}
+}
+
+//RecoveryInterceptor перехватывает панику в обработчике запроса, выводит в лог стек вызовов и возвращает клиенту
+//ошибку с кодом Internal.
+func RecoveryInterceptor() UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.FromContext(ctx).Error("panic recovered",
+					log.F(log.FieldMethod, info.FullMethod),
+					log.F("panic", fmt.Sprint(r)),
+					log.F("stack", string(debug.Stack())))
+
+				resp, err = nil, status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+//TracingInterceptor создаёт серверный span для каждого вызова. Если клиент передал контекст трассировки W3C
+//в метаданных запроса, span становится продолжением трассировки клиента.
+func TracingInterceptor() UnaryServerInterceptor {
+	tracer := otel.Tracer("github.com/vps2/accounttesttask/internal/server/grpc")
+
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		ctx, span := tracer.Start(tracing.Extract(ctx), info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(label.String("rpc.system", "grpc"), label.String("rpc.method", info.FullMethod)),
+		)
+		defer span.End()
+
+		if id := RequestIdFromContext(ctx); id != "" {
+			span.SetAttributes(label.String(log.FieldRequestId, id))
+		}
+
+		resp, err := handler(ctx, req)
+		tracing.SetStatus(span, errcode.Error(err))
+
+		return resp, err
+	}
+}
+
+//RateLimitInterceptor отклоняет запросы с кодом ResourceExhausted, если превышено допустимое количество запросов
+//в секунду, заданное limiter.
+func RateLimitInterceptor(limiter *rate.Limiter) UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		if !limiter.Allow() {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s: rate limit exceeded", info.FullMethod)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+//RecordingInterceptor записывает в w каждый вызов методов сервиса service, например "api.AccountsService",
+//с моментом начала, запросом, кодом состояния и ответом. Ошибка записи выводится в лог и не влияет на вызов.
+func RecordingInterceptor(w *traffic.Writer, service string) UnaryServerInterceptor {
+	prefix := "/" + service + "/"
+
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, prefix) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		rec, recErr := traffic.NewRecord(start, info.FullMethod, req, resp, errcode.Error(err))
+		if recErr == nil {
+			recErr = w.Write(rec)
+		}
+		if recErr != nil {
+			log.FromContext(ctx).Error("call is not recorded",
+				log.F(log.FieldMethod, info.FullMethod),
+				log.F(log.FieldError, recErr))
+		}
+
+		return resp, err
+	}
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/pkg/log"
+	"github.com/vps2/accounttesttask/pkg/traffic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := log.NewContext(context.Background(), log.New(buf))
+	info := &UnaryServerInfo{FullMethod: "/api.AccountsService/GetAmount"}
+
+	resp, err := RecoveryInterceptor()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("some panic")
+	})
+
+	assert.Assert(t, resp == nil)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Assert(t, strings.Contains(buf.String(), "panic recovered"))
+	assert.Assert(t, strings.Contains(buf.String(), "some panic"))
+	assert.Assert(t, strings.Contains(buf.String(), "stack="))
+}
+
+func TestRequestIdInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want func(id string) bool
+	}{
+		{
+			name: "propagated",
+			md:   metadata.Pairs(RequestIdKey, "abc"),
+			want: func(id string) bool { return id == "abc" },
+		},
+		{
+			name: "generated",
+			md:   metadata.MD{},
+			want: func(id string) bool { return len(id) == 32 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &UnaryServerInfo{FullMethod: "/api.AccountsService/GetAmount"}
+
+			var got string
+			RequestIdInterceptor()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = RequestIdFromContext(ctx)
+				return nil, nil
+			})
+
+			if !tt.want(got) {
+				t.Errorf("RequestIdInterceptor(): unexpected request id %q", got)
+			}
+		})
+	}
+}
+
+func TestAccessLogInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		err       error
+		want      string
+	}{
+		{
+			name:      "fast call",
+			threshold: time.Hour,
+			want:      "[INFO] call method=/api.AccountsService/GetAmount peer=\"\" code=OK",
+		},
+		{
+			name:      "slow call",
+			threshold: time.Nanosecond,
+			err:       status.Error(codes.NotFound, "not found"),
+			want:      "[WARNING] slow call method=/api.AccountsService/GetAmount peer=\"\" code=NotFound",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			ctx := log.NewContext(context.Background(), log.New(buf))
+			info := &UnaryServerInfo{FullMethod: "/api.AccountsService/GetAmount"}
+
+			interceptor := AccessLogInterceptor(func() time.Duration { return tt.threshold })
+			interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				time.Sleep(time.Millisecond)
+				return nil, tt.err
+			})
+
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("AccessLogInterceptor() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordingInterceptor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	interceptor := RecordingInterceptor(traffic.NewWriter(buf), "api.AccountsService")
+
+	calls := []struct {
+		method string
+		req    interface{}
+		resp   interface{}
+		err    error
+	}{
+		{
+			method: "/api.AccountsService/GetAmount",
+			req:    &api.GetRequest{BalanceId: 1},
+			resp:   &api.GetResponse{BalanceId: 1, Amount: 100, Currency: "USD"},
+		},
+		{
+			method: "/api.AccountsService/AddAmount",
+			req:    &api.AddRequest{BalanceId: 1, Value: -200},
+			err:    status.Error(codes.FailedPrecondition, "insufficient funds"),
+		},
+		{
+			method: "/api.AdminService/Freeze",
+			req:    &api.GetRequest{BalanceId: 1},
+		},
+	}
+	for _, c := range calls {
+		interceptor(context.Background(), c.req, &UnaryServerInfo{FullMethod: c.method},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return c.resp, c.err
+			})
+	}
+
+	records, err := traffic.Read(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(records))
+
+	assert.Equal(t, "OK", records[0].Code)
+	req, resp, err := records[0].Messages()
+	assert.NilError(t, err)
+	assert.Assert(t, proto.Equal(calls[0].req.(proto.Message), req))
+	assert.Assert(t, proto.Equal(calls[0].resp.(proto.Message), resp))
+
+	assert.Equal(t, "/api.AccountsService/AddAmount", records[1].Method)
+	assert.Equal(t, "FailedPrecondition", records[1].Code)
+	assert.Equal(t, "insufficient funds", records[1].Message)
+	assert.Equal(t, 0, len(records[1].Response))
+}
+
+func TestToOriginalGRPCUnaryInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+
+	interceptors := toOriginalGRPCUnaryInterceptors([]UnaryServerInterceptor{interceptor("first"), interceptor("second")})
+	for _, i := range interceptors {
+		i(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	}
+
+	assert.DeepEqual(t, []string{"first", "second"}, calls)
+}
@@ -5,38 +5,280 @@ import (
 	"errors"
 	"net"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/server/errcode"
+	"github.com/vps2/accounttesttask/internal/server/model"
 	"github.com/vps2/accounttesttask/internal/server/service"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
+//AccountsServiceName - полное наименование сервиса счетов
+const AccountsServiceName = "api.AccountsService"
+
+//minKeepaliveTime - минимальный период проверки соединения клиентом, при меньшем периоде соединение разрывается
+const minKeepaliveTime = 10 * time.Second
+
 type accountsServiceServer struct {
 	service service.AccountsService
 }
 
 func (srv *accountsServiceServer) GetAmount(ctx context.Context, req *api.GetRequest) (*api.GetResponse, error) {
-	amount, err := srv.service.GetAmount(ctx, req.BalanceId)
+	balance, err := srv.service.GetBalance(ctx, req.BalanceId)
 	if err != nil {
-		return nil, err
+		return nil, errcode.Error(err)
 	}
 
 	return &api.GetResponse{
 		BalanceId: req.GetBalanceId(),
-		Amount:    amount,
+		Amount:    balance.Amount,
+		Available: balance.Available,
+		Currency:  balance.Currency,
+		Version:   balance.Version,
 	}, nil
 }
 
 func (srv *accountsServiceServer) AddAmount(ctx context.Context, req *api.AddRequest) (*api.AddResponse, error) {
-	if err := srv.service.AddAmount(ctx, req.BalanceId, req.Value); err != nil {
-		return nil, err
+	var err error
+	if req.ExpectedVersion != nil {
+		err = srv.service.AddAmountIfVersion(ctx, req.BalanceId, req.Value, req.Currency, *req.ExpectedVersion, req.IdempotencyKey)
+	} else {
+		err = srv.service.AddAmount(ctx, req.BalanceId, req.Value, req.Currency, req.IdempotencyKey)
+	}
+	if err != nil {
+		return nil, errcode.Error(err)
 	}
 
 	return &api.AddResponse{}, nil
 }
 
+func (srv *accountsServiceServer) SetAmountIfVersion(ctx context.Context, req *api.SetAmountRequest) (*api.SetAmountResponse, error) {
+	if err := srv.service.SetAmountIfVersion(ctx, req.BalanceId, req.Amount, req.Currency, req.Version); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.SetAmountResponse{}, nil
+}
+
+func (srv *accountsServiceServer) Authorize(ctx context.Context, req *api.AuthorizeRequest) (*api.AuthorizeResponse, error) {
+	holdId, err := srv.service.Authorize(ctx, req.BalanceId, req.Amount, req.Currency, time.Duration(req.TtlSeconds)*time.Second, req.IdempotencyKey)
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.AuthorizeResponse{HoldId: holdId}, nil
+}
+
+func (srv *accountsServiceServer) Capture(ctx context.Context, req *api.CaptureRequest) (*api.CaptureResponse, error) {
+	if err := srv.service.Capture(ctx, req.HoldId, req.Amount); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.CaptureResponse{}, nil
+}
+
+func (srv *accountsServiceServer) Void(ctx context.Context, req *api.VoidRequest) (*api.VoidResponse, error) {
+	if err := srv.service.Void(ctx, req.HoldId); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.VoidResponse{}, nil
+}
+
+func (srv *accountsServiceServer) Transfer(ctx context.Context, req *api.TransferRequest) (*api.TransferResponse, error) {
+	credited, err := srv.service.Transfer(ctx, req.FromBalanceId, req.ToBalanceId, req.Amount, req.Currency, req.FxRate, req.IdempotencyKey)
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.TransferResponse{Credited: credited}, nil
+}
+
+func (srv *accountsServiceServer) ListAccounts(ctx context.Context, req *api.ListAccountsRequest) (*api.ListAccountsResponse, error) {
+	accounts, nextPageToken, err := srv.service.ListAccounts(ctx, &service.ListAccountsRequest{
+		LabelSelector: req.LabelSelector,
+		MinBalance:    req.MinBalance,
+		MaxBalance:    req.MaxBalance,
+		Status:        model.AccountStatus(req.Status),
+		OrderBy:       req.OrderBy,
+		PageSize:      int(req.PageSize),
+		PageToken:     req.PageToken,
+	})
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	resp := &api.ListAccountsResponse{
+		Accounts:      make([]*api.Account, 0, len(accounts)),
+		NextPageToken: nextPageToken,
+	}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, &api.Account{
+			BalanceId: account.Id,
+			Amount:    account.Balance,
+			Currency:  account.Currency,
+			Status:    string(account.Status),
+			Owner:     account.Owner,
+			Labels:    account.Labels,
+			CreatedAt: account.CreatedAt.UTC().Format(time.RFC3339Nano),
+			UpdatedAt: account.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	return resp, nil
+}
+
+type adminServiceServer struct {
+	service   service.AdminService
+	scheduler service.SchedulerService
+}
+
+func (srv *adminServiceServer) SetMinBalance(ctx context.Context, req *api.SetMinBalanceRequest) (*api.SetMinBalanceResponse, error) {
+	if err := srv.service.SetMinBalance(ctx, req.BalanceId, req.MinBalance); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.SetMinBalanceResponse{}, nil
+}
+
+func (srv *adminServiceServer) Freeze(ctx context.Context, req *api.FreezeRequest) (*api.FreezeResponse, error) {
+	status := model.StatusFrozen
+	if req.DebitsOnly {
+		status = model.StatusDebitFrozen
+	}
+
+	if err := srv.service.SetStatus(ctx, req.BalanceId, status, req.Reason); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.FreezeResponse{}, nil
+}
+
+func (srv *adminServiceServer) Unfreeze(ctx context.Context, req *api.UnfreezeRequest) (*api.UnfreezeResponse, error) {
+	if err := srv.service.SetStatus(ctx, req.BalanceId, model.StatusActive, req.Reason); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.UnfreezeResponse{}, nil
+}
+
+func (srv *adminServiceServer) Close(ctx context.Context, req *api.CloseRequest) (*api.CloseResponse, error) {
+	if err := srv.service.SetStatus(ctx, req.BalanceId, model.StatusClosed, req.Reason); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.CloseResponse{}, nil
+}
+
+func (srv *adminServiceServer) SetMetadata(ctx context.Context, req *api.SetMetadataRequest) (*api.SetMetadataResponse, error) {
+	if err := srv.service.SetMetadata(ctx, req.BalanceId, req.Owner, req.Labels); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.SetMetadataResponse{}, nil
+}
+
+func (srv *adminServiceServer) CreateSchedule(ctx context.Context, req *api.CreateScheduleRequest) (*api.CreateScheduleResponse, error) {
+	var startAt time.Time
+	if req.StartAt != "" {
+		var err error
+		if startAt, err = time.Parse(time.RFC3339, req.StartAt); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid start time %q: expected RFC 3339", req.StartAt)
+		}
+	}
+
+	schedule, err := srv.scheduler.CreateSchedule(ctx, &service.CreateScheduleRequest{
+		BalanceId:   req.BalanceId,
+		ToBalanceId: req.ToBalanceId,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Cron:        req.Cron,
+		StartAt:     startAt,
+		CatchUp:     model.CatchUpPolicy(req.CatchUp),
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.CreateScheduleResponse{Schedule: toAPISchedule(schedule)}, nil
+}
+
+func (srv *adminServiceServer) ListSchedules(ctx context.Context, req *api.ListSchedulesRequest) (*api.ListSchedulesResponse, error) {
+	schedules, err := srv.scheduler.ListSchedules(ctx, req.BalanceId)
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	resp := &api.ListSchedulesResponse{Schedules: make([]*api.Schedule, 0, len(schedules))}
+	for _, schedule := range schedules {
+		resp.Schedules = append(resp.Schedules, toAPISchedule(schedule))
+	}
+
+	return resp, nil
+}
+
+func (srv *adminServiceServer) CancelSchedule(ctx context.Context, req *api.CancelScheduleRequest) (*api.CancelScheduleResponse, error) {
+	if err := srv.scheduler.CancelSchedule(ctx, req.ScheduleId); err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	return &api.CancelScheduleResponse{}, nil
+}
+
+func (srv *adminServiceServer) ListScheduleRuns(ctx context.Context, req *api.ListScheduleRunsRequest) (*api.ListScheduleRunsResponse, error) {
+	runs, err := srv.scheduler.ListScheduleRuns(ctx, req.ScheduleId, int(req.Limit))
+	if err != nil {
+		return nil, errcode.Error(err)
+	}
+
+	resp := &api.ListScheduleRunsResponse{Runs: make([]*api.ScheduleRun, 0, len(runs))}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, &api.ScheduleRun{
+			Id:          run.Id,
+			ScheduledAt: formatTime(run.ScheduledAt),
+			StartedAt:   formatTime(run.StartedAt),
+			FinishedAt:  formatTime(run.FinishedAt),
+			Status:      string(run.Status),
+			Detail:      run.Detail,
+		})
+	}
+
+	return resp, nil
+}
+
+func toAPISchedule(schedule *model.Schedule) *api.Schedule {
+	return &api.Schedule{
+		Id:          schedule.Id,
+		BalanceId:   schedule.BalanceId,
+		ToBalanceId: schedule.ToBalanceId,
+		Amount:      schedule.Amount,
+		Currency:    schedule.Currency,
+		Cron:        schedule.Cron,
+		CatchUp:     string(schedule.CatchUp),
+		Status:      string(schedule.Status),
+		Description: schedule.Description,
+		NextRunAt:   formatTime(schedule.NextRunAt),
+		CreatedAt:   formatTime(schedule.CreatedAt),
+	}
+}
+
+//formatTime возвращает время в формате RFC 3339 в UTC или пустую строку для нулевого времени.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
 type statisticsServiceServer struct {
 	service service.StatisticsService
 }
@@ -47,9 +289,32 @@ func (srv *statisticsServiceServer) Reset(context.Context, *api.Empty) (*api.Emp
 	return &api.Empty{}, nil
 }
 
+func (srv *statisticsServiceServer) Get(context.Context, *api.Empty) (*api.StatisticsResponse, error) {
+	resp := &api.StatisticsResponse{
+		ReadOperations:           srv.service.TotalReadOperations(),
+		WriteOperations:          srv.service.TotalWriteOperations(),
+		ReadOperationsPerSecond:  srv.service.ReadOperationsPerSecond(),
+		WriteOperationsPerSecond: srv.service.WriteOperationsPerSecond(),
+	}
+	for _, progress := range srv.service.AccrualProgress() {
+		resp.Accruals = append(resp.Accruals, &api.AccrualProgress{
+			Rule:      progress.Rule,
+			Period:    progress.Period,
+			Processed: progress.Processed,
+			Posted:    progress.Posted,
+			Skipped:   progress.Skipped,
+			Failed:    progress.Failed,
+			Done:      progress.Done,
+		})
+	}
+
+	return resp, nil
+}
+
 type Server struct {
 	accountsServiceServer   *accountsServiceServer
 	statisticsServiceServer *statisticsServiceServer
+	adminServiceServer      *adminServiceServer
 
 	address string
 
@@ -58,6 +323,12 @@ type Server struct {
 
 	unaryInt  []grpc.UnaryServerInterceptor
 	streamInt []grpc.StreamServerInterceptor
+
+	tlsCertFile string
+	tlsKeyFile  string
+
+	maxConcurrentStreams uint32
+	maxRecvMsgSize       int
 }
 
 func NewServer(address string, accountsSvc service.AccountsService, statisticsSvc service.StatisticsService) *Server {
@@ -80,6 +351,31 @@ func (srv *Server) WithStreamInterceptors(interceptors ...StreamServerIntercepto
 	return srv
 }
 
+//WithAdminService регистрирует административный сервис, включающий управление расписаниями операций. Без вызова
+//этого метода сервис недоступен.
+func (srv *Server) WithAdminService(adminSvc service.AdminService, schedulerSvc service.SchedulerService) *Server {
+	srv.adminServiceServer = &adminServiceServer{service: adminSvc, scheduler: schedulerSvc}
+
+	return srv
+}
+
+//WithTLS включает TLS. Сертификат и ключ загружаются из указанных файлов при запуске сервера.
+func (srv *Server) WithTLS(certFile, keyFile string) *Server {
+	srv.tlsCertFile = certFile
+	srv.tlsKeyFile = keyFile
+
+	return srv
+}
+
+//WithLimits устанавливает ограничения на количество одновременных потоков в одном соединении и на размер
+//принимаемого сообщения. Нулевые значения оставляют ограничения gRPC по умолчанию.
+func (srv *Server) WithLimits(maxConcurrentStreams uint32, maxRecvMsgSize int) *Server {
+	srv.maxConcurrentStreams = maxConcurrentStreams
+	srv.maxRecvMsgSize = maxRecvMsgSize
+
+	return srv
+}
+
 func (srv *Server) Start() error {
 	srv.mu.Lock()
 
@@ -88,13 +384,38 @@ func (srv *Server) Start() error {
 		return errors.New("server already started")
 	}
 
-	grpcSrv := grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(srv.unaryInt...),
 		grpc.ChainStreamInterceptor(srv.streamInt...),
-	)
+		//клиенты проверяют простаивающие соединения чаще, чем допускают настройки по умолчанию
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             minKeepaliveTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if srv.tlsCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(srv.tlsCertFile, srv.tlsKeyFile)
+		if err != nil {
+			srv.mu.Unlock()
+
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	if srv.maxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(srv.maxConcurrentStreams))
+	}
+	if srv.maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(srv.maxRecvMsgSize))
+	}
+
+	grpcSrv := grpc.NewServer(opts...)
 
 	api.RegisterAccountsServiceServer(grpcSrv, srv.accountsServiceServer)
 	api.RegisterStatisticsServiceServer(grpcSrv, srv.statisticsServiceServer)
+	if srv.adminServiceServer != nil {
+		api.RegisterAdminServiceServer(grpcSrv, srv.adminServiceServer)
+	}
 
 	listener, err := net.Listen("tcp", srv.address)
 	if err != nil {
@@ -158,6 +479,7 @@ func toOriginalGRPCUnaryInterceptors(interceprors []UnaryServerInterceptor) []gr
 	var res []grpc.UnaryServerInterceptor
 
 	for _, f := range interceprors {
+		f := f
 		res = append(res, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 			return f(ctx, req, (*UnaryServerInfo)(info), UnaryHandler(handler))
 		})
@@ -170,6 +492,7 @@ func toOriginalGRPCStreamInterceptors(interceprors []StreamServerInterceptor) []
 	var res []grpc.StreamServerInterceptor
 
 	for _, f := range interceprors {
+		f := f
 		res = append(res, func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 			return f(srv, *(*ServerStream)(unsafe.Pointer(&ss)), (*StreamServerInfo)(info), *(*StreamHandler)(unsafe.Pointer(&handler)))
 		})
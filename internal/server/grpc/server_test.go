@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vps2/accounttesttask/internal/api"
+	"github.com/vps2/accounttesttask/internal/server/service"
+	smocks "github.com/vps2/accounttesttask/internal/server/service/mocks"
+
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"gotest.tools/assert"
+)
+
+func TestAccountsServiceServer_AddAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+	}{
+		{name: "added", wantCode: codes.OK},
+		{name: "balance limit exceeded", err: service.ErrBalanceLimitExceeded, wantCode: codes.InvalidArgument},
+		{name: "amount too large", err: service.ErrAmountTooLarge, wantCode: codes.InvalidArgument},
+		{name: "insufficient funds", err: service.ErrInsufficientFunds, wantCode: codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsSvc := &smocks.AccountsService{}
+			accountsSvc.On("AddAmount", mock.Anything, int32(1), int64(100), "", "").Return(tt.err)
+			srv := &accountsServiceServer{service: accountsSvc}
+
+			_, err := srv.AddAmount(context.Background(), &api.AddRequest{BalanceId: 1, Value: 100})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+
+			accountsSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStatisticsServiceServer_Get(t *testing.T) {
+	statisticsSvc := &smocks.StatisticsService{}
+	statisticsSvc.On("TotalReadOperations").Return(int64(10))
+	statisticsSvc.On("TotalWriteOperations").Return(int64(4))
+	statisticsSvc.On("ReadOperationsPerSecond").Return(int64(2))
+	statisticsSvc.On("WriteOperationsPerSecond").Return(int64(1))
+	statisticsSvc.On("AccrualProgress").Return([]service.AccrualProgress{
+		{Rule: "fee", Period: "2021-02", Processed: 7, Posted: 7, Done: true},
+		{Rule: "interest", Period: "2021-03-01", Processed: 5, Posted: 3, Skipped: 1, Failed: 1},
+	})
+	srv := &statisticsServiceServer{service: statisticsSvc}
+
+	resp, err := srv.Get(context.Background(), &api.Empty{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, int64(10), resp.ReadOperations)
+	assert.Equal(t, int64(1), resp.WriteOperationsPerSecond)
+	assert.Equal(t, 2, len(resp.Accruals))
+	assert.Assert(t, proto.Equal(&api.AccrualProgress{Rule: "fee", Period: "2021-02", Processed: 7, Posted: 7, Done: true},
+		resp.Accruals[0]))
+	assert.Assert(t, proto.Equal(&api.AccrualProgress{Rule: "interest", Period: "2021-03-01", Processed: 5, Posted: 3,
+		Skipped: 1, Failed: 1}, resp.Accruals[1]))
+
+	statisticsSvc.AssertExpectations(t)
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//RateLimitMiddleware отклоняет запросы с кодом ResourceExhausted (HTTP 429), если превышено допустимое количество
+//запросов в секунду, заданное limiter.
+func RateLimitMiddleware(limiter *rate.Limiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow() {
+				writeError(w, status.Error(codes.ResourceExhausted, "rate limit exceeded"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
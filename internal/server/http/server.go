@@ -0,0 +1,509 @@
+//Пакет http реализует HTTP/JSON шлюз к сервисам счетов и статистики для клиентов, не поддерживающих gRPC.
+//Описание API находится в файле api/openapi/accounts.yaml.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/errcode"
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	balancesPath    = "/v1/balances"
+	balancesPrefix  = "/v1/balances/"
+	addSuffix       = ":add"
+	authorizeSuffix = ":authorize"
+	transferSuffix  = ":transfer"
+	setSuffix       = ":set"
+	holdsPrefix     = "/v1/holds/"
+	captureSuffix   = ":capture"
+	voidSuffix      = ":void"
+	statisticsPath  = "/v1/statistics"
+	resetPath       = "/v1/statistics:reset"
+)
+
+//Middleware - обёртка над обработчиком HTTP запросов
+type Middleware func(http.Handler) http.Handler
+
+type getResponse struct {
+	BalanceId int32  `json:"balanceId"`
+	Amount    int64  `json:"amount"`
+	Available int64  `json:"available"`
+	Currency  string `json:"currency"`
+	Version   int64  `json:"version"`
+}
+
+type addRequest struct {
+	Value           int64  `json:"value"`
+	Currency        string `json:"currency"`
+	ExpectedVersion *int64 `json:"expectedVersion"`
+	IdempotencyKey  string `json:"idempotencyKey"`
+}
+
+type setRequest struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Version  int64  `json:"version"`
+}
+
+type authorizeRequest struct {
+	Amount         int64  `json:"amount"`
+	TtlSeconds     int64  `json:"ttlSeconds"`
+	Currency       string `json:"currency"`
+	IdempotencyKey string `json:"idempotencyKey"`
+}
+
+type authorizeResponse struct {
+	HoldId string `json:"holdId"`
+}
+
+type transferRequest struct {
+	ToBalanceId    int32  `json:"toBalanceId"`
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency"`
+	FxRate         string `json:"fxRate"`
+	IdempotencyKey string `json:"idempotencyKey"`
+}
+
+type transferResponse struct {
+	Credited int64 `json:"credited"`
+}
+
+type account struct {
+	BalanceId int32             `json:"balanceId"`
+	Amount    int64             `json:"amount"`
+	Currency  string            `json:"currency"`
+	Status    string            `json:"status"`
+	Owner     string            `json:"owner"`
+	Labels    map[string]string `json:"labels"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+type listResponse struct {
+	Accounts      []*account `json:"accounts"`
+	NextPageToken string     `json:"nextPageToken"`
+}
+
+type captureRequest struct {
+	Amount int64 `json:"amount"`
+}
+
+type accrualProgress struct {
+	Rule      string `json:"rule"`
+	Period    string `json:"period"`
+	Processed int64  `json:"processed"`
+	Posted    int64  `json:"posted"`
+	Skipped   int64  `json:"skipped"`
+	Failed    int64  `json:"failed"`
+	Done      bool   `json:"done"`
+}
+
+type statisticsResponse struct {
+	ReadOperations           int64              `json:"readOperations"`
+	WriteOperations          int64              `json:"writeOperations"`
+	ReadOperationsPerSecond  int64              `json:"readOperationsPerSecond"`
+	WriteOperationsPerSecond int64              `json:"writeOperationsPerSecond"`
+	Accruals                 []*accrualProgress `json:"accruals"`
+}
+
+type errorResponse struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+type Server struct {
+	accountsSvc   service.AccountsService
+	statisticsSvc service.StatisticsService
+
+	address string
+
+	mu         sync.Mutex
+	httpServer *http.Server
+
+	middlewares []Middleware
+
+	tlsCertFile string
+	tlsKeyFile  string
+}
+
+func NewServer(address string, accountsSvc service.AccountsService, statisticsSvc service.StatisticsService) *Server {
+	return &Server{
+		accountsSvc:   accountsSvc,
+		statisticsSvc: statisticsSvc,
+		address:       address,
+	}
+}
+
+//WithMiddlewares добавляет обёртки обработчика запросов. Первая из переданных обёрток будет вызываться первой.
+func (srv *Server) WithMiddlewares(middlewares ...Middleware) *Server {
+	srv.middlewares = append(srv.middlewares, middlewares...)
+
+	return srv
+}
+
+//WithTLS включает TLS. Сертификат и ключ загружаются из указанных файлов при запуске сервера.
+func (srv *Server) WithTLS(certFile, keyFile string) *Server {
+	srv.tlsCertFile = certFile
+	srv.tlsKeyFile = keyFile
+
+	return srv
+}
+
+func (srv *Server) Start() error {
+	srv.mu.Lock()
+
+	if srv.httpServer != nil {
+		srv.mu.Unlock()
+		return errors.New("server already started")
+	}
+
+	listener, err := net.Listen("tcp", srv.address)
+	if err != nil {
+		srv.mu.Unlock()
+
+		return err
+	}
+
+	httpSrv := &http.Server{Handler: srv.Handler()}
+
+	srv.httpServer = httpSrv
+	srv.mu.Unlock()
+
+	if srv.tlsCertFile != "" {
+		err = httpSrv.ServeTLS(listener, srv.tlsCertFile, srv.tlsKeyFile)
+	} else {
+		err = httpSrv.Serve(listener)
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+func (srv *Server) GracefulStop() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.httpServer != nil {
+		srv.httpServer.Shutdown(context.Background())
+		srv.httpServer = nil
+	}
+}
+
+//Handler возвращает обработчик всех маршрутов шлюза вместе с добавленными обёртками.
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(balancesPath, srv.handleListBalances)
+	mux.HandleFunc(balancesPrefix, srv.handleBalances)
+	mux.HandleFunc(holdsPrefix, srv.handleHolds)
+	mux.HandleFunc(statisticsPath, srv.handleStatistics)
+	mux.HandleFunc(resetPath, srv.handleReset)
+
+	var handler http.Handler = mux
+	for i := len(srv.middlewares) - 1; i >= 0; i-- {
+		handler = srv.middlewares[i](handler)
+	}
+
+	return handler
+}
+
+func (srv *Server) handleBalances(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, balancesPrefix)
+
+	switch {
+	case r.Method == http.MethodGet && !strings.Contains(path, ":"):
+		id, err := parseBalanceId(path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		balance, err := srv.accountsSvc.GetBalance(r.Context(), id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, &getResponse{
+			BalanceId: id,
+			Amount:    balance.Amount,
+			Available: balance.Available,
+			Currency:  balance.Currency,
+			Version:   balance.Version,
+		})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, addSuffix):
+		id, err := parseBalanceId(strings.TrimSuffix(path, addSuffix))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req := addRequest{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if req.ExpectedVersion != nil {
+			err = srv.accountsSvc.AddAmountIfVersion(r.Context(), id, req.Value, req.Currency, *req.ExpectedVersion, req.IdempotencyKey)
+		} else {
+			err = srv.accountsSvc.AddAmount(r.Context(), id, req.Value, req.Currency, req.IdempotencyKey)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, setSuffix):
+		id, err := parseBalanceId(strings.TrimSuffix(path, setSuffix))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req := setRequest{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := srv.accountsSvc.SetAmountIfVersion(r.Context(), id, req.Amount, req.Currency, req.Version); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, authorizeSuffix):
+		id, err := parseBalanceId(strings.TrimSuffix(path, authorizeSuffix))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req := authorizeRequest{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		ttl := time.Duration(req.TtlSeconds) * time.Second
+		holdId, err := srv.accountsSvc.Authorize(r.Context(), id, req.Amount, req.Currency, ttl, req.IdempotencyKey)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, &authorizeResponse{HoldId: holdId})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, transferSuffix):
+		id, err := parseBalanceId(strings.TrimSuffix(path, transferSuffix))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req := transferRequest{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		credited, err := srv.accountsSvc.Transfer(r.Context(), id, req.ToBalanceId, req.Amount, req.Currency, req.FxRate,
+			req.IdempotencyKey)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, &transferResponse{Credited: credited})
+	default:
+		writeError(w, status.Error(codes.NotFound, "not found"))
+	}
+}
+
+func (srv *Server) handleListBalances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, status.Error(codes.NotFound, "not found"))
+		return
+	}
+
+	query := r.URL.Query()
+	req := &service.ListAccountsRequest{
+		LabelSelector: query.Get("labelSelector"),
+		Status:        model.AccountStatus(query.Get("status")),
+		OrderBy:       query.Get("orderBy"),
+		PageToken:     query.Get("pageToken"),
+	}
+
+	var err error
+	if req.MinBalance, err = parseOptionalInt(query, "minBalance"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.MaxBalance, err = parseOptionalInt(query, "maxBalance"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if v := query.Get("pageSize"); v != "" {
+		if req.PageSize, err = strconv.Atoi(v); err != nil {
+			writeError(w, status.Errorf(codes.InvalidArgument, "invalid pageSize %q", v))
+			return
+		}
+	}
+
+	accounts, nextPageToken, err := srv.accountsSvc.ListAccounts(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := &listResponse{
+		Accounts:      make([]*account, 0, len(accounts)),
+		NextPageToken: nextPageToken,
+	}
+	for _, acc := range accounts {
+		resp.Accounts = append(resp.Accounts, &account{
+			BalanceId: acc.Id,
+			Amount:    acc.Balance,
+			Currency:  acc.Currency,
+			Status:    string(acc.Status),
+			Owner:     acc.Owner,
+			Labels:    acc.Labels,
+			CreatedAt: acc.CreatedAt.UTC(),
+			UpdatedAt: acc.UpdatedAt.UTC(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (srv *Server) handleHolds(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, holdsPrefix)
+
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(path, captureSuffix):
+		req := captureRequest{}
+		if err := decodeBody(r, &req); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := srv.accountsSvc.Capture(r.Context(), strings.TrimSuffix(path, captureSuffix), req.Amount); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, voidSuffix):
+		if err := srv.accountsSvc.Void(r.Context(), strings.TrimSuffix(path, voidSuffix)); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	default:
+		writeError(w, status.Error(codes.NotFound, "not found"))
+	}
+}
+
+func (srv *Server) handleStatistics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, status.Error(codes.NotFound, "not found"))
+		return
+	}
+
+	resp := &statisticsResponse{
+		ReadOperations:           srv.statisticsSvc.TotalReadOperations(),
+		WriteOperations:          srv.statisticsSvc.TotalWriteOperations(),
+		ReadOperationsPerSecond:  srv.statisticsSvc.ReadOperationsPerSecond(),
+		WriteOperationsPerSecond: srv.statisticsSvc.WriteOperationsPerSecond(),
+		Accruals:                 make([]*accrualProgress, 0),
+	}
+	for _, progress := range srv.statisticsSvc.AccrualProgress() {
+		resp.Accruals = append(resp.Accruals, &accrualProgress{
+			Rule:      progress.Rule,
+			Period:    progress.Period,
+			Processed: progress.Processed,
+			Posted:    progress.Posted,
+			Skipped:   progress.Skipped,
+			Failed:    progress.Failed,
+			Done:      progress.Done,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (srv *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, status.Error(codes.NotFound, "not found"))
+		return
+	}
+
+	srv.statisticsSvc.Reset()
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func parseBalanceId(s string) (int32, error) {
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid balance id %q", s)
+	}
+
+	return int32(id), nil
+}
+
+func parseOptionalInt(query url.Values, name string) (*int64, error) {
+	s := query.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s %q", name, s)
+	}
+
+	return &v, nil
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %s", err)
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := errcode.Code(err)
+	message := err.Error()
+	if st, ok := status.FromError(err); ok {
+		message = st.Message()
+	}
+
+	writeJSON(w, errcode.HTTPStatus(code), &errorResponse{Code: code, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
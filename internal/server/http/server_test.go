@@ -0,0 +1,268 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/internal/server/service"
+	smocks "github.com/vps2/accounttesttask/internal/server/service/mocks"
+
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/assert"
+)
+
+func TestServer_Handler(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService)
+		method       string
+		path         string
+		body         string
+		wantStatus   int
+		wantBody     string
+	}{
+		{
+			name: "get amount",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("GetBalance", mock.Anything, int32(1)).
+					Return(&model.Balance{Id: 1, Amount: 300, Available: 200, Currency: "USD", Version: 3}, nil)
+			},
+			method:     http.MethodGet,
+			path:       "/v1/balances/1",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"balanceId":1,"amount":300,"available":200,"currency":"USD","version":3}`,
+		},
+		{
+			name:         "get amount with invalid id",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {},
+			method:       http.MethodGet,
+			path:         "/v1/balances/abc",
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     `{"code":3,"message":"invalid balance id \"abc\""}`,
+		},
+		{
+			name: "list accounts",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				minBalance := int64(100)
+				req := &service.ListAccountsRequest{
+					LabelSelector: "env=prod",
+					MinBalance:    &minBalance,
+					Status:        model.StatusActive,
+					OrderBy:       "balance desc",
+					PageSize:      1,
+				}
+				created := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+				accountsSvc.On("ListAccounts", mock.Anything, req).Return([]*model.Account{
+					{
+						Id:        7,
+						Balance:   500,
+						Currency:  "USD",
+						Status:    model.StatusActive,
+						Owner:     "alice",
+						Labels:    map[string]string{"env": "prod"},
+						CreatedAt: created,
+						UpdatedAt: created,
+					},
+				}, "next", nil)
+			},
+			method:     http.MethodGet,
+			path:       "/v1/balances?labelSelector=env%3Dprod&minBalance=100&status=active&orderBy=balance+desc&pageSize=1",
+			wantStatus: http.StatusOK,
+			wantBody: `{"accounts":[{"balanceId":7,"amount":500,"currency":"USD","status":"active","owner":"alice",` +
+				`"labels":{"env":"prod"},"createdAt":"2021-03-01T10:00:00Z","updatedAt":"2021-03-01T10:00:00Z"}],` +
+				`"nextPageToken":"next"}`,
+		},
+		{
+			name:         "list accounts with invalid balance bound",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {},
+			method:       http.MethodGet,
+			path:         "/v1/balances?maxBalance=lots",
+			wantStatus:   http.StatusBadRequest,
+			wantBody:     `{"code":3,"message":"invalid maxBalance \"lots\""}`,
+		},
+		{
+			name: "list accounts with invalid page token",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("ListAccounts", mock.Anything, &service.ListAccountsRequest{PageToken: "bad"}).
+					Return(nil, "", service.ErrInvalidPageToken)
+			},
+			method:     http.MethodGet,
+			path:       "/v1/balances?pageToken=bad",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":3,"message":"invalid page token"}`,
+		},
+		{
+			name: "add amount if version",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("AddAmountIfVersion", mock.Anything, int32(1), int64(10), "", int64(3), "").
+					Return(service.ErrVersionMismatch)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:add",
+			body:       `{"value":10,"expectedVersion":3}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"code":10,"message":"the account version does not match the expected one"}`,
+		},
+		{
+			name: "set amount if version",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("SetAmountIfVersion", mock.Anything, int32(1), int64(500), "USD", int64(0)).Return(nil)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:set",
+			body:       `{"amount":500,"currency":"USD","version":0}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name: "add amount",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("AddAmount", mock.Anything, int32(1), int64(-10), "EUR", "").Return(nil)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:add",
+			body:       `{"value":-10,"currency":"EUR"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name: "add amount with insufficient funds",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("AddAmount", mock.Anything, int32(1), int64(-400), "", "").Return(service.ErrInsufficientFunds)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:add",
+			body:       `{"value":-400}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":9,"message":"the balance is less than the withdrawal amount"}`,
+		},
+		{
+			name: "add amount above the balance limit",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("AddAmount", mock.Anything, int32(1), int64(400), "", "").Return(service.ErrBalanceLimitExceeded)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:add",
+			body:       `{"value":400}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":3,"message":"the balance would exceed the maximum balance of an account"}`,
+		},
+		{
+			name: "authorize",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("Authorize", mock.Anything, int32(1), int64(100), "", time.Minute, "").Return("hold", nil)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:authorize",
+			body:       `{"amount":100,"ttlSeconds":60}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"holdId":"hold"}`,
+		},
+		{
+			name: "transfer with exchange rate",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("Transfer", mock.Anything, int32(1), int32(2), int64(1000), "USD", "0.92", "t1").
+					Return(int64(920), nil)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:transfer",
+			body:       `{"toBalanceId":2,"amount":1000,"currency":"USD","fxRate":"0.92","idempotencyKey":"t1"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"credited":920}`,
+		},
+		{
+			name: "transfer between currencies without exchange rate",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("Transfer", mock.Anything, int32(1), int32(2), int64(1000), "", "", "").
+					Return(int64(0), service.ErrCurrencyMismatch)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/balances/1:transfer",
+			body:       `{"toBalanceId":2,"amount":1000}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"code":9,"message":"the currency does not match the account currency"}`,
+		},
+		{
+			name: "capture unknown hold",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("Capture", mock.Anything, "hold", int64(50)).Return(repository.ErrHoldNotFound)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/holds/hold:capture",
+			body:       `{"amount":50}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"code":5,"message":"hold not found or expired"}`,
+		},
+		{
+			name: "void",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				accountsSvc.On("Void", mock.Anything, "hold").Return(nil)
+			},
+			method:     http.MethodPost,
+			path:       "/v1/holds/hold:void",
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name: "get statistics",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				statisticsSvc.On("TotalReadOperations").Return(int64(10))
+				statisticsSvc.On("TotalWriteOperations").Return(int64(4))
+				statisticsSvc.On("ReadOperationsPerSecond").Return(int64(2))
+				statisticsSvc.On("WriteOperationsPerSecond").Return(int64(1))
+				statisticsSvc.On("AccrualProgress").Return([]service.AccrualProgress{
+					{Rule: "interest", Period: "2021-03-01", Processed: 5, Posted: 3, Skipped: 1, Failed: 1},
+				})
+			},
+			method:     http.MethodGet,
+			path:       "/v1/statistics",
+			wantStatus: http.StatusOK,
+			wantBody: `{"readOperations":10,"writeOperations":4,"readOperationsPerSecond":2,"writeOperationsPerSecond":1,` +
+				`"accruals":[{"rule":"interest","period":"2021-03-01","processed":5,"posted":3,"skipped":1,"failed":1,` +
+				`"done":false}]}`,
+		},
+		{
+			name: "reset statistics",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {
+				statisticsSvc.On("Reset").Return()
+			},
+			method:     http.MethodPost,
+			path:       "/v1/statistics:reset",
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name:         "unknown route",
+			expectations: func(accountsSvc *smocks.AccountsService, statisticsSvc *smocks.StatisticsService) {},
+			method:       http.MethodDelete,
+			path:         "/v1/balances/1",
+			wantStatus:   http.StatusNotFound,
+			wantBody:     `{"code":5,"message":"not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsSvc := &smocks.AccountsService{}
+			statisticsSvc := &smocks.StatisticsService{}
+			tt.expectations(accountsSvc, statisticsSvc)
+
+			srv := NewServer("", accountsSvc, statisticsSvc)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			srv.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, strings.TrimSpace(rec.Body.String()))
+
+			accountsSvc.AssertExpectations(t)
+			statisticsSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -1,27 +1,79 @@
 package model
 
+import "time"
+
+//AccountStatus - состояние счёта, определяющее допустимые операции
+type AccountStatus string
+
+const (
+	//StatusActive - допускаются все операции
+	StatusActive AccountStatus = "active"
+	//StatusDebitFrozen - запрещены списания, зачисления допускаются
+	StatusDebitFrozen AccountStatus = "debit_frozen"
+	//StatusFrozen - запрещены списания и зачисления
+	StatusFrozen AccountStatus = "frozen"
+	//StatusClosed - счёт закрыт навсегда, его идентификатор не может быть использован повторно
+	StatusClosed AccountStatus = "closed"
+)
+
 type Account struct {
 	Id      int32
 	Balance int64
+	//MinBalance - минимально допустимый баланс. Отрицательное значение разрешает уход в минус до этой границы.
+	MinBalance int64
+	//Currency - код валюты ISO 4217. Баланс хранится в минорных единицах этой валюты.
+	Currency string
+	Status   AccountStatus
+	Owner    string
+	//Labels - произвольные метки для отбора счетов с помощью селекторов
+	Labels    map[string]string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	//Version увеличивается при каждом изменении счёта. У несуществующего счёта версия равна нулю.
+	Version int64
 }
 
 func (account *Account) ToDBAccount() *DBAccount {
 	return &DBAccount{
-		Id:      account.Id,
-		Balance: account.Balance,
+		Id:         account.Id,
+		Balance:    account.Balance,
+		MinBalance: account.MinBalance,
+		Currency:   account.Currency,
+		Status:     string(account.Status),
+		Owner:      account.Owner,
+		Labels:     account.Labels,
+		CreatedAt:  account.CreatedAt,
+		UpdatedAt:  account.UpdatedAt,
+		Version:    account.Version,
 	}
 }
 
 // DBAccount is a Postgres user
 type DBAccount struct {
-	tableName struct{} `pg:"accounts"`
-	Id        int32    `pg:",notnull,pk"`
-	Balance   int64    `pg:",use_zero,notnull"`
+	tableName  struct{}          `pg:"accounts"`
+	Id         int32             `pg:",notnull,pk"`
+	Balance    int64             `pg:",use_zero,notnull"`
+	MinBalance int64             `pg:",use_zero,notnull"`
+	Currency   string            `pg:",notnull"`
+	Status     string            `pg:",notnull"`
+	Owner      string            `pg:",use_zero,notnull"`
+	Labels     map[string]string `pg:",type:jsonb"`
+	CreatedAt  time.Time         `pg:",notnull"`
+	UpdatedAt  time.Time         `pg:",notnull"`
+	Version    int64             `pg:",notnull"`
 }
 
 func (dbAccount *DBAccount) ToAccount() *Account {
 	return &Account{
-		Id:      dbAccount.Id,
-		Balance: dbAccount.Balance,
+		Id:         dbAccount.Id,
+		Balance:    dbAccount.Balance,
+		MinBalance: dbAccount.MinBalance,
+		Currency:   dbAccount.Currency,
+		Status:     AccountStatus(dbAccount.Status),
+		Owner:      dbAccount.Owner,
+		Labels:     dbAccount.Labels,
+		CreatedAt:  dbAccount.CreatedAt,
+		UpdatedAt:  dbAccount.UpdatedAt,
+		Version:    dbAccount.Version,
 	}
 }
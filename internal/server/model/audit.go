@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+//StatusChange - запись журнала аудита об изменении состояния счёта
+type StatusChange struct {
+	AccountId int32
+	From      AccountStatus
+	To        AccountStatus
+	Reason    string
+	ChangedAt time.Time
+}
+
+func (change *StatusChange) ToDBStatusChange() *DBStatusChange {
+	return &DBStatusChange{
+		AccountId: change.AccountId,
+		OldStatus: string(change.From),
+		NewStatus: string(change.To),
+		Reason:    change.Reason,
+		ChangedAt: change.ChangedAt,
+	}
+}
+
+// DBStatusChange is a Postgres account status audit record
+type DBStatusChange struct {
+	tableName struct{}  `pg:"account_status_audit"`
+	Id        int64     `pg:",pk"`
+	AccountId int32     `pg:",notnull"`
+	OldStatus string    `pg:",notnull"`
+	NewStatus string    `pg:",notnull"`
+	Reason    string    `pg:",use_zero,notnull"`
+	ChangedAt time.Time `pg:",notnull"`
+}
+
+func (dbChange *DBStatusChange) ToStatusChange() *StatusChange {
+	return &StatusChange{
+		AccountId: dbChange.AccountId,
+		From:      AccountStatus(dbChange.OldStatus),
+		To:        AccountStatus(dbChange.NewStatus),
+		Reason:    dbChange.Reason,
+		ChangedAt: dbChange.ChangedAt,
+	}
+}
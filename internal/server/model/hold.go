@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+//Hold - резервирование суммы на счёте. Зарезервированная сумма недоступна для списания до подтверждения или отмены
+//резервирования либо до истечения срока его действия.
+type Hold struct {
+	Id        string
+	BalanceId int32
+	Amount    int64
+	ExpiresAt time.Time
+}
+
+//Balance - баланс счёта и доступная для списания сумма, равная балансу за вычетом активных резервирований
+type Balance struct {
+	Id        int32
+	Amount    int64
+	Available int64
+	Currency  string
+	//Version - версия счёта, с которой можно выполнить условное изменение баланса
+	Version int64
+}
+
+func (hold *Hold) ToDBHold() *DBHold {
+	return &DBHold{
+		Id:        hold.Id,
+		AccountId: hold.BalanceId,
+		Amount:    hold.Amount,
+		ExpiresAt: hold.ExpiresAt,
+	}
+}
+
+// DBHold is a Postgres hold
+type DBHold struct {
+	tableName struct{}  `pg:"holds"`
+	Id        string    `pg:",notnull,pk"`
+	AccountId int32     `pg:",notnull"`
+	Amount    int64     `pg:",notnull"`
+	ExpiresAt time.Time `pg:",notnull"`
+}
+
+func (dbHold *DBHold) ToHold() *Hold {
+	return &Hold{
+		Id:        dbHold.Id,
+		BalanceId: dbHold.AccountId,
+		Amount:    dbHold.Amount,
+		ExpiresAt: dbHold.ExpiresAt,
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+//LedgerEntry - проводка, изменившая баланс счёта. Ключ проводки уникален в пределах счёта, поэтому повторная
+//проводка с тем же ключом не выполняется.
+type LedgerEntry struct {
+	AccountId int32
+	//Key - ключ идемпотентности проводки, например "savings-interest:2021-03-01"
+	Key string
+	//Tag - вид проводки, например interest или fee
+	Tag      string
+	Amount   int64
+	PostedAt time.Time
+}
+
+//Виды проводок, которые выполняются по запросам с ключом идемпотентности
+const (
+	TagAdd      = "add"
+	TagTransfer = "transfer"
+)
+
+func (entry *LedgerEntry) ToDBLedgerEntry() *DBLedgerEntry {
+	return &DBLedgerEntry{
+		AccountId: entry.AccountId,
+		Key:       entry.Key,
+		Tag:       entry.Tag,
+		Amount:    entry.Amount,
+		PostedAt:  entry.PostedAt,
+	}
+}
+
+// DBLedgerEntry is a Postgres ledger entry
+type DBLedgerEntry struct {
+	tableName struct{}  `pg:"ledger_entries"`
+	Id        int64     `pg:",pk"`
+	AccountId int32     `pg:",notnull"`
+	Key       string    `pg:"idempotency_key,notnull"`
+	Tag       string    `pg:",use_zero,notnull"`
+	Amount    int64     `pg:",notnull"`
+	PostedAt  time.Time `pg:",notnull"`
+}
+
+func (dbEntry *DBLedgerEntry) ToLedgerEntry() *LedgerEntry {
+	return &LedgerEntry{
+		AccountId: dbEntry.AccountId,
+		Key:       dbEntry.Key,
+		Tag:       dbEntry.Tag,
+		Amount:    dbEntry.Amount,
+		PostedAt:  dbEntry.PostedAt,
+	}
+}
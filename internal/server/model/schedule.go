@@ -0,0 +1,158 @@
+package model
+
+import "time"
+
+//ScheduleStatus - состояние расписания
+type ScheduleStatus string
+
+const (
+	//ScheduleActive - расписание выполняется
+	ScheduleActive ScheduleStatus = "active"
+	//ScheduleCompleted - однократная операция выполнена или у повторяющейся больше нет моментов запуска
+	ScheduleCompleted ScheduleStatus = "completed"
+	//ScheduleCancelled - расписание отменено
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+//CatchUpPolicy определяет, как выполняются запуски, пропущенные из-за простоя сервера
+type CatchUpPolicy string
+
+const (
+	//CatchUpSkip - пропущенные запуски не выполняются
+	CatchUpSkip CatchUpPolicy = "skip"
+	//CatchUpLatest - из пропущенных запусков выполняется только последний
+	CatchUpLatest CatchUpPolicy = "latest"
+	//CatchUpAll - выполняется каждый пропущенный запуск
+	CatchUpAll CatchUpPolicy = "all"
+)
+
+//Schedule - отложенная или повторяющаяся операция со счётом
+type Schedule struct {
+	Id        string
+	BalanceId int32
+	//ToBalanceId - счёт зачисления перевода со счёта BalanceId. Если не задан, то Amount зачисляется на счёт
+	//BalanceId, а отрицательная сумма списывается с него.
+	ToBalanceId *int32
+	//Amount - сумма в минорных единицах валюты Currency. Пустая валюта означает валюту счёта BalanceId.
+	Amount   int64
+	Currency string
+	//Cron - расписание повторяющейся операции в формате cron (UTC). Пустое для однократной операции.
+	Cron        string
+	CatchUp     CatchUpPolicy
+	Status      ScheduleStatus
+	Description string
+	//NextRunAt - момент следующего запуска. Нулевой у завершённого или отменённого расписания.
+	NextRunAt time.Time
+	CreatedAt time.Time
+}
+
+//RunStatus - результат запуска расписания
+type RunStatus string
+
+const (
+	//RunPending - операция выполняется. Запуск, оставшийся в этом состоянии после перезапуска сервера, имеет
+	//неизвестный результат и повторно не выполняется.
+	RunPending RunStatus = "pending"
+	//RunSucceeded - операция выполнена
+	RunSucceeded RunStatus = "succeeded"
+	//RunFailed - операция не выполнена, текст ошибки сохраняется в Detail
+	RunFailed RunStatus = "failed"
+	//RunSkipped - пропущенные запуски не выполнялись согласно CatchUpPolicy
+	RunSkipped RunStatus = "skipped"
+)
+
+//ScheduleRun - запись журнала запусков расписания
+type ScheduleRun struct {
+	Id          int64
+	ScheduleId  string
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Status      RunStatus
+	//Detail - текст ошибки неудачного запуска или количество пропущенных запусков
+	Detail string
+}
+
+func (schedule *Schedule) ToDBSchedule() *DBSchedule {
+	return &DBSchedule{
+		Id:          schedule.Id,
+		AccountId:   schedule.BalanceId,
+		ToAccountId: schedule.ToBalanceId,
+		Amount:      schedule.Amount,
+		Currency:    schedule.Currency,
+		Cron:        schedule.Cron,
+		CatchUp:     string(schedule.CatchUp),
+		Status:      string(schedule.Status),
+		Description: schedule.Description,
+		NextRunAt:   schedule.NextRunAt,
+		CreatedAt:   schedule.CreatedAt,
+	}
+}
+
+// DBSchedule is a Postgres schedule
+type DBSchedule struct {
+	tableName   struct{}  `pg:"schedules"`
+	Id          string    `pg:",notnull,pk"`
+	AccountId   int32     `pg:",use_zero,notnull"`
+	ToAccountId *int32    `pg:"to_account_id"`
+	Amount      int64     `pg:",notnull"`
+	Currency    string    `pg:",use_zero,notnull"`
+	Cron        string    `pg:",use_zero,notnull"`
+	CatchUp     string    `pg:",notnull"`
+	Status      string    `pg:",notnull"`
+	Description string    `pg:",use_zero,notnull"`
+	NextRunAt   time.Time `pg:"next_run_at"`
+	CreatedAt   time.Time `pg:",notnull"`
+}
+
+func (dbSchedule *DBSchedule) ToSchedule() *Schedule {
+	return &Schedule{
+		Id:          dbSchedule.Id,
+		BalanceId:   dbSchedule.AccountId,
+		ToBalanceId: dbSchedule.ToAccountId,
+		Amount:      dbSchedule.Amount,
+		Currency:    dbSchedule.Currency,
+		Cron:        dbSchedule.Cron,
+		CatchUp:     CatchUpPolicy(dbSchedule.CatchUp),
+		Status:      ScheduleStatus(dbSchedule.Status),
+		Description: dbSchedule.Description,
+		NextRunAt:   dbSchedule.NextRunAt,
+		CreatedAt:   dbSchedule.CreatedAt,
+	}
+}
+
+func (run *ScheduleRun) ToDBScheduleRun() *DBScheduleRun {
+	return &DBScheduleRun{
+		Id:          run.Id,
+		ScheduleId:  run.ScheduleId,
+		ScheduledAt: run.ScheduledAt,
+		StartedAt:   run.StartedAt,
+		FinishedAt:  run.FinishedAt,
+		Status:      string(run.Status),
+		Detail:      run.Detail,
+	}
+}
+
+// DBScheduleRun is a Postgres schedule run record
+type DBScheduleRun struct {
+	tableName   struct{}  `pg:"schedule_runs"`
+	Id          int64     `pg:",pk"`
+	ScheduleId  string    `pg:",notnull"`
+	ScheduledAt time.Time `pg:",notnull"`
+	StartedAt   time.Time `pg:",notnull"`
+	FinishedAt  time.Time `pg:"finished_at"`
+	Status      string    `pg:",notnull"`
+	Detail      string    `pg:",use_zero,notnull"`
+}
+
+func (dbRun *DBScheduleRun) ToScheduleRun() *ScheduleRun {
+	return &ScheduleRun{
+		Id:          dbRun.Id,
+		ScheduleId:  dbRun.ScheduleId,
+		ScheduledAt: dbRun.ScheduledAt,
+		StartedAt:   dbRun.StartedAt,
+		FinishedAt:  dbRun.FinishedAt,
+		Status:      RunStatus(dbRun.Status),
+		Detail:      dbRun.Detail,
+	}
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "github.com/vps2/accounttesttask/pkg/currency"
+
+//Available возвращает баланс за вычетом суммы активных резервирований held и списываемой суммы amount. Если
+//результат не помещается в int64, то возвращается ErrAmountOutOfRange.
+func Available(balance, held, amount int64) (int64, error) {
+	v, err := currency.Sub(balance, held)
+	if err == nil {
+		v, err = currency.Sub(v, amount)
+	}
+	if err != nil {
+		return 0, ErrAmountOutOfRange
+	}
+
+	return v, nil
+}
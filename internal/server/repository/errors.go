@@ -6,4 +6,22 @@ import "errors"
 var (
 	ErrAccountNotFound      = errors.New("account not found")
 	ErrAccountAlreadyExists = errors.New("account already exists")
+	ErrMinBalanceViolation  = errors.New("the balance is less than the minimum balance")
+	ErrHoldNotFound         = errors.New("hold not found or expired")
+	ErrCaptureExceedsHold   = errors.New("the capture amount exceeds the held amount")
+	ErrAccountNotEmpty      = errors.New("the account has a non-zero balance or active holds")
+	ErrAccountClosed        = errors.New("the account is closed")
+	ErrAccountFrozen        = errors.New("the account is frozen")
+	ErrVersionConflict      = errors.New("the account version has changed")
+	ErrAmountOutOfRange     = errors.New("the balance is out of range")
+	ErrDuplicateEntry       = errors.New("the ledger entry has already been posted")
+	ErrEntryNotFound        = errors.New("ledger entry not found")
+	ErrDuplicateHold        = errors.New("the hold has already been created")
+)
+
+//Ошибки, которые могут возвратить экземпляры repository.Schedules
+var (
+	ErrScheduleNotFound  = errors.New("schedule not found")
+	ErrScheduleNotActive = errors.New("the schedule is completed or cancelled")
+	ErrScheduleConflict  = errors.New("the schedule run has already been claimed")
 )
@@ -2,46 +2,515 @@ package inmem
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/vps2/accounttesttask/internal/server/model"
 	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/currency"
 )
 
+//AccountsRepo хранит счета в памяти. Все методы выполняются под общим мьютексом, поэтому каждый из них атомарен.
 type AccountsRepo struct {
-	m map[int32]*model.Account
+	mu sync.Mutex
+	m  map[int32]*model.Account
+	//ids - упорядоченный по возрастанию индекс идентификаторов счетов
+	ids   []int32
+	holds map[string]*model.Hold
+	audit []*model.StatusChange
+	//entries - проводки счетов по ключам
+	entries map[int32]map[string]*model.LedgerEntry
 }
 
 func NewAccountsRepo() *AccountsRepo {
 	return &AccountsRepo{
-		m: make(map[int32]*model.Account),
+		m:       make(map[int32]*model.Account),
+		holds:   make(map[string]*model.Hold),
+		entries: make(map[int32]map[string]*model.LedgerEntry),
 	}
 }
 
 func (a *AccountsRepo) GetById(ctx context.Context, id int32) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
 	if account, ok := a.m[id]; ok {
-		return account, nil
+		return clone(account), nil
 	}
 
 	return nil, repository.ErrAccountNotFound
 }
 
 func (a *AccountsRepo) Create(ctx context.Context, account *model.Account) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.create(account)
+}
+
+func (a *AccountsRepo) create(account *model.Account) (*model.Account, error) {
 	if _, ok := a.m[account.Id]; !ok {
-		a.m[account.Id] = account
+		if account.Balance < account.MinBalance {
+			return nil, repository.ErrMinBalanceViolation
+		}
+
+		acc := clone(account)
+		now := time.Now()
+		if acc.CreatedAt.IsZero() {
+			acc.CreatedAt = now
+		}
+		acc.UpdatedAt = now
+		acc.Version = 1
+		a.m[account.Id] = acc
+
+		i := sort.Search(len(a.ids), func(i int) bool { return a.ids[i] >= account.Id })
+		a.ids = append(a.ids, 0)
+		copy(a.ids[i+1:], a.ids[i:])
+		a.ids[i] = account.Id
 
-		return account, nil
+		return clone(acc), nil
 	}
 
 	return nil, repository.ErrAccountAlreadyExists
 }
 
 func (a *AccountsRepo) Update(ctx context.Context, account *model.Account) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.update(account)
+}
+
+func (a *AccountsRepo) PostEntry(ctx context.Context, account *model.Account, entry *model.LedgerEntry) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.entries[account.Id][entry.Key]; ok {
+		return nil, repository.ErrDuplicateEntry
+	}
+
+	var (
+		acc *model.Account
+		err error
+	)
+	if account.Version == 0 {
+		acc, err = a.create(account)
+	} else {
+		acc, err = a.update(account)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.addEntry(entry, account.Id, entry.Amount, acc.UpdatedAt)
+
+	return acc, nil
+}
+
+func (a *AccountsRepo) GetEntry(ctx context.Context, accountId int32, key string) (*model.LedgerEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[accountId][key]
+	if !ok {
+		return nil, repository.ErrEntryNotFound
+	}
+	res := *entry
+
+	return &res, nil
+}
+
+//addEntry сохраняет копию проводки entry для счёта accountId с суммой amount.
+func (a *AccountsRepo) addEntry(entry *model.LedgerEntry, accountId int32, amount int64, now time.Time) {
+	saved := *entry
+	saved.AccountId = accountId
+	saved.Amount = amount
+	if saved.PostedAt.IsZero() {
+		saved.PostedAt = now
+	}
+	if a.entries[accountId] == nil {
+		a.entries[accountId] = make(map[string]*model.LedgerEntry)
+	}
+	a.entries[accountId][entry.Key] = &saved
+}
+
+func (a *AccountsRepo) update(account *model.Account) (*model.Account, error) {
 	if acc, ok := a.m[account.Id]; ok {
+		if acc.Version != account.Version {
+			return nil, repository.ErrVersionConflict
+		}
+
+		now := time.Now()
+		if account.Balance < acc.MinBalance {
+			return nil, repository.ErrMinBalanceViolation
+		}
+		if account.Balance < acc.Balance {
+			available, err := a.available(account.Id, account.Balance, 0, now)
+			if err != nil {
+				return nil, err
+			}
+			if available < acc.MinBalance {
+				return nil, repository.ErrMinBalanceViolation
+			}
+		}
+
 		acc.Balance = account.Balance
+		touch(acc, now)
 
-		return acc, nil
+		return clone(acc), nil
 	}
 
 	return nil, repository.ErrAccountNotFound
 }
+
+func (a *AccountsRepo) SetMinBalance(ctx context.Context, id int32, minBalance int64) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if acc, ok := a.m[id]; ok {
+		if acc.Balance < minBalance {
+			return nil, repository.ErrMinBalanceViolation
+		}
+
+		acc.MinBalance = minBalance
+		touch(acc, time.Now())
+
+		return clone(acc), nil
+	}
+
+	return nil, repository.ErrAccountNotFound
+}
+
+func (a *AccountsRepo) SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) (*model.StatusChange, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc, ok := a.m[id]
+	if !ok {
+		return nil, repository.ErrAccountNotFound
+	}
+
+	if acc.Status == model.StatusClosed {
+		return nil, repository.ErrAccountClosed
+	}
+
+	now := time.Now()
+	if status == model.StatusClosed {
+		held, err := a.heldAmount(id, now)
+		if err != nil {
+			return nil, err
+		}
+		if acc.Balance != 0 || held != 0 {
+			return nil, repository.ErrAccountNotEmpty
+		}
+	}
+
+	change := &model.StatusChange{
+		AccountId: id,
+		From:      acc.Status,
+		To:        status,
+		Reason:    reason,
+		ChangedAt: now,
+	}
+	acc.Status = status
+	touch(acc, now)
+	a.audit = append(a.audit, change)
+
+	res := *change
+
+	return &res, nil
+}
+
+func (a *AccountsRepo) Transfer(ctx context.Context, fromId, toId int32, debit, credit int64, key string) (*model.Account, *model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	from, ok := a.m[fromId]
+	if !ok {
+		return nil, nil, repository.ErrAccountNotFound
+	}
+	to, ok := a.m[toId]
+	if !ok {
+		return nil, nil, repository.ErrAccountNotFound
+	}
+	if err := repository.CheckStatus(from.Status, true); err != nil {
+		return nil, nil, err
+	}
+	if err := repository.CheckStatus(to.Status, false); err != nil {
+		return nil, nil, err
+	}
+	if key != "" {
+		_, fromDup := a.entries[fromId][key]
+		_, toDup := a.entries[toId][key]
+		if fromDup || toDup {
+			return nil, nil, repository.ErrDuplicateEntry
+		}
+	}
+
+	available, err := a.available(fromId, from.Balance, debit, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if available < from.MinBalance {
+		return nil, nil, repository.ErrMinBalanceViolation
+	}
+	toBalance, err := currency.Add(to.Balance, credit)
+	if err != nil {
+		return nil, nil, repository.ErrAmountOutOfRange
+	}
+	if toBalance < to.MinBalance {
+		return nil, nil, repository.ErrMinBalanceViolation
+	}
+
+	now := time.Now()
+	from.Balance -= debit
+	touch(from, now)
+	to.Balance = toBalance
+	touch(to, now)
+	if key != "" {
+		entry := &model.LedgerEntry{Key: key, Tag: model.TagTransfer}
+		a.addEntry(entry, fromId, -debit, now)
+		a.addEntry(entry, toId, credit, now)
+	}
+
+	return clone(from), clone(to), nil
+}
+
+func (a *AccountsRepo) SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc, ok := a.m[id]
+	if !ok {
+		return nil, repository.ErrAccountNotFound
+	}
+
+	acc.Owner = owner
+	acc.Labels = copyLabels(labels)
+	touch(acc, time.Now())
+
+	return clone(acc), nil
+}
+
+//ListAccounts при упорядочивании по идентификатору обходит упорядоченный индекс начиная с позиции после
+//query.After, в остальных случаях упорядочивает все подходящие счета.
+func (a *AccountsRepo) ListAccounts(ctx context.Context, query *repository.ListQuery) ([]*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var res []*model.Account
+
+	if query.OrderBy == repository.OrderById {
+		n := len(a.ids)
+		start := 0
+		if query.After != nil {
+			start = sort.Search(n, func(i int) bool { return a.ids[i] > query.After.Id })
+			if query.Desc {
+				start = n - sort.Search(n, func(i int) bool { return a.ids[i] >= query.After.Id })
+			}
+		}
+
+		for i := start; i < n && len(res) < query.Limit; i++ {
+			id := a.ids[i]
+			if query.Desc {
+				id = a.ids[n-1-i]
+			}
+			if acc := a.m[id]; query.Matches(acc) {
+				res = append(res, clone(acc))
+			}
+		}
+
+		return res, nil
+	}
+
+	for _, id := range a.ids {
+		acc := a.m[id]
+		if query.Matches(acc) && (query.After == nil || query.Less(query.After, acc)) {
+			res = append(res, acc)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return query.Less(res[i], res[j]) })
+
+	if len(res) > query.Limit {
+		res = res[:query.Limit]
+	}
+	for i := range res {
+		res[i] = clone(res[i])
+	}
+
+	return res, nil
+}
+
+func (a *AccountsRepo) CreateHold(ctx context.Context, hold *model.Hold) (*model.Hold, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc, ok := a.m[hold.BalanceId]
+	if !ok {
+		return nil, repository.ErrAccountNotFound
+	}
+	if _, ok := a.holds[hold.Id]; ok {
+		return nil, repository.ErrDuplicateHold
+	}
+	if err := repository.CheckStatus(acc.Status, true); err != nil {
+		return nil, err
+	}
+
+	available, err := a.available(hold.BalanceId, acc.Balance, hold.Amount, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if available < acc.MinBalance {
+		return nil, repository.ErrMinBalanceViolation
+	}
+
+	h := *hold
+	a.holds[hold.Id] = &h
+
+	return hold, nil
+}
+
+func (a *AccountsRepo) HeldAmount(ctx context.Context, id int32) (int64, time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	held, err := a.heldAmount(id, now)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	var next time.Time
+	for _, hold := range a.holds {
+		if hold.BalanceId == id && hold.ExpiresAt.After(now) && (next.IsZero() || hold.ExpiresAt.Before(next)) {
+			next = hold.ExpiresAt
+		}
+	}
+
+	return held, next, nil
+}
+
+func (a *AccountsRepo) CaptureHold(ctx context.Context, holdId string, amount int64) (*model.Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	hold, ok := a.activeHold(holdId, time.Now())
+	if !ok {
+		return nil, repository.ErrHoldNotFound
+	}
+	if amount > hold.Amount {
+		return nil, repository.ErrCaptureExceedsHold
+	}
+
+	acc, ok := a.m[hold.BalanceId]
+	if !ok {
+		return nil, repository.ErrAccountNotFound
+	}
+	if err := repository.CheckStatus(acc.Status, true); err != nil {
+		return nil, err
+	}
+	balance, err := currency.Sub(acc.Balance, amount)
+	if err != nil {
+		return nil, repository.ErrAmountOutOfRange
+	}
+	if balance < acc.MinBalance {
+		return nil, repository.ErrMinBalanceViolation
+	}
+
+	acc.Balance = balance
+	touch(acc, time.Now())
+	delete(a.holds, holdId)
+
+	return clone(acc), nil
+}
+
+func (a *AccountsRepo) DeleteHold(ctx context.Context, holdId string) (*model.Hold, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	hold, ok := a.activeHold(holdId, time.Now())
+	if !ok {
+		return nil, repository.ErrHoldNotFound
+	}
+
+	delete(a.holds, holdId)
+	h := *hold
+
+	return &h, nil
+}
+
+func (a *AccountsRepo) DeleteExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := 0
+	for id, hold := range a.holds {
+		if !hold.ExpiresAt.After(now) {
+			delete(a.holds, id)
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (a *AccountsRepo) activeHold(holdId string, now time.Time) (*model.Hold, bool) {
+	hold, ok := a.holds[holdId]
+	if !ok || !hold.ExpiresAt.After(now) {
+		return nil, false
+	}
+
+	return hold, true
+}
+
+func (a *AccountsRepo) heldAmount(id int32, now time.Time) (int64, error) {
+	var sum int64
+	for _, hold := range a.holds {
+		if hold.BalanceId == id && hold.ExpiresAt.After(now) {
+			var err error
+			if sum, err = currency.Add(sum, hold.Amount); err != nil {
+				return 0, repository.ErrAmountOutOfRange
+			}
+		}
+	}
+
+	return sum, nil
+}
+
+//available возвращает баланс balance счёта id за вычетом активных резервирований и суммы amount.
+func (a *AccountsRepo) available(id int32, balance, amount int64, now time.Time) (int64, error) {
+	held, err := a.heldAmount(id, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return repository.Available(balance, held, amount)
+}
+
+//touch отмечает изменение счёта.
+func touch(account *model.Account, now time.Time) {
+	account.UpdatedAt = now
+	account.Version++
+}
+
+//clone возвращает копию счёта, чтобы изменения, не сохранённые через методы репозитория, не попадали в хранилище.
+func clone(account *model.Account) *model.Account {
+	acc := *account
+	acc.Labels = copyLabels(account.Labels)
+
+	return &acc
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	res := make(map[string]string, len(labels))
+	for k, v := range labels {
+		res[k] = v
+	}
+
+	return res
+}
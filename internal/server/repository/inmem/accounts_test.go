@@ -0,0 +1,211 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/labels"
+
+	"gotest.tools/assert"
+)
+
+func TestAccountsRepo_ListAccounts(t *testing.T) {
+	repo := NewAccountsRepo()
+	for _, account := range []*model.Account{
+		{Id: 4, Balance: 100, Labels: map[string]string{"env": "prod"}},
+		{Id: 1, Balance: 300, Labels: map[string]string{"env": "dev"}},
+		{Id: 3, Balance: 300},
+		{Id: 2, Balance: 200, Labels: map[string]string{"env": "prod"}},
+	} {
+		_, err := repo.Create(context.Background(), account)
+		assert.NilError(t, err)
+	}
+
+	prod, err := labels.Parse("env=prod")
+	assert.NilError(t, err)
+	minBalance := int64(200)
+
+	tests := []struct {
+		name  string
+		query *repository.ListQuery
+		want  []int32
+	}{
+		{
+			name:  "by id",
+			query: &repository.ListQuery{OrderBy: repository.OrderById, Limit: 10},
+			want:  []int32{1, 2, 3, 4},
+		},
+		{
+			name:  "by id descending after a cursor",
+			query: &repository.ListQuery{OrderBy: repository.OrderById, Desc: true, After: &model.Account{Id: 3}, Limit: 10},
+			want:  []int32{2, 1},
+		},
+		{
+			name:  "by id with a filter and a limit",
+			query: &repository.ListQuery{Selector: prod, OrderBy: repository.OrderById, Limit: 1},
+			want:  []int32{2},
+		},
+		{
+			name:  "by balance descending",
+			query: &repository.ListQuery{OrderBy: repository.OrderByBalance, Desc: true, Limit: 10},
+			want:  []int32{3, 1, 2, 4},
+		},
+		{
+			name: "by balance after a cursor with a tie",
+			query: &repository.ListQuery{
+				OrderBy:    repository.OrderByBalance,
+				MinBalance: &minBalance,
+				After:      &model.Account{Id: 1, Balance: 300},
+				Limit:      10,
+			},
+			want: []int32{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := repo.ListAccounts(context.Background(), tt.query)
+			assert.NilError(t, err)
+
+			ids := make([]int32, 0, len(accounts))
+			for _, account := range accounts {
+				ids = append(ids, account.Id)
+			}
+			assert.DeepEqual(t, tt.want, ids)
+		})
+	}
+}
+
+func TestAccountsRepo_Update(t *testing.T) {
+	repo := NewAccountsRepo()
+	created, err := repo.Create(context.Background(), &model.Account{Id: 1, Balance: 300})
+	assert.NilError(t, err)
+	assert.Equal(t, int64(1), created.Version)
+
+	created.Balance = 400
+	updated, err := repo.Update(context.Background(), created)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	//изменение по устаревшей версии отклоняется
+	_, err = repo.Update(context.Background(), created)
+	assert.Equal(t, repository.ErrVersionConflict, err)
+
+	_, err = repo.CreateHold(context.Background(), &model.Hold{Id: "h", BalanceId: 1, Amount: 300, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.NilError(t, err)
+
+	//списывать можно только не зарезервированную сумму
+	updated.Balance = 200
+	_, err = repo.Update(context.Background(), updated)
+	assert.Equal(t, repository.ErrMinBalanceViolation, err)
+}
+
+func TestAccountsRepo_StatusChecks(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAccountsRepo()
+	for _, id := range []int32{1, 2} {
+		_, err := repo.Create(ctx, &model.Account{Id: id, Balance: 300, Status: model.StatusActive})
+		assert.NilError(t, err)
+	}
+	_, err := repo.CreateHold(ctx, &model.Hold{Id: "h", BalanceId: 1, Amount: 100, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.NilError(t, err)
+
+	//резервирование, созданное до заморозки счёта, не списывается и сохраняется
+	_, err = repo.SetStatus(ctx, 1, model.StatusDebitFrozen, "")
+	assert.NilError(t, err)
+	_, err = repo.CaptureHold(ctx, "h", 100)
+	assert.Equal(t, repository.ErrAccountFrozen, err)
+	_, err = repo.CreateHold(ctx, &model.Hold{Id: "h2", BalanceId: 1, Amount: 100, ExpiresAt: time.Now().Add(time.Minute)})
+	assert.Equal(t, repository.ErrAccountFrozen, err)
+	_, _, err = repo.Transfer(ctx, 1, 2, 100, 100, "")
+	assert.Equal(t, repository.ErrAccountFrozen, err)
+
+	//на счёт с замороженными списаниями можно зачислять
+	_, _, err = repo.Transfer(ctx, 2, 1, 100, 100, "")
+	assert.NilError(t, err)
+
+	_, err = repo.SetStatus(ctx, 1, model.StatusFrozen, "")
+	assert.NilError(t, err)
+	_, _, err = repo.Transfer(ctx, 2, 1, 100, 100, "")
+	assert.Equal(t, repository.ErrAccountFrozen, err)
+
+	_, err = repo.SetStatus(ctx, 1, model.StatusActive, "")
+	assert.NilError(t, err)
+	account, err := repo.CaptureHold(ctx, "h", 100)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(300), account.Balance)
+
+	_, err = repo.Create(ctx, &model.Account{Id: 3, Status: model.StatusActive})
+	assert.NilError(t, err)
+	_, err = repo.SetStatus(ctx, 3, model.StatusClosed, "")
+	assert.NilError(t, err)
+	_, _, err = repo.Transfer(ctx, 2, 3, 100, 100, "")
+	assert.Equal(t, repository.ErrAccountClosed, err)
+}
+
+func TestAccountsRepo_HeldAmount(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAccountsRepo()
+	_, err := repo.Create(ctx, &model.Account{Id: 1, Balance: 300})
+	assert.NilError(t, err)
+
+	held, next, err := repo.HeldAmount(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(0), held)
+	assert.Assert(t, next.IsZero())
+
+	soon, later := time.Now().Add(time.Minute), time.Now().Add(time.Hour)
+	_, err = repo.CreateHold(ctx, &model.Hold{Id: "h1", BalanceId: 1, Amount: 100, ExpiresAt: later})
+	assert.NilError(t, err)
+	_, err = repo.CreateHold(ctx, &model.Hold{Id: "h2", BalanceId: 1, Amount: 50, ExpiresAt: soon})
+	assert.NilError(t, err)
+
+	//возвращается момент истечения ближайшего резервирования
+	held, next, err = repo.HeldAmount(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(150), held)
+	assert.Equal(t, soon, next)
+
+	hold, err := repo.DeleteHold(ctx, "h2")
+	assert.NilError(t, err)
+	assert.Equal(t, int32(1), hold.BalanceId)
+
+	held, next, err = repo.HeldAmount(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(100), held)
+	assert.Equal(t, later, next)
+
+	_, err = repo.DeleteHold(ctx, "h2")
+	assert.Equal(t, repository.ErrHoldNotFound, err)
+}
+
+func TestAccountsRepo_TransferWithKey(t *testing.T) {
+	ctx := context.Background()
+	repo := NewAccountsRepo()
+	for _, id := range []int32{1, 2} {
+		_, err := repo.Create(ctx, &model.Account{Id: id, Balance: 300, Status: model.StatusActive})
+		assert.NilError(t, err)
+	}
+
+	_, _, err := repo.Transfer(ctx, 1, 2, 100, 90, "t1")
+	assert.NilError(t, err)
+
+	entry, err := repo.GetEntry(ctx, 2, "t1")
+	assert.NilError(t, err)
+	assert.Equal(t, int64(90), entry.Amount)
+	assert.Equal(t, model.TagTransfer, entry.Tag)
+
+	//повтор с тем же ключом не изменяет балансы
+	_, _, err = repo.Transfer(ctx, 1, 2, 100, 90, "t1")
+	assert.Equal(t, repository.ErrDuplicateEntry, err)
+
+	from, err := repo.GetById(ctx, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(200), from.Balance)
+
+	_, err = repo.GetEntry(ctx, 1, "t2")
+	assert.Equal(t, repository.ErrEntryNotFound, err)
+}
@@ -0,0 +1,188 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+)
+
+//SchedulesRepo хранит расписания и журнал их запусков в памяти. Все методы выполняются под общим мьютексом.
+type SchedulesRepo struct {
+	mu sync.Mutex
+	//schedules - расписания в порядке создания
+	schedules []*model.Schedule
+	runs      map[string][]*model.ScheduleRun
+	lastRunId int64
+}
+
+func NewSchedulesRepo() *SchedulesRepo {
+	return &SchedulesRepo{
+		runs: make(map[string][]*model.ScheduleRun),
+	}
+}
+
+func (r *SchedulesRepo) CreateSchedule(ctx context.Context, schedule *model.Schedule) (*model.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := cloneSchedule(schedule)
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	r.schedules = append(r.schedules, s)
+
+	return cloneSchedule(s), nil
+}
+
+func (r *SchedulesRepo) GetSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.find(id); ok {
+		return cloneSchedule(s), nil
+	}
+
+	return nil, repository.ErrScheduleNotFound
+}
+
+func (r *SchedulesRepo) ListSchedules(ctx context.Context, balanceId *int32) ([]*model.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var schedules []*model.Schedule
+	for _, s := range r.schedules {
+		if balanceId == nil || s.BalanceId == *balanceId {
+			schedules = append(schedules, cloneSchedule(s))
+		}
+	}
+
+	return schedules, nil
+}
+
+func (r *SchedulesRepo) CancelSchedule(ctx context.Context, id string) (*model.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.find(id)
+	if !ok {
+		return nil, repository.ErrScheduleNotFound
+	}
+	if s.Status != model.ScheduleActive {
+		return nil, repository.ErrScheduleNotActive
+	}
+
+	s.Status = model.ScheduleCancelled
+	s.NextRunAt = time.Time{}
+
+	return cloneSchedule(s), nil
+}
+
+func (r *SchedulesRepo) DueSchedules(ctx context.Context, now time.Time, limit int) ([]*model.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*model.Schedule
+	for _, s := range r.schedules {
+		if s.Status == model.ScheduleActive && !s.NextRunAt.After(now) {
+			due = append(due, cloneSchedule(s))
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextRunAt.Before(due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (r *SchedulesRepo) AdvanceSchedule(ctx context.Context, id string, from, next time.Time, runs []*model.ScheduleRun) ([]*model.ScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.find(id)
+	if !ok {
+		return nil, repository.ErrScheduleNotFound
+	}
+	if s.Status != model.ScheduleActive || !s.NextRunAt.Equal(from) {
+		return nil, repository.ErrScheduleConflict
+	}
+
+	s.NextRunAt = next
+	if next.IsZero() {
+		s.Status = model.ScheduleCompleted
+	}
+
+	added := make([]*model.ScheduleRun, 0, len(runs))
+	for _, run := range runs {
+		r.lastRunId++
+		saved := *run
+		saved.Id = r.lastRunId
+		saved.ScheduleId = id
+		r.runs[id] = append(r.runs[id], &saved)
+
+		res := saved
+		added = append(added, &res)
+	}
+
+	return added, nil
+}
+
+func (r *SchedulesRepo) FinishRun(ctx context.Context, run *model.ScheduleRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, saved := range r.runs[run.ScheduleId] {
+		if saved.Id == run.Id {
+			saved.FinishedAt = run.FinishedAt
+			saved.Status = run.Status
+			saved.Detail = run.Detail
+
+			return nil
+		}
+	}
+
+	return repository.ErrScheduleNotFound
+}
+
+func (r *SchedulesRepo) ListRuns(ctx context.Context, scheduleId string, limit int) ([]*model.ScheduleRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.find(scheduleId); !ok {
+		return nil, repository.ErrScheduleNotFound
+	}
+
+	runs := r.runs[scheduleId]
+	res := make([]*model.ScheduleRun, 0, limit)
+	for i := len(runs) - 1; i >= 0 && len(res) < limit; i-- {
+		run := *runs[i]
+		res = append(res, &run)
+	}
+
+	return res, nil
+}
+
+func (r *SchedulesRepo) find(id string) (*model.Schedule, bool) {
+	for _, s := range r.schedules {
+		if s.Id == id {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+func cloneSchedule(schedule *model.Schedule) *model.Schedule {
+	s := *schedule
+	if schedule.ToBalanceId != nil {
+		toId := *schedule.ToBalanceId
+		s.ToBalanceId = &toId
+	}
+
+	return &s
+}
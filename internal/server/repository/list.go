@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/pkg/labels"
+)
+
+//Поля, по которым можно упорядочить счета в ListAccounts. Счета с одинаковым значением поля упорядочиваются по
+//идентификатору.
+const (
+	OrderById        = "id"
+	OrderByBalance   = "balance"
+	OrderByCreatedAt = "created_at"
+	OrderByUpdatedAt = "updated_at"
+)
+
+//ListQuery - условия отбора, порядок и размер страницы счетов
+type ListQuery struct {
+	Selector labels.Selector
+	//MinBalance и MaxBalance - границы баланса включительно. nil означает отсутствие границы.
+	MinBalance *int64
+	MaxBalance *int64
+	//Status - состояние счетов. Пустое значение означает любое состояние.
+	Status  model.AccountStatus
+	OrderBy string
+	Desc    bool
+	//After - последний счёт предыдущей страницы. Из него используются идентификатор и поле упорядочивания.
+	After *model.Account
+	Limit int
+}
+
+//Matches проверяет, что счёт удовлетворяет условиям отбора.
+func (q *ListQuery) Matches(account *model.Account) bool {
+	if q.MinBalance != nil && account.Balance < *q.MinBalance {
+		return false
+	}
+	if q.MaxBalance != nil && account.Balance > *q.MaxBalance {
+		return false
+	}
+	if q.Status != "" && account.Status != q.Status {
+		return false
+	}
+
+	return q.Selector.Matches(account.Labels)
+}
+
+//Less сообщает, должен ли счёт a находиться раньше счёта b в порядке, заданном запросом.
+func (q *ListQuery) Less(a, b *model.Account) bool {
+	var cmp int
+	switch q.OrderBy {
+	case OrderByBalance:
+		cmp = compareInt64(a.Balance, b.Balance)
+	case OrderByCreatedAt:
+		cmp = compareInt64(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano())
+	case OrderByUpdatedAt:
+		cmp = compareInt64(a.UpdatedAt.UnixNano(), b.UpdatedAt.UnixNano())
+	}
+	if cmp == 0 {
+		cmp = compareInt64(int64(a.Id), int64(b.Id))
+	}
+
+	if q.Desc {
+		return cmp > 0
+	}
+
+	return cmp < 0
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
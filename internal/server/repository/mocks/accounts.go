@@ -4,9 +4,11 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 	model "github.com/vps2/accounttesttask/internal/server/model"
+	repository "github.com/vps2/accounttesttask/internal/server/repository"
 )
 
 // AccountsRepo is an autogenerated mock type for the Accounts type
@@ -14,6 +16,29 @@ type AccountsRepo struct {
 	mock.Mock
 }
 
+// CaptureHold provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AccountsRepo) CaptureHold(_a0 context.Context, _a1 string, _a2 int64) (*model.Account, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *model.Account); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: _a0, _a1
 func (_m *AccountsRepo) Create(_a0 context.Context, _a1 *model.Account) (*model.Account, error) {
 	ret := _m.Called(_a0, _a1)
@@ -37,6 +62,73 @@ func (_m *AccountsRepo) Create(_a0 context.Context, _a1 *model.Account) (*model.
 	return r0, r1
 }
 
+// CreateHold provides a mock function with given fields: _a0, _a1
+func (_m *AccountsRepo) CreateHold(_a0 context.Context, _a1 *model.Hold) (*model.Hold, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *model.Hold
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Hold) *model.Hold); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Hold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Hold) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpiredHolds provides a mock function with given fields: _a0, _a1
+func (_m *AccountsRepo) DeleteExpiredHolds(_a0 context.Context, _a1 time.Time) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteHold provides a mock function with given fields: _a0, _a1
+func (_m *AccountsRepo) DeleteHold(_a0 context.Context, _a1 string) (*model.Hold, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *model.Hold
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Hold); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Hold)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetById provides a mock function with given fields: _a0, _a1
 func (_m *AccountsRepo) GetById(_a0 context.Context, _a1 int32) (*model.Account, error) {
 	ret := _m.Called(_a0, _a1)
@@ -60,6 +152,204 @@ func (_m *AccountsRepo) GetById(_a0 context.Context, _a1 int32) (*model.Account,
 	return r0, r1
 }
 
+// GetEntry provides a mock function with given fields: ctx, accountId, key
+func (_m *AccountsRepo) GetEntry(ctx context.Context, accountId int32, key string) (*model.LedgerEntry, error) {
+	ret := _m.Called(ctx, accountId, key)
+
+	var r0 *model.LedgerEntry
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string) *model.LedgerEntry); ok {
+		r0 = rf(ctx, accountId, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.LedgerEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, string) error); ok {
+		r1 = rf(ctx, accountId, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HeldAmount provides a mock function with given fields: _a0, _a1
+func (_m *AccountsRepo) HeldAmount(_a0 context.Context, _a1 int32) (int64, time.Time, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, int32) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 time.Time
+	if rf, ok := ret.Get(1).(func(context.Context, int32) time.Time); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int32) error); ok {
+		r2 = rf(_a0, _a1)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListAccounts provides a mock function with given fields: ctx, query
+func (_m *AccountsRepo) ListAccounts(ctx context.Context, query *repository.ListQuery) ([]*model.Account, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []*model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, *repository.ListQuery) []*model.Account); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *repository.ListQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PostEntry provides a mock function with given fields: ctx, account, entry
+func (_m *AccountsRepo) PostEntry(ctx context.Context, account *model.Account, entry *model.LedgerEntry) (*model.Account, error) {
+	ret := _m.Called(ctx, account, entry)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Account, *model.LedgerEntry) *model.Account); ok {
+		r0 = rf(ctx, account, entry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Account, *model.LedgerEntry) error); ok {
+		r1 = rf(ctx, account, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetMetadata provides a mock function with given fields: ctx, id, owner, labels
+func (_m *AccountsRepo) SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) (*model.Account, error) {
+	ret := _m.Called(ctx, id, owner, labels)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, int32, string, map[string]string) *model.Account); ok {
+		r0 = rf(ctx, id, owner, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, string, map[string]string) error); ok {
+		r1 = rf(ctx, id, owner, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetMinBalance provides a mock function with given fields: _a0, _a1, _a2
+func (_m *AccountsRepo) SetMinBalance(_a0 context.Context, _a1 int32, _a2 int64) (*model.Account, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int64) *model.Account); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetStatus provides a mock function with given fields: ctx, id, status, reason
+func (_m *AccountsRepo) SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) (*model.StatusChange, error) {
+	ret := _m.Called(ctx, id, status, reason)
+
+	var r0 *model.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, int32, model.AccountStatus, string) *model.StatusChange); ok {
+		r0 = rf(ctx, id, status, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int32, model.AccountStatus, string) error); ok {
+		r1 = rf(ctx, id, status, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Transfer provides a mock function with given fields: ctx, fromId, toId, debit, credit, key
+func (_m *AccountsRepo) Transfer(ctx context.Context, fromId int32, toId int32, debit int64, credit int64, key string) (*model.Account, *model.Account, error) {
+	ret := _m.Called(ctx, fromId, toId, debit, credit, key)
+
+	var r0 *model.Account
+	if rf, ok := ret.Get(0).(func(context.Context, int32, int32, int64, int64, string) *model.Account); ok {
+		r0 = rf(ctx, fromId, toId, debit, credit, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Account)
+		}
+	}
+
+	var r1 *model.Account
+	if rf, ok := ret.Get(1).(func(context.Context, int32, int32, int64, int64, string) *model.Account); ok {
+		r1 = rf(ctx, fromId, toId, debit, credit, key)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*model.Account)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int32, int32, int64, int64, string) error); ok {
+		r2 = rf(ctx, fromId, toId, debit, credit, key)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Update provides a mock function with given fields: _a0, _a1
 func (_m *AccountsRepo) Update(_a0 context.Context, _a1 *model.Account) (*model.Account, error) {
 	ret := _m.Called(_a0, _a1)
@@ -2,13 +2,22 @@ package pg
 
 import (
 	"context"
+	"time"
 
 	"github.com/vps2/accounttesttask/internal/server/model"
 	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/labels"
 
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/vps2/accounttesttask/internal/server/repository/pg")
+
 type AccountsRepo struct {
 	db *pg.DB
 }
@@ -19,9 +28,12 @@ func NewAccountsRepo(db *pg.DB) *AccountsRepo {
 	}
 }
 
-func (repo *AccountsRepo) GetById(ctx context.Context, id int32) (*model.Account, error) {
+func (repo *AccountsRepo) GetById(ctx context.Context, id int32) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.GetById", label.Int32("balance.id", id))
+	defer func() { endSpan(span, err) }()
+
 	account := &model.DBAccount{}
-	err := repo.db.ModelContext(ctx, account).
+	err = repo.db.ModelContext(ctx, account).
 		Where("id = ?", id).
 		Select()
 	if err != nil {
@@ -35,29 +47,566 @@ func (repo *AccountsRepo) GetById(ctx context.Context, id int32) (*model.Account
 	return account.ToAccount(), nil
 }
 
-func (repo *AccountsRepo) Create(ctx context.Context, account *model.Account) (*model.Account, error) {
+func (repo *AccountsRepo) Create(ctx context.Context, account *model.Account) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.Create", label.Int32("balance.id", account.Id))
+	defer func() { endSpan(span, err) }()
+
 	dbAccount := account.ToDBAccount()
-	_, err := repo.db.ModelContext(ctx, dbAccount).
+	_, err = repo.db.ModelContext(ctx, dbAccount).
+		Returning("*").
 		Insert()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return dbAccount.ToAccount(), nil
+}
+
+//Update изменяет только баланс счёта. Запись счёта блокируется до конца транзакции, поэтому сравнение версии,
+//проверка суммы резервирований и изменение выполняются атомарно. Версию увеличивает триггер таблицы, соблюдение
+//минимального баланса дополнительно проверяется ограничением CHECK.
+func (repo *AccountsRepo) Update(ctx context.Context, account *model.Account) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.Update", label.Int32("balance.id", account.Id))
+	defer func() { endSpan(span, err) }()
+
+	dbAccount := &model.DBAccount{}
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		return updateBalance(ctx, tx, account, dbAccount)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return account, nil
+	return dbAccount.ToAccount(), nil
 }
 
-func (repo *AccountsRepo) Update(ctx context.Context, account *model.Account) (*model.Account, error) {
-	dbAccount := account.ToDBAccount()
-	_, err := repo.db.ModelContext(ctx, dbAccount).
+func (repo *AccountsRepo) PostEntry(ctx context.Context, account *model.Account, entry *model.LedgerEntry) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.PostEntry", label.Int32("balance.id", account.Id),
+		label.String("entry.key", entry.Key))
+	defer func() { endSpan(span, err) }()
+
+	dbAccount := &model.DBAccount{}
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if account.Version == 0 {
+			dbAccount = account.ToDBAccount()
+			if _, err := tx.ModelContext(ctx, dbAccount).Returning("*").Insert(); err != nil {
+				return mapError(err)
+			}
+		} else if err := updateBalance(ctx, tx, account, dbAccount); err != nil {
+			return err
+		}
+
+		//откат транзакции при повторной проводке отменяет изменение баланса
+		return insertEntry(ctx, tx, entry, account.Id, entry.Amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dbAccount.ToAccount(), nil
+}
+
+func (repo *AccountsRepo) GetEntry(ctx context.Context, accountId int32, key string) (_ *model.LedgerEntry, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.GetEntry", label.Int32("balance.id", accountId), label.String("entry.key", key))
+	defer func() { endSpan(span, err) }()
+
+	dbEntry := &model.DBLedgerEntry{}
+	err = repo.db.ModelContext(ctx, dbEntry).
+		Where("account_id = ?", accountId).
+		Where("idempotency_key = ?", key).
+		Select()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, repository.ErrEntryNotFound
+		}
+
+		return nil, err
+	}
+
+	return dbEntry.ToLedgerEntry(), nil
+}
+
+//insertEntry сохраняет в транзакции tx проводку entry для счёта accountId с суммой amount. Если у счёта уже есть
+//проводка с тем же ключом, то возвращается ErrDuplicateEntry.
+func insertEntry(ctx context.Context, tx *pg.Tx, entry *model.LedgerEntry, accountId int32, amount int64) error {
+	dbEntry := entry.ToDBLedgerEntry()
+	dbEntry.AccountId = accountId
+	dbEntry.Amount = amount
+	if dbEntry.PostedAt.IsZero() {
+		dbEntry.PostedAt = time.Now()
+	}
+	res, err := tx.ModelContext(ctx, dbEntry).OnConflict("DO NOTHING").Insert()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return repository.ErrDuplicateEntry
+	}
+
+	return nil
+}
+
+//updateBalance изменяет баланс счёта в транзакции tx с проверками, описанными в repository.Accounts.Update,
+//и записывает изменённый счёт в dbAccount.
+func updateBalance(ctx context.Context, tx *pg.Tx, account *model.Account, dbAccount *model.DBAccount) error {
+	current := &model.DBAccount{Id: account.Id}
+	if err := tx.ModelContext(ctx, current).WherePK().For("UPDATE").Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return repository.ErrAccountNotFound
+		}
+
+		return err
+	}
+	if current.Version != account.Version {
+		return repository.ErrVersionConflict
+	}
+
+	if account.Balance < current.Balance {
+		held, err := heldAmount(ctx, tx, account.Id)
+		if err != nil {
+			return err
+		}
+		available, err := repository.Available(account.Balance, held, 0)
+		if err != nil {
+			return err
+		}
+		if available < current.MinBalance {
+			return repository.ErrMinBalanceViolation
+		}
+	}
+
+	_, err := tx.QueryOneContext(ctx, dbAccount,
+		"UPDATE accounts SET balance = ? WHERE id = ? RETURNING *", account.Balance, account.Id)
+
+	return mapError(err)
+}
+
+func (repo *AccountsRepo) SetMinBalance(ctx context.Context, id int32, minBalance int64) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.SetMinBalance", label.Int32("balance.id", id))
+	defer func() { endSpan(span, err) }()
+
+	dbAccount := &model.DBAccount{Id: id, MinBalance: minBalance}
+	_, err = repo.db.ModelContext(ctx, dbAccount).
+		Column("min_balance").
 		WherePK().
+		Returning("*").
 		Update()
 	if err != nil {
 		if err == pg.ErrNoRows {
 			return nil, repository.ErrAccountNotFound
 		}
 
+		return nil, mapError(err)
+	}
+
+	return dbAccount.ToAccount(), nil
+}
+
+//SetStatus блокирует запись счёта до конца транзакции, поэтому проверка баланса перед закрытием, изменение
+//состояния и запись в журнал аудита выполняются атомарно.
+func (repo *AccountsRepo) SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) (_ *model.StatusChange, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.SetStatus", label.Int32("balance.id", id), label.String("status", string(status)))
+	defer func() { endSpan(span, err) }()
+
+	change := &model.DBStatusChange{}
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		account := &model.DBAccount{Id: id}
+		if err := tx.ModelContext(ctx, account).WherePK().For("UPDATE").Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return repository.ErrAccountNotFound
+			}
+
+			return err
+		}
+		if account.Status == string(model.StatusClosed) {
+			return repository.ErrAccountClosed
+		}
+
+		if status == model.StatusClosed {
+			held, err := heldAmount(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if account.Balance != 0 || held != 0 {
+				return repository.ErrAccountNotEmpty
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE accounts SET status = ? WHERE id = ?", status, id); err != nil {
+			return err
+		}
+
+		change = &model.DBStatusChange{
+			AccountId: id,
+			OldStatus: account.Status,
+			NewStatus: string(status),
+			Reason:    reason,
+			ChangedAt: time.Now(),
+		}
+		_, err := tx.ModelContext(ctx, change).Insert()
+
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return account, nil
+	return change.ToStatusChange(), nil
+}
+
+func (repo *AccountsRepo) SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.SetMetadata", label.Int32("balance.id", id))
+	defer func() { endSpan(span, err) }()
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	dbAccount := &model.DBAccount{Id: id, Owner: owner, Labels: labels}
+	_, err = repo.db.ModelContext(ctx, dbAccount).
+		Column("owner", "labels").
+		WherePK().
+		Returning("*").
+		Update()
+	if err != nil {
+		if err == pg.ErrNoRows {
+			return nil, repository.ErrAccountNotFound
+		}
+
+		return nil, err
+	}
+
+	return dbAccount.ToAccount(), nil
+}
+
+//ListAccounts использует постраничный вывод по ключу: следующая страница начинается со счетов, у которых пара
+//(поле упорядочивания, идентификатор) больше (меньше при обратном порядке), чем у query.After. Для каждого поля
+//упорядочивания есть индекс по этой паре, условия на метки используют GIN индекс.
+func (repo *AccountsRepo) ListAccounts(ctx context.Context, query *repository.ListQuery) (_ []*model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.ListAccounts", label.String("order_by", query.OrderBy))
+	defer func() { endSpan(span, err) }()
+
+	var dbAccounts []model.DBAccount
+	q := repo.db.ModelContext(ctx, &dbAccounts)
+
+	for _, r := range query.Selector {
+		switch r.Operator {
+		case labels.Equals:
+			q.Where("labels @> ?", map[string]string{r.Key: r.Value})
+		case labels.NotEquals:
+			q.Where("NOT labels @> ?", map[string]string{r.Key: r.Value})
+		case labels.Exists:
+			q.Where("labels \\? ?", r.Key)
+		case labels.NotExists:
+			q.Where("NOT labels \\? ?", r.Key)
+		}
+	}
+	if query.MinBalance != nil {
+		q.Where("balance >= ?", *query.MinBalance)
+	}
+	if query.MaxBalance != nil {
+		q.Where("balance <= ?", *query.MaxBalance)
+	}
+	if query.Status != "" {
+		q.Where("status = ?", query.Status)
+	}
+
+	cmp, dir := ">", "ASC"
+	if query.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if query.After != nil {
+		switch query.OrderBy {
+		case repository.OrderById:
+			q.Where("id "+cmp+" ?", query.After.Id)
+		case repository.OrderByBalance:
+			q.Where("(balance, id) "+cmp+" (?, ?)", query.After.Balance, query.After.Id)
+		case repository.OrderByCreatedAt:
+			q.Where("(created_at, id) "+cmp+" (?, ?)", query.After.CreatedAt, query.After.Id)
+		case repository.OrderByUpdatedAt:
+			q.Where("(updated_at, id) "+cmp+" (?, ?)", query.After.UpdatedAt, query.After.Id)
+		}
+	}
+	if query.OrderBy != repository.OrderById {
+		q.OrderExpr("? "+dir, pg.Ident(query.OrderBy))
+	}
+
+	err = q.OrderExpr("id " + dir).
+		Limit(query.Limit).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*model.Account, 0, len(dbAccounts))
+	for i := range dbAccounts {
+		accounts = append(accounts, dbAccounts[i].ToAccount())
+	}
+
+	return accounts, nil
+}
+
+//Transfer блокирует записи обоих счетов в порядке возрастания идентификаторов, чтобы встречные переводы не
+//приводили к взаимной блокировке, и изменяет балансы и сохраняет проводки перевода в одной транзакции.
+func (repo *AccountsRepo) Transfer(ctx context.Context, fromId, toId int32, debit, credit int64, key string) (_, _ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.Transfer",
+		label.Int32("balance.from_id", fromId), label.Int32("balance.to_id", toId))
+	defer func() { endSpan(span, err) }()
+
+	from, to := &model.DBAccount{}, &model.DBAccount{}
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		var accounts []model.DBAccount
+		err := tx.ModelContext(ctx, &accounts).
+			WhereIn("id IN (?)", []int32{fromId, toId}).
+			Order("id").
+			For("UPDATE").
+			Select()
+		if err != nil {
+			return err
+		}
+		if len(accounts) != 2 {
+			return repository.ErrAccountNotFound
+		}
+
+		held, err := heldAmount(ctx, tx, fromId)
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			if account.Id != fromId {
+				if err := repository.CheckStatus(model.AccountStatus(account.Status), false); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := repository.CheckStatus(model.AccountStatus(account.Status), true); err != nil {
+				return err
+			}
+			available, err := repository.Available(account.Balance, held, debit)
+			if err != nil {
+				return err
+			}
+			if available < account.MinBalance {
+				return repository.ErrMinBalanceViolation
+			}
+		}
+
+		_, err = tx.QueryOneContext(ctx, from,
+			"UPDATE accounts SET balance = balance - ? WHERE id = ? RETURNING *", debit, fromId)
+		if err != nil {
+			return mapError(err)
+		}
+		_, err = tx.QueryOneContext(ctx, to,
+			"UPDATE accounts SET balance = balance + ? WHERE id = ? RETURNING *", credit, toId)
+		if err != nil || key == "" {
+			return mapError(err)
+		}
+
+		//откат транзакции при повторном ключе отменяет изменение балансов
+		entry := &model.LedgerEntry{Key: key, Tag: model.TagTransfer}
+		if err := insertEntry(ctx, tx, entry, fromId, -debit); err != nil {
+			return err
+		}
+
+		return insertEntry(ctx, tx, entry, toId, credit)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return from.ToAccount(), to.ToAccount(), nil
+}
+
+//CreateHold блокирует запись счёта до конца транзакции, поэтому проверка существования резервирования, состояния
+//счёта и доступной суммы и создание резервирования выполняются атомарно.
+func (repo *AccountsRepo) CreateHold(ctx context.Context, hold *model.Hold) (_ *model.Hold, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.CreateHold", label.Int32("balance.id", hold.BalanceId))
+	defer func() { endSpan(span, err) }()
+
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		account := &model.DBAccount{Id: hold.BalanceId}
+		if err := tx.ModelContext(ctx, account).WherePK().For("UPDATE").Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return repository.ErrAccountNotFound
+			}
+
+			return err
+		}
+		exists, err := tx.ModelContext(ctx, (*model.DBHold)(nil)).Where("id = ?", hold.Id).Exists()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return repository.ErrDuplicateHold
+		}
+		if err := repository.CheckStatus(model.AccountStatus(account.Status), true); err != nil {
+			return err
+		}
+
+		held, err := heldAmount(ctx, tx, hold.BalanceId)
+		if err != nil {
+			return err
+		}
+		available, err := repository.Available(account.Balance, held, hold.Amount)
+		if err != nil {
+			return err
+		}
+		if available < account.MinBalance {
+			return repository.ErrMinBalanceViolation
+		}
+
+		_, err = tx.ModelContext(ctx, hold.ToDBHold()).Insert()
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (repo *AccountsRepo) HeldAmount(ctx context.Context, id int32) (_ int64, _ time.Time, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.HeldAmount", label.Int32("balance.id", id))
+	defer func() { endSpan(span, err) }()
+
+	var (
+		sum  int64
+		next pg.NullTime
+	)
+	_, err = repo.db.QueryOneContext(ctx, pg.Scan(&sum, &next),
+		"SELECT COALESCE(SUM(amount), 0), MIN(expires_at) FROM holds WHERE account_id = ? AND expires_at > ?", id, time.Now())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return sum, next.Time, nil
+}
+
+//CaptureHold удаляет резервирование и изменяет баланс в одной транзакции. Если состояние счёта не допускает
+//списание, то транзакция откатывается и резервирование сохраняется.
+func (repo *AccountsRepo) CaptureHold(ctx context.Context, holdId string, amount int64) (_ *model.Account, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.CaptureHold", label.String("hold.id", holdId))
+	defer func() { endSpan(span, err) }()
+
+	account := &model.DBAccount{}
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		hold := &model.DBHold{}
+		_, err := tx.QueryOneContext(ctx, hold,
+			"DELETE FROM holds WHERE id = ? AND expires_at > ? RETURNING *", holdId, time.Now())
+		if err != nil {
+			if err == pg.ErrNoRows {
+				return repository.ErrHoldNotFound
+			}
+
+			return err
+		}
+		if amount > hold.Amount {
+			return repository.ErrCaptureExceedsHold
+		}
+
+		//состояние проверяется под блокировкой записи счёта, чтобы счёт не был заморожен или закрыт до списания
+		var status string
+		_, err = tx.QueryOneContext(ctx, pg.Scan(&status), "SELECT status FROM accounts WHERE id = ? FOR UPDATE",
+			hold.AccountId)
+		if err != nil {
+			if err == pg.ErrNoRows {
+				return repository.ErrAccountNotFound
+			}
+
+			return err
+		}
+		if err := repository.CheckStatus(model.AccountStatus(status), true); err != nil {
+			return err
+		}
+
+		_, err = tx.QueryOneContext(ctx, account,
+			"UPDATE accounts SET balance = balance - ? WHERE id = ? RETURNING *", amount, hold.AccountId)
+		if err == pg.ErrNoRows {
+			return repository.ErrAccountNotFound
+		}
+
+		return mapError(err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account.ToAccount(), nil
+}
+
+func (repo *AccountsRepo) DeleteHold(ctx context.Context, holdId string) (_ *model.Hold, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.DeleteHold", label.String("hold.id", holdId))
+	defer func() { endSpan(span, err) }()
+
+	dbHold := &model.DBHold{}
+	res, err := repo.db.QueryContext(ctx, dbHold, "DELETE FROM holds WHERE id = ? AND expires_at > ? RETURNING *", holdId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsReturned() == 0 {
+		return nil, repository.ErrHoldNotFound
+	}
+
+	return dbHold.ToHold(), nil
+}
+
+func (repo *AccountsRepo) DeleteExpiredHolds(ctx context.Context, now time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "AccountsRepo.DeleteExpiredHolds")
+	defer func() { endSpan(span, err) }()
+
+	res, err := repo.db.ExecContext(ctx, "DELETE FROM holds WHERE expires_at <= ?", now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func heldAmount(ctx context.Context, db orm.DB, id int32) (int64, error) {
+	var sum int64
+	_, err := db.QueryOneContext(ctx, pg.Scan(&sum),
+		"SELECT COALESCE(SUM(amount), 0) FROM holds WHERE account_id = ? AND expires_at > ?", id, time.Now())
+
+	return sum, err
+}
+
+//mapError преобразует ошибки нарушения ограничений таблицы в ошибки репозитория.
+func mapError(err error) error {
+	if pgErr, ok := err.(pg.Error); ok {
+		switch pgErr.Field('C') {
+		case "23505": //unique_violation
+			return repository.ErrAccountAlreadyExists
+		case "23514": //check_violation
+			return repository.ErrMinBalanceViolation
+		case "22003": //numeric_value_out_of_range
+			return repository.ErrAmountOutOfRange
+		}
+	}
+
+	return err
+}
+
+//startSpan создаёт span операции с хранилищем. Span'ы запросов go-pg становятся его дочерними.
+func startSpan(ctx context.Context, name string, attrs ...label.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, label.String("db.system", "postgresql"))...),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != repository.ErrAccountNotFound && err != repository.ErrMinBalanceViolation &&
+		err != repository.ErrHoldNotFound && err != repository.ErrCaptureExceedsHold &&
+		err != repository.ErrAccountNotEmpty && err != repository.ErrAccountClosed && err != repository.ErrAccountFrozen &&
+		err != repository.ErrVersionConflict && err != repository.ErrAmountOutOfRange &&
+		err != repository.ErrDuplicateEntry && err != repository.ErrEntryNotFound && err != repository.ErrDuplicateHold &&
+		err != repository.ErrScheduleNotFound && err != repository.ErrScheduleNotActive &&
+		err != repository.ErrScheduleConflict {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
@@ -0,0 +1,316 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+//migrationLockId - ключ advisory lock, удерживаемой на время применения миграций. Не даёт нескольким экземплярам
+//сервера применять миграции одновременно.
+const migrationLockId = 7204176303
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT "pk_schema_migrations_version" PRIMARY KEY (version)
+)`
+
+//Migration - миграция схемы базы данных
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+//MigrationStatus - состояние миграции. Для неприменённой миграции AppliedAt содержит нулевое время.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+//Migrator применяет и откатывает миграции схемы. Номера применённых миграций хранятся в таблице schema_migrations.
+type Migrator struct {
+	db         *pg.DB
+	migrations []*Migration
+}
+
+//NewMigrator создаёт Migrator с миграциями, прочитанными из fsys.
+func NewMigrator(db *pg.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+//WithParam задаёт значение именованного параметра ?name, который подставляется в текст миграций. Например,
+//миграция валюты счетов использует ?default_currency.
+func (m *Migrator) WithParam(name string, value interface{}) *Migrator {
+	m.db = m.db.WithParam(name, value)
+
+	return m
+}
+
+//LoadMigrations читает из корня fsys файлы вида <версия>_<наименование>.up.sql и <версия>_<наименование>.down.sql
+//и возвращает миграции, упорядоченные по возрастанию версии.
+func LoadMigrations(fsys fs.FS) ([]*Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		var direction string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: file name must end with .up.sql or .down.sql", file)
+		}
+
+		base := strings.TrimSuffix(file, "."+direction+".sql")
+		i := strings.Index(base, "_")
+		if i <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must start with a version followed by '_'", file)
+		}
+		version, err := strconv.ParseInt(base[:i], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version %q", file, base[:i])
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: base[i+1:]}
+			byVersion[version] = m
+		} else if m.Name != base[i+1:] {
+			return nil, fmt.Errorf("migration %s: version %d is already used by %q", file, version, m.Name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s: up migration is missing or empty", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+//Up применяет все неприменённые миграции и возвращает их.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+
+	err := m.withLock(ctx, func(conn *pg.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)",
+					migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+//Down откатывает steps последних применённых миграций и возвращает их.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var reverted []*Migration
+
+	err := m.withLock(ctx, func(conn *pg.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+
+			err := conn.RunInTransaction(ctx, func(tx *pg.Tx) error {
+				if strings.TrimSpace(migration.Down) != "" {
+					if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+						return err
+					}
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+//Baseline отмечает миграции до версии version включительно как применённые, не выполняя их, и возвращает
+//отмеченные миграции. Используется для баз данных, схема которых была создана до появления миграций: после
+//отметки migrate up применяет только более поздние миграции.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]*Migration, error) {
+	known := false
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			known = true
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	var marked []*Migration
+
+	err := m.withLock(ctx, func(conn *pg.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			_, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)",
+				migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			marked = append(marked, migration)
+		}
+
+		return nil
+	})
+
+	return marked, err
+}
+
+//Status возвращает состояние всех известных миграций.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := m.withLock(ctx, func(conn *pg.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: versions[migration.Version],
+			})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+//withLock выполняет fn на отдельном соединении, удерживая advisory lock. Блокировка сессионная, поэтому все
+//запросы должны выполняться на одном соединении.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pg.Conn) error) (err error) {
+	conn := m.db.Conn()
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", migrationLockId); err != nil {
+		return err
+	}
+	defer func() {
+		if _, unlockErr := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(?)", migrationLockId); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createVersionTable); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pg.Conn) (map[int64]time.Time, error) {
+	var rows []struct {
+		Version   int64
+		AppliedAt time.Time
+	}
+	if _, err := conn.QueryContext(ctx, &rows, "SELECT version, applied_at FROM schema_migrations"); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+
+	return versions, nil
+}
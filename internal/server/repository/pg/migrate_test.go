@@ -0,0 +1,81 @@
+package pg
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	migrations "github.com/vps2/accounttesttask/db-migrations"
+
+	"gotest.tools/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"000002_add_index.up.sql":     {Data: []byte("CREATE INDEX ...")},
+				"000001_init_schema.up.sql":   {Data: []byte("CREATE TABLE ...")},
+				"000001_init_schema.down.sql": {Data: []byte("DROP TABLE ...")},
+			},
+			versions: []int64{1, 2},
+		},
+		{
+			name: "missing up migration",
+			fsys: fstest.MapFS{
+				"000001_init_schema.down.sql": {Data: []byte("DROP TABLE ...")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid version",
+			fsys: fstest.MapFS{
+				"init_schema.up.sql": {Data: []byte("CREATE TABLE ...")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"000001_init_schema.up.sql": {Data: []byte("CREATE TABLE ...")},
+				"000001_other.up.sql":       {Data: []byte("CREATE TABLE ...")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadMigrations(tt.fsys)
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+
+			var versions []int64
+			for _, m := range got {
+				versions = append(versions, m.Version)
+			}
+			assert.DeepEqual(t, tt.versions, versions)
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	got, err := LoadMigrations(migrations.FS)
+	assert.NilError(t, err)
+	assert.Assert(t, len(got) > 0)
+
+	for _, m := range got {
+		assert.Assert(t, strings.TrimSpace(m.Down) != "", "migration %d_%s has no down migration", m.Version, m.Name)
+	}
+
+	//откат начальной миграции удаляет созданную ею таблицу
+	assert.Assert(t, strings.Contains(got[0].Down, "DROP TABLE IF EXISTS accounts"), got[0].Down)
+}
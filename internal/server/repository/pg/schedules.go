@@ -0,0 +1,200 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+
+	"github.com/go-pg/pg/v10"
+	"go.opentelemetry.io/otel/label"
+)
+
+type SchedulesRepo struct {
+	db *pg.DB
+}
+
+func NewSchedulesRepo(db *pg.DB) *SchedulesRepo {
+	return &SchedulesRepo{
+		db: db,
+	}
+}
+
+func (repo *SchedulesRepo) CreateSchedule(ctx context.Context, schedule *model.Schedule) (_ *model.Schedule, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.CreateSchedule", label.String("schedule.id", schedule.Id))
+	defer func() { endSpan(span, err) }()
+
+	dbSchedule := schedule.ToDBSchedule()
+	if dbSchedule.CreatedAt.IsZero() {
+		dbSchedule.CreatedAt = time.Now()
+	}
+	if _, err = repo.db.ModelContext(ctx, dbSchedule).Returning("*").Insert(); err != nil {
+		return nil, err
+	}
+
+	return dbSchedule.ToSchedule(), nil
+}
+
+func (repo *SchedulesRepo) GetSchedule(ctx context.Context, id string) (_ *model.Schedule, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.GetSchedule", label.String("schedule.id", id))
+	defer func() { endSpan(span, err) }()
+
+	dbSchedule := &model.DBSchedule{Id: id}
+	if err = repo.db.ModelContext(ctx, dbSchedule).WherePK().Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, repository.ErrScheduleNotFound
+		}
+
+		return nil, err
+	}
+
+	return dbSchedule.ToSchedule(), nil
+}
+
+func (repo *SchedulesRepo) ListSchedules(ctx context.Context, balanceId *int32) (_ []*model.Schedule, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.ListSchedules")
+	defer func() { endSpan(span, err) }()
+
+	var dbSchedules []*model.DBSchedule
+	q := repo.db.ModelContext(ctx, &dbSchedules)
+	if balanceId != nil {
+		q.Where("account_id = ?", *balanceId)
+	}
+	if err = q.Order("created_at", "id").Select(); err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*model.Schedule, 0, len(dbSchedules))
+	for _, dbSchedule := range dbSchedules {
+		schedules = append(schedules, dbSchedule.ToSchedule())
+	}
+
+	return schedules, nil
+}
+
+func (repo *SchedulesRepo) CancelSchedule(ctx context.Context, id string) (_ *model.Schedule, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.CancelSchedule", label.String("schedule.id", id))
+	defer func() { endSpan(span, err) }()
+
+	dbSchedule := &model.DBSchedule{}
+	_, err = repo.db.QueryOneContext(ctx, dbSchedule,
+		"UPDATE schedules SET status = ?, next_run_at = NULL WHERE id = ? AND status = ? RETURNING *",
+		model.ScheduleCancelled, id, model.ScheduleActive)
+	if err == pg.ErrNoRows {
+		//расписание не найдено или уже не активно
+		if _, err = repo.GetSchedule(ctx, id); err != nil {
+			return nil, err
+		}
+
+		return nil, repository.ErrScheduleNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dbSchedule.ToSchedule(), nil
+}
+
+func (repo *SchedulesRepo) DueSchedules(ctx context.Context, now time.Time, limit int) (_ []*model.Schedule, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.DueSchedules")
+	defer func() { endSpan(span, err) }()
+
+	var dbSchedules []*model.DBSchedule
+	err = repo.db.ModelContext(ctx, &dbSchedules).
+		Where("status = ?", model.ScheduleActive).
+		Where("next_run_at <= ?", now).
+		Order("next_run_at").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*model.Schedule, 0, len(dbSchedules))
+	for _, dbSchedule := range dbSchedules {
+		schedules = append(schedules, dbSchedule.ToSchedule())
+	}
+
+	return schedules, nil
+}
+
+//AdvanceSchedule переносит запуск условным изменением записи расписания, поэтому при нескольких экземплярах
+//сервера один запуск выполняет только один из них.
+func (repo *SchedulesRepo) AdvanceSchedule(ctx context.Context, id string, from, next time.Time, runs []*model.ScheduleRun) (_ []*model.ScheduleRun, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.AdvanceSchedule", label.String("schedule.id", id))
+	defer func() { endSpan(span, err) }()
+
+	status := model.ScheduleActive
+	var nextRunAt interface{} = next
+	if next.IsZero() {
+		status, nextRunAt = model.ScheduleCompleted, nil
+	}
+
+	added := make([]*model.ScheduleRun, 0, len(runs))
+	err = repo.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE schedules SET next_run_at = ?, status = ? WHERE id = ? AND status = ? AND next_run_at = ?",
+			nextRunAt, status, id, model.ScheduleActive, from)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return repository.ErrScheduleConflict
+		}
+
+		for _, run := range runs {
+			dbRun := run.ToDBScheduleRun()
+			dbRun.ScheduleId = id
+			if _, err := tx.ModelContext(ctx, dbRun).Returning("*").Insert(); err != nil {
+				return err
+			}
+			added = append(added, dbRun.ToScheduleRun())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+func (repo *SchedulesRepo) FinishRun(ctx context.Context, run *model.ScheduleRun) (err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.FinishRun", label.String("schedule.id", run.ScheduleId))
+	defer func() { endSpan(span, err) }()
+
+	_, err = repo.db.ModelContext(ctx, run.ToDBScheduleRun()).
+		Column("finished_at", "status", "detail").
+		WherePK().
+		Update()
+
+	return err
+}
+
+func (repo *SchedulesRepo) ListRuns(ctx context.Context, scheduleId string, limit int) (_ []*model.ScheduleRun, err error) {
+	ctx, span := startSpan(ctx, "SchedulesRepo.ListRuns", label.String("schedule.id", scheduleId))
+	defer func() { endSpan(span, err) }()
+
+	if _, err = repo.GetSchedule(ctx, scheduleId); err != nil {
+		return nil, err
+	}
+
+	var dbRuns []*model.DBScheduleRun
+	err = repo.db.ModelContext(ctx, &dbRuns).
+		Where("schedule_id = ?", scheduleId).
+		Order("id DESC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*model.ScheduleRun, 0, len(dbRuns))
+	for _, dbRun := range dbRuns {
+		runs = append(runs, dbRun.ToScheduleRun())
+	}
+
+	return runs, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/vps2/accounttesttask/internal/server/model"
 )
@@ -10,5 +11,53 @@ import (
 type Accounts interface {
 	GetById(context.Context, int32) (*model.Account, error)
 	Create(context.Context, *model.Account) (*model.Account, error)
+	//Update изменяет баланс счёта, если его версия совпадает с account.Version, иначе возвращается
+	//ErrVersionConflict. Если баланс становится меньше минимально допустимого, а при уменьшении баланса - баланс за
+	//вычетом активных резервирований, то возвращается ErrMinBalanceViolation. Любое изменение счёта увеличивает его
+	//версию.
 	Update(context.Context, *model.Account) (*model.Account, error)
+	//PostEntry изменяет баланс счёта так же, как Update, и сохраняет проводку entry в одной операции. Если у счёта
+	//уже есть проводка с тем же ключом, то баланс не изменяется и возвращается ErrDuplicateEntry. Счёт с нулевой
+	//версией создаётся так же, как в Create.
+	PostEntry(ctx context.Context, account *model.Account, entry *model.LedgerEntry) (*model.Account, error)
+	//GetEntry возвращает проводку счёта с ключом key. Если её нет, то возвращается ErrEntryNotFound.
+	GetEntry(ctx context.Context, accountId int32, key string) (*model.LedgerEntry, error)
+	//SetMinBalance изменяет минимально допустимый баланс счёта. Если текущий баланс меньше нового минимального,
+	//то возвращается ErrMinBalanceViolation.
+	SetMinBalance(context.Context, int32, int64) (*model.Account, error)
+	//Transfer списывает debit со счёта fromId и зачисляет credit на счёт toId в одной операции. Суммы выражены
+	//в минорных единицах валют соответствующих счетов. Если баланс счёта списания за вычетом активных резервирований
+	//становится меньше минимально допустимого, то возвращается ErrMinBalanceViolation. Если состояние одного из
+	//счетов не допускает списание или зачисление соответственно, то возвращается ErrAccountFrozen или
+	//ErrAccountClosed. Непустой key сохраняется как ключ проводок перевода на обоих счетах: если у одного из них уже
+	//есть проводка с этим ключом, то балансы не изменяются и возвращается ErrDuplicateEntry. Возвращает оба счёта
+	//с изменёнными балансами.
+	Transfer(ctx context.Context, fromId, toId int32, debit, credit int64, key string) (*model.Account, *model.Account, error)
+	//SetStatus изменяет состояние счёта и добавляет запись в журнал аудита в одной операции. Возвращает добавленную
+	//запись. Закрыть можно только счёт с нулевым балансом без активных резервирований, иначе возвращается
+	//ErrAccountNotEmpty. Состояние закрытого счёта не изменяется, возвращается ErrAccountClosed.
+	SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) (*model.StatusChange, error)
+	//SetMetadata заменяет владельца и метки счёта.
+	SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) (*model.Account, error)
+	//ListAccounts возвращает не более query.Limit счетов, удовлетворяющих условиям отбора, в заданном порядке
+	//начиная с позиции после query.After.
+	ListAccounts(ctx context.Context, query *ListQuery) ([]*model.Account, error)
+
+	//CreateHold резервирует сумму на счёте. Если баланс за вычетом активных резервирований и новой суммы становится
+	//меньше минимально допустимого, то возвращается ErrMinBalanceViolation. Если состояние счёта не допускает
+	//списание, то возвращается ErrAccountFrozen или ErrAccountClosed. Если резервирование с тем же идентификатором уже
+	//есть, то оно не изменяется и возвращается ErrDuplicateHold.
+	CreateHold(context.Context, *model.Hold) (*model.Hold, error)
+	//HeldAmount возвращает сумму активных (не истёкших) резервирований счёта и момент истечения ближайшего из них.
+	//Если активных резервирований нет, то возвращается нулевое время.
+	HeldAmount(context.Context, int32) (int64, time.Time, error)
+	//CaptureHold списывает со счёта сумму, не превышающую зарезервированную, и удаляет резервирование. Возвращает
+	//счёт с изменённым балансом. Если активного резервирования нет, то возвращается ErrHoldNotFound. Если состояние
+	//счёта не допускает списание, то резервирование сохраняется и возвращается ErrAccountFrozen или ErrAccountClosed.
+	CaptureHold(context.Context, string, int64) (*model.Account, error)
+	//DeleteHold удаляет активное резервирование и возвращает его. Если его нет, то возвращается ErrHoldNotFound.
+	DeleteHold(context.Context, string) (*model.Hold, error)
+	//DeleteExpiredHolds удаляет резервирования, срок действия которых истёк к указанному моменту, и возвращает их
+	//количество.
+	DeleteExpiredHolds(context.Context, time.Time) (int, error)
 }
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+)
+
+//go:generate mockery --dir . --name Schedules --filename schedules.go --structname SchedulesRepo --output ./mocks
+type Schedules interface {
+	CreateSchedule(context.Context, *model.Schedule) (*model.Schedule, error)
+	//GetSchedule возвращает расписание. Если его нет, то возвращается ErrScheduleNotFound.
+	GetSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	//ListSchedules возвращает расписания счёта balanceId, а если он не задан - все расписания, в порядке создания.
+	ListSchedules(ctx context.Context, balanceId *int32) ([]*model.Schedule, error)
+	//CancelSchedule отменяет активное расписание. Если расписание уже завершено или отменено, то возвращается
+	//ErrScheduleNotActive.
+	CancelSchedule(ctx context.Context, id string) (*model.Schedule, error)
+	//DueSchedules возвращает не более limit активных расписаний, момент следующего запуска которых наступил к now,
+	//в порядке наступления.
+	DueSchedules(ctx context.Context, now time.Time, limit int) ([]*model.Schedule, error)
+	//AdvanceSchedule переносит следующий запуск активного расписания с момента from на next и добавляет в журнал
+	//записи о запусках runs в одной операции. Нулевой next завершает расписание. Если запуск from уже перенесён
+	//или расписание не активно, то возвращается ErrScheduleConflict. Возвращает добавленные записи.
+	AdvanceSchedule(ctx context.Context, id string, from, next time.Time, runs []*model.ScheduleRun) ([]*model.ScheduleRun, error)
+	//FinishRun сохраняет результат запуска.
+	FinishRun(context.Context, *model.ScheduleRun) error
+	//ListRuns возвращает не более limit последних записей журнала запусков расписания, начиная с последней.
+	ListRuns(ctx context.Context, scheduleId string, limit int) ([]*model.ScheduleRun, error)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import "github.com/vps2/accounttesttask/internal/server/model"
+
+//CheckStatus проверяет, что состояние счёта допускает списание (debit) или зачисление. Для закрытого счёта
+//возвращается ErrAccountClosed, для замороженного - ErrAccountFrozen.
+func CheckStatus(status model.AccountStatus, debit bool) error {
+	switch status {
+	case model.StatusClosed:
+		return ErrAccountClosed
+	case model.StatusFrozen:
+		return ErrAccountFrozen
+	case model.StatusDebitFrozen:
+		if debit {
+			return ErrAccountFrozen
+		}
+	}
+
+	return nil
+}
@@ -2,34 +2,80 @@ package service
 
 import (
 	"context"
-	"errors"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/vps2/accounttesttask/internal/server/model"
 	"github.com/vps2/accounttesttask/internal/server/repository"
 	"github.com/vps2/accounttesttask/pkg/cache"
+	"github.com/vps2/accounttesttask/pkg/currency"
+	"github.com/vps2/accounttesttask/pkg/labels"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/vps2/accounttesttask/internal/server/service")
+
+//maxUpdateAttempts - количество попыток безусловного изменения баланса при конкурентных изменениях счёта
+const maxUpdateAttempts = 10
+
+//AccountsSvc не блокирует счета: атомарность изменений обеспечивает хранилище, а изменения баланса по прочитанному
+//значению выполняются с проверкой версии счёта.
 type AccountsSvc struct {
-	mu    *sync.RWMutex
-	repo  repository.Accounts
-	cache cache.Cache
+	//cacheMu упорядочивает обновления кэша, чтобы более старая версия счёта не заменила более новую
+	cacheMu *sync.Mutex
+	//heldGen увеличивается при каждом изменении резервирований и защищён cacheMu. Сумма резервирований, прочитанная
+	//до изменения, не сохраняется в кэше.
+	heldGen         uint64
+	repo            repository.Accounts
+	cache           cache.Cache
+	defaultCurrency string
+	//maxOperationAmount и maxBalance - ограничения суммы операции и баланса счёта. Ноль отключает ограничение.
+	maxOperationAmount int64
+	maxBalance         int64
 }
 
 func NewAccountsSvc(repo repository.Accounts, cache cache.Cache) *AccountsSvc {
 	return &AccountsSvc{
-		mu:    &sync.RWMutex{},
-		repo:  repo,
-		cache: cache,
+		cacheMu:         &sync.Mutex{},
+		repo:            repo,
+		cache:           cache,
+		defaultCurrency: currency.Default,
 	}
 }
 
+//WithDefaultCurrency задаёт валюту новых счетов, для которых в запросе не указана валюта.
+func (svc *AccountsSvc) WithDefaultCurrency(code string) *AccountsSvc {
+	svc.defaultCurrency = code
+
+	return svc
+}
+
+//WithLimits задаёт максимальную сумму одной операции и максимальный баланс счёта. Ноль отключает ограничение.
+func (svc *AccountsSvc) WithLimits(maxOperationAmount, maxBalance int64) *AccountsSvc {
+	svc.maxOperationAmount = maxOperationAmount
+	svc.maxBalance = maxBalance
+
+	return svc
+}
+
 func (svc *AccountsSvc) GetAmount(ctx context.Context, id int32) (int64, error) {
-	svc.mu.RLock()
-	defer svc.mu.RUnlock()
+	ctx, span := tracer.Start(ctx, "AccountsSvc.GetAmount", trace.WithAttributes(label.Int32("balance.id", id)))
+	defer span.End()
 
-	if val, ok := svc.cache.Get(id); ok {
-		return val.(int64), nil
+	if err := validateId(id); err != nil {
+		return 0, err
+	}
+
+	if account, ok := svc.cacheGet(ctx, id); ok {
+		return account.Balance, nil
 	}
 
 	account, err := svc.repo.GetById(ctx, id)
@@ -40,50 +86,767 @@ func (svc *AccountsSvc) GetAmount(ctx context.Context, id int32) (int64, error)
 	}
 }
 
-func (svc *AccountsSvc) AddAmount(ctx context.Context, id int32, amount int64) error {
-	svc.mu.Lock()
-	defer svc.mu.Unlock()
+//AddAmount изменяет баланс счёта на amount, выраженную в минорных единицах валюты code. Пустой code означает
+//валюту счёта, а для нового счёта - валюту по умолчанию. Если валюта не совпадает с валютой счёта, то возвращается
+//ErrCurrencyMismatch. При конкурентном изменении счёта попытка повторяется. Непустой ключ идемпотентности key
+//сохраняется как ключ проводки: повторный запрос с тем же ключом не изменяет баланс и завершается успешно.
+func (svc *AccountsSvc) AddAmount(ctx context.Context, id int32, amount int64, code, key string) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.AddAmount", trace.WithAttributes(label.Int32("balance.id", id),
+		label.Int64("amount", amount), label.String("currency", code), label.String("idempotency_key", key)))
+	defer span.End()
+
+	if err := svc.validateAddAmount(id, amount); err != nil {
+		return err
+	}
+
+	return svc.addAmount(ctx, id, amount, code, nil, key)
+}
+
+//AddAmountIfVersion изменяет баланс счёта на amount так же, как AddAmount, но только если версия счёта равна
+//version. Нулевая версия означает, что счёта ещё нет. При несовпадении версии возвращается ErrVersionMismatch.
+//Повторный запрос с тем же непустым key завершается успешно без проверки версии.
+func (svc *AccountsSvc) AddAmountIfVersion(ctx context.Context, id int32, amount int64, code string, version int64, key string) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.AddAmountIfVersion", trace.WithAttributes(label.Int32("balance.id", id),
+		label.Int64("amount", amount), label.Int64("version", version), label.String("idempotency_key", key)))
+	defer span.End()
+
+	if err := svc.validateAddAmount(id, amount); err != nil {
+		return err
+	}
+
+	return svc.addAmount(ctx, id, amount, code, &version, key)
+}
+
+//addAmount изменяет баланс счёта на amount. Изменение с непустым key выполняется как проводка с этим ключом.
+func (svc *AccountsSvc) addAmount(ctx context.Context, id int32, amount int64, code string, version *int64, key string) error {
+	var entry *model.LedgerEntry
+	if key != "" {
+		entry = &model.LedgerEntry{Key: key, Tag: model.TagAdd, Amount: amount}
+	}
+
+	err := svc.setBalance(ctx, id, code, version, entry, func(balance int64) (int64, error) { return add(balance, amount) })
+	if err == repository.ErrDuplicateEntry {
+		return nil
+	}
+
+	return err
+}
+
+//SetAmountIfVersion устанавливает баланс счёта равным amount, если версия счёта равна version. Нулевая версия
+//означает, что счёта ещё нет, и он создаётся. При несовпадении версии возвращается ErrVersionMismatch.
+func (svc *AccountsSvc) SetAmountIfVersion(ctx context.Context, id int32, amount int64, code string, version int64) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.SetAmountIfVersion", trace.WithAttributes(
+		label.Int32("balance.id", id), label.Int64("amount", amount), label.Int64("version", version)))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
+		return err
+	}
+
+	return svc.setBalance(ctx, id, code, &version, nil, func(int64) (int64, error) { return amount, nil })
+}
+
+//PostEntry изменяет баланс счёта на entry.Amount в валюте счёта так же, как AddAmount, и сохраняет
+//проводку entry. Если у счёта уже есть проводка с ключом entry.Key, то баланс не изменяется и возвращается
+//repository.ErrDuplicateEntry, поэтому повторная проводка безопасна.
+func (svc *AccountsSvc) PostEntry(ctx context.Context, entry *model.LedgerEntry) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.PostEntry", trace.WithAttributes(label.Int32("balance.id", entry.AccountId),
+		label.Int64("amount", entry.Amount), label.String("entry.key", entry.Key)))
+	defer span.End()
+
+	if err := svc.validateAddAmount(entry.AccountId, entry.Amount); err != nil {
+		return err
+	}
+
+	return svc.setBalance(ctx, entry.AccountId, "", nil, entry,
+		func(balance int64) (int64, error) { return add(balance, entry.Amount) })
+}
+
+//GetBalance возвращает баланс счёта и доступную для списания сумму. Для несуществующего счёта возвращаются нули.
+func (svc *AccountsSvc) GetBalance(ctx context.Context, id int32) (*model.Balance, error) {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.GetBalance", trace.WithAttributes(label.Int32("balance.id", id)))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
+		return nil, err
+	}
+
+	balance := &model.Balance{Id: id}
+	if account, ok := svc.cacheGet(ctx, id); ok {
+		balance.Amount, balance.Currency, balance.Version = account.Balance, account.Currency, account.Version
+	} else if account, err := svc.repo.GetById(ctx, id); err == nil {
+		balance.Amount, balance.Currency, balance.Version = account.Balance, account.Currency, account.Version
+	} else if err == repository.ErrAccountNotFound {
+		return balance, nil
+	} else {
+		return nil, err
+	}
+
+	held, err := svc.heldAmount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Available, err = sub(balance.Amount, held); err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+//Authorize резервирует сумму amount на счёте на время ttl и возвращает идентификатор резервирования. Если баланс
+//за вычетом активных резервирований и amount становится меньше минимально допустимого, то возвращается
+//ErrInsufficientFunds. Непустой code должен совпадать с валютой счёта. Идентификатор резервирования с непустым
+//ключом идемпотентности key определяется ключом, поэтому повторный запрос с тем же ключом не создаёт новое
+//резервирование, а возвращает идентификатор существующего.
+func (svc *AccountsSvc) Authorize(ctx context.Context, id int32, amount int64, code string, ttl time.Duration, key string) (string, error) {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.Authorize", trace.WithAttributes(
+		label.Int32("balance.id", id), label.Int64("amount", amount), label.String("idempotency_key", key)))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
+		return "", err
+	}
+	if amount <= 0 {
+		return "", ErrNonPositiveHoldAmount
+	}
+	if err := svc.checkOperationAmount(amount); err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		return "", ErrNonPositiveHoldTTL
+	}
+	code, err := currencyCode(code)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := svc.repo.GetById(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if code != "" && code != account.Currency {
+		return "", ErrCurrencyMismatch
+	}
+	if err := checkStatus(account, true); err != nil {
+		return "", err
+	}
+
+	hold := &model.Hold{
+		Id:        newId(),
+		BalanceId: id,
+		Amount:    amount,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if key != "" {
+		hold.Id = holdIdForKey(id, key)
+	}
+	if _, err := svc.repo.CreateHold(ctx, hold); err == repository.ErrDuplicateHold {
+		return hold.Id, nil
+	} else if err != nil {
+		return "", balanceError(err)
+	}
+
+	svc.invalidateHeld(id)
+
+	return hold.Id, nil
+}
+
+//Capture списывает со счёта сумму amount, не превышающую зарезервированную, и снимает резервирование. Остаток
+//зарезервированной суммы становится доступным.
+func (svc *AccountsSvc) Capture(ctx context.Context, holdId string, amount int64) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.Capture",
+		trace.WithAttributes(label.String("hold.id", holdId), label.Int64("amount", amount)))
+	defer span.End()
 
-	if account, err := svc.repo.GetById(ctx, id); err == nil { //нашли запись в хранилище
-		newAmount := account.Balance + amount
-		if newAmount < 0 {
-			return errors.New("the balance is less than the withdrawal amount")
+	if amount <= 0 {
+		return ErrNonPositiveCaptureValue
+	}
+	if err := svc.checkOperationAmount(amount); err != nil {
+		return err
+	}
+
+	//состояние счёта проверяет хранилище в момент списания: резервирование могло быть создано до заморозки или
+	//закрытия счёта
+	account, err := svc.repo.CaptureHold(ctx, holdId, amount)
+	if err != nil {
+		return balanceError(err)
+	}
+
+	svc.cacheSet(ctx, account)
+	svc.invalidateHeld(account.Id)
+
+	return nil
+}
+
+//Void снимает резервирование без списания.
+func (svc *AccountsSvc) Void(ctx context.Context, holdId string) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.Void", trace.WithAttributes(label.String("hold.id", holdId)))
+	defer span.End()
+
+	hold, err := svc.repo.DeleteHold(ctx, holdId)
+	if err != nil {
+		return err
+	}
+
+	svc.invalidateHeld(hold.BalanceId)
+
+	return nil
+}
+
+//Transfer переводит amount, выраженную в минорных единицах валюты code, со счёта fromId на счёт toId и возвращает
+//сумму зачисления в минорных единицах валюты счёта toId. Пустой code означает валюту счёта fromId, непустой должен
+//с ней совпадать. Перевод между счетами в разных валютах требует курса fxRate - количества единиц валюты счёта toId
+//за одну единицу валюты счёта fromId; сумма зачисления округляется в сторону нуля. Для счетов в одной валюте курс
+//не указывается. Непустой ключ идемпотентности key сохраняется как ключ проводок перевода: повторный запрос с тем
+//же ключом не изменяет балансы и возвращает сумму зачисления первого перевода.
+func (svc *AccountsSvc) Transfer(ctx context.Context, fromId, toId int32, amount int64, code, fxRate, key string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.Transfer", trace.WithAttributes(label.Int32("balance.from_id", fromId),
+		label.Int32("balance.to_id", toId), label.Int64("amount", amount), label.String("idempotency_key", key)))
+	defer span.End()
+
+	if err := validateId(fromId); err != nil {
+		return 0, err
+	}
+	if err := validateId(toId); err != nil {
+		return 0, err
+	}
+	if amount <= 0 {
+		return 0, ErrNonPositiveTransfer
+	}
+	if err := svc.checkOperationAmount(amount); err != nil {
+		return 0, err
+	}
+	if fromId == toId {
+		return 0, ErrSelfTransfer
+	}
+	code, err := currencyCode(code)
+	if err != nil {
+		return 0, err
+	}
+	if key != "" {
+		//перевод уже выполнен: проверки состояния счетов и баланса могут отклонить повтор
+		if entry, err := svc.repo.GetEntry(ctx, toId, key); err == nil {
+			return entry.Amount, nil
+		} else if err != repository.ErrEntryNotFound {
+			return 0, err
 		}
+	}
 
-		account.Balance = newAmount
+	from, err := svc.repo.GetById(ctx, fromId)
+	if err != nil {
+		return 0, err
+	}
+	to, err := svc.repo.GetById(ctx, toId)
+	if err != nil {
+		return 0, err
+	}
+	if code != "" && code != from.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	if err := checkStatus(from, true); err != nil {
+		return 0, err
+	}
+	if err := checkStatus(to, false); err != nil {
+		return 0, err
+	}
 
-		return svc.update(ctx, account)
-	} else if err == repository.ErrAccountNotFound { //записи нет в хранилище
-		if amount <= 0 {
-			return errors.New("cannot create an account with a negative or zero balance")
+	credit, err := convert(amount, from.Currency, to.Currency, fxRate)
+	if err != nil {
+		return 0, err
+	}
+	toBalance, err := add(to.Balance, credit)
+	if err != nil {
+		return 0, err
+	}
+	if err := svc.checkBalance(to.Balance, toBalance); err != nil {
+		return 0, err
+	}
+
+	//хранилище повторно проверяет состояния счетов в момент перевода, так как они могли измениться после чтения
+	from, to, err = svc.repo.Transfer(ctx, fromId, toId, amount, credit, key)
+	if err == repository.ErrDuplicateEntry { //конкурентный повтор с тем же ключом
+		return credit, nil
+	} else if err != nil {
+		return 0, balanceError(err)
+	}
+
+	svc.cacheSet(ctx, from)
+	svc.cacheSet(ctx, to)
+
+	return credit, nil
+}
+
+//ListAccounts возвращает страницу счетов, удовлетворяющих условиям отбора, и признак продолжения для получения
+//следующей страницы. Для последней страницы признак продолжения пуст.
+func (svc *AccountsSvc) ListAccounts(ctx context.Context, req *ListAccountsRequest) ([]*model.Account, string, error) {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.ListAccounts",
+		trace.WithAttributes(label.String("label_selector", req.LabelSelector), label.String("order_by", req.OrderBy)))
+	defer span.End()
+
+	query, orderBy, err := newListQuery(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	//запрашиваем на один счёт больше, чтобы узнать, есть ли следующая страница
+	pageSize := query.Limit
+	query.Limit++
+
+	accounts, err := svc.repo.ListAccounts(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(accounts) <= pageSize {
+		return accounts, "", nil
+	}
+
+	accounts = accounts[:pageSize]
+
+	return accounts, encodePageToken(orderBy, accounts[pageSize-1]), nil
+}
+
+//StartHoldSweeper запускает периодическое удаление истёкших резервирований. Истёкшие резервирования не уменьшают
+//доступную сумму и до удаления, поэтому интервал влияет только на размер хранилища. Удаление прекращается при
+//отмене ctx.
+func (svc *AccountsSvc) StartHoldSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				svc.sweepHolds(ctx)
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+}
 
-		account = &model.Account{Id: id, Balance: amount}
+func (svc *AccountsSvc) sweepHolds(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.sweepHolds")
+	defer span.End()
 
-		return svc.create(ctx, account)
-	} else {
+	n, err := svc.repo.DeleteExpiredHolds(ctx, time.Now())
+	if err != nil {
+		log.Error("expired holds are not released", log.F(log.FieldError, err))
+		return
+	}
+	if n > 0 {
+		log.Debug("expired holds released", log.F("count", n))
+	}
+}
+
+//SetMinBalance устанавливает минимально допустимый баланс счёта. Отрицательное значение разрешает уход в минус до
+//этой границы. Если текущий баланс меньше нового минимального, то возвращается ErrBalanceBelowMinimum.
+func (svc *AccountsSvc) SetMinBalance(ctx context.Context, id int32, minBalance int64) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.SetMinBalance",
+		trace.WithAttributes(label.Int32("balance.id", id), label.Int64("min_balance", minBalance)))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
 		return err
 	}
+
+	account, err := svc.repo.SetMinBalance(ctx, id, minBalance)
+	if err != nil {
+		if err == repository.ErrMinBalanceViolation {
+			return ErrBalanceBelowMinimum
+		}
+
+		return err
+	}
+
+	//изменение увеличивает версию счёта, поэтому кэш обновляется, чтобы чтения возвращали актуальную версию
+	svc.cacheSet(ctx, account)
+
+	return nil
+}
+
+//SetStatus изменяет состояние счёта: замораживает списания или все операции, размораживает или закрывает счёт.
+//Закрытый счёт не может быть открыт повторно. Каждое изменение записывается в журнал аудита хранилища и в лог.
+func (svc *AccountsSvc) SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.SetStatus",
+		trace.WithAttributes(label.Int32("balance.id", id), label.String("status", string(status))))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
+		return err
+	}
+	switch status {
+	case model.StatusActive, model.StatusDebitFrozen, model.StatusFrozen, model.StatusClosed:
+	default:
+		return ErrUnknownStatus
+	}
+
+	account, err := svc.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if account.Status == model.StatusClosed {
+		return ErrAccountClosed
+	}
+	if account.Status == status {
+		return nil
+	}
+
+	change, err := svc.repo.SetStatus(ctx, id, status, reason)
+	if err != nil {
+		if err == repository.ErrAccountClosed {
+			return ErrAccountClosed
+		}
+
+		return err
+	}
+
+	//состояние изменено, поэтому ошибка чтения счёта лишь оставляет в кэше прежнюю версию
+	if account, err := svc.repo.GetById(ctx, id); err == nil {
+		svc.cacheSet(ctx, account)
+	}
+
+	log.FromContext(ctx).Info("account status changed",
+		log.F(log.FieldBalanceId, id),
+		log.F("from", string(change.From)),
+		log.F("to", string(change.To)),
+		log.F("reason", reason))
+
+	return nil
 }
 
-func (svc *AccountsSvc) update(ctx context.Context, account *model.Account) error {
-	_, err := svc.repo.Update(ctx, account)
+//SetMetadata заменяет владельца и метки счёта.
+func (svc *AccountsSvc) SetMetadata(ctx context.Context, id int32, owner string, lbls map[string]string) error {
+	ctx, span := tracer.Start(ctx, "AccountsSvc.SetMetadata", trace.WithAttributes(label.Int32("balance.id", id)))
+	defer span.End()
+
+	if err := validateId(id); err != nil {
+		return err
+	}
+
+	if err := labels.Validate(lbls); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLabels, err)
+	}
+
+	account, err := svc.repo.SetMetadata(ctx, id, owner, lbls)
 	if err != nil {
 		return err
 	}
 
-	svc.cache.Set(account.Id, account.Balance)
+	svc.cacheSet(ctx, account)
 
 	return nil
 }
 
-func (svc *AccountsSvc) create(ctx context.Context, account *model.Account) error {
-	_, err := svc.repo.Create(ctx, account)
+//setBalance устанавливает баланс счёта id равным newBalance(текущий баланс), а если счёта нет - создаёт его
+//с балансом newBalance(0). Если version не nil, то изменение выполняется только для этой версии счёта, иначе при
+//конкурентном изменении счёта попытка повторяется до maxUpdateAttempts раз. Если entry не nil, то вместе
+//с изменением баланса или созданием счёта сохраняется проводка. Если у счёта уже есть проводка с ключом entry.Key,
+//то возвращается repository.ErrDuplicateEntry до проверок версии и баланса, так как изменение уже выполнено.
+func (svc *AccountsSvc) setBalance(ctx context.Context, id int32, code string, version *int64, entry *model.LedgerEntry, newBalance func(int64) (int64, error)) error {
+	code, err := currencyCode(code)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		if _, err := svc.repo.GetEntry(ctx, id, entry.Key); err == nil {
+			return repository.ErrDuplicateEntry
+		} else if err != repository.ErrEntryNotFound {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := svc.trySetBalance(ctx, id, code, version, entry, newBalance)
+		if err != repository.ErrVersionConflict && err != repository.ErrAccountAlreadyExists {
+			return err
+		}
+		if version != nil || attempt == maxUpdateAttempts {
+			return ErrVersionMismatch
+		}
+	}
+}
+
+func (svc *AccountsSvc) trySetBalance(ctx context.Context, id int32, code string, version *int64, entry *model.LedgerEntry, newBalance func(int64) (int64, error)) error {
+	account, err := svc.repo.GetById(ctx, id)
+	if err == repository.ErrAccountNotFound { //записи нет в хранилище
+		if version != nil && *version != 0 {
+			return ErrVersionMismatch
+		}
+
+		balance, err := newBalance(0)
+		if err != nil {
+			return err
+		}
+		if balance <= 0 {
+			return ErrNonPositiveInitialValue
+		}
+		if err := svc.checkOperationAmount(balance); err != nil {
+			return err
+		}
+		if err := svc.checkBalance(0, balance); err != nil {
+			return err
+		}
+
+		if code == "" {
+			code = svc.defaultCurrency
+		}
+		account = &model.Account{Id: id, Balance: balance, Currency: code, Status: model.StatusActive}
+
+		var saved *model.Account
+		if entry != nil {
+			saved, err = svc.repo.PostEntry(ctx, account, entry)
+		} else {
+			saved, err = svc.repo.Create(ctx, account)
+		}
+		if err != nil {
+			return err
+		}
+		svc.cacheSet(ctx, saved)
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if version != nil && *version != account.Version {
+		return ErrVersionMismatch
+	}
+	if code != "" && code != account.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	balance, err := newBalance(account.Balance)
+	if err != nil {
+		return err
+	}
+	delta, err := sub(balance, account.Balance)
+	if err != nil {
+		return err
+	}
+	if err := svc.checkOperationAmount(delta); err != nil {
+		return err
+	}
+	if err := svc.checkBalance(account.Balance, balance); err != nil {
+		return err
+	}
+
+	debit := delta < 0
+	if err := checkStatus(account, debit); err != nil {
+		return err
+	}
+	if balance < account.MinBalance {
+		return ErrInsufficientFunds
+	}
+	if debit { //списывать можно только не зарезервированную сумму
+		held, _, err := svc.repo.HeldAmount(ctx, id)
+		if err != nil {
+			return err
+		}
+		available, err := sub(balance, held)
+		if err != nil {
+			return err
+		}
+		if available < account.MinBalance {
+			return ErrInsufficientFunds
+		}
+	}
+
+	account.Balance = balance
+
+	//хранилище изменяет баланс, только если версия счёта не изменилась с момента чтения, и повторно проверяет
+	//минимальный баланс в момент изменения
+	var saved *model.Account
+	if entry != nil {
+		saved, err = svc.repo.PostEntry(ctx, account, entry)
+	} else {
+		saved, err = svc.repo.Update(ctx, account)
+	}
 	if err != nil {
+		if err == repository.ErrMinBalanceViolation {
+			return ErrInsufficientFunds
+		}
+
 		return err
 	}
+	svc.cacheSet(ctx, saved)
+
+	return nil
+}
+
+//cacheGet возвращает копию счёта из кэша.
+func (svc *AccountsSvc) cacheGet(ctx context.Context, id int32) (model.Account, bool) {
+	_, span := tracer.Start(ctx, "cache.Get")
+	defer span.End()
+
+	val, ok := svc.cache.Get(id)
+	span.SetAttributes(label.Bool("cache.hit", ok))
+	if !ok {
+		return model.Account{}, false
+	}
+
+	return val.(model.Account), true
+}
+
+//cacheSet сохраняет в кэше копию счёта, чтобы последующие изменения account не затрагивали кэш. Если в кэше уже
+//есть более новая версия счёта, то она не заменяется.
+func (svc *AccountsSvc) cacheSet(ctx context.Context, account *model.Account) {
+	_, span := tracer.Start(ctx, "cache.Set")
+	defer span.End()
+
+	svc.cacheMu.Lock()
+	defer svc.cacheMu.Unlock()
+
+	if val, ok := svc.cache.Get(account.Id); ok && val.(model.Account).Version > account.Version {
+		return
+	}
+
+	svc.cache.Set(account.Id, *account)
+}
 
-	svc.cache.Set(account.Id, account.Balance)
+//heldKey - ключ кэша для суммы резервирований счёта. Тип отличает его от ключа самого счёта.
+type heldKey int32
+
+//heldEntry - сумма активных резервирований счёта. Она актуальна до истечения ближайшего резервирования expiresAt,
+//а при нулевом expiresAt - до появления нового резервирования. Удалять истёкшие резервирования из кэша не нужно:
+//запись перестаёт быть актуальной в момент истечения.
+type heldEntry struct {
+	amount    int64
+	expiresAt time.Time
+	stale     bool
+}
+
+//heldAmount возвращает сумму активных резервирований счёта из кэша, а если её там нет или она устарела, то из
+//хранилища.
+func (svc *AccountsSvc) heldAmount(ctx context.Context, id int32) (int64, error) {
+	if val, ok := svc.cache.Get(heldKey(id)); ok {
+		if entry := val.(heldEntry); !entry.stale && (entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)) {
+			return entry.amount, nil
+		}
+	}
+
+	svc.cacheMu.Lock()
+	gen := svc.heldGen
+	svc.cacheMu.Unlock()
+
+	held, expiresAt, err := svc.repo.HeldAmount(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	svc.cacheMu.Lock()
+	defer svc.cacheMu.Unlock()
+
+	if svc.heldGen == gen {
+		svc.cache.Set(heldKey(id), heldEntry{amount: held, expiresAt: expiresAt})
+	}
+
+	return held, nil
+}
+
+//invalidateHeld помечает сумму резервирований счёта в кэше как устаревшую. Вызывается после создания или снятия
+//резервирования.
+func (svc *AccountsSvc) invalidateHeld(id int32) {
+	svc.cacheMu.Lock()
+	defer svc.cacheMu.Unlock()
+
+	svc.heldGen++
+	svc.cache.Set(heldKey(id), heldEntry{stale: true})
+}
+
+//balanceError преобразует ошибки хранилища, возвращаемые при изменении баланса, в ошибки сервиса.
+func balanceError(err error) error {
+	switch err {
+	case repository.ErrMinBalanceViolation:
+		return ErrInsufficientFunds
+	case repository.ErrAccountFrozen:
+		return ErrAccountFrozen
+	case repository.ErrAccountClosed:
+		return ErrAccountClosed
+	}
+
+	return err
+}
+
+//checkStatus проверяет, что состояние счёта допускает списание (debit) или зачисление.
+func checkStatus(account *model.Account, debit bool) error {
+	switch account.Status {
+	case model.StatusClosed:
+		return ErrAccountClosed
+	case model.StatusFrozen:
+		return ErrAccountFrozen
+	case model.StatusDebitFrozen:
+		if debit {
+			return ErrAccountFrozen
+		}
+	}
 
 	return nil
 }
+
+//currencyCode проверяет код валюты и приводит его к верхнему регистру. Пустой код возвращается без изменений.
+func currencyCode(code string) (string, error) {
+	if code == "" {
+		return "", nil
+	}
+
+	c, err := currency.Lookup(code)
+	if err != nil {
+		return "", ErrUnknownCurrency
+	}
+
+	return c.Code, nil
+}
+
+//convert переводит amount из минорных единиц валюты from в минорные единицы валюты to по курсу rate. Курс
+//обязателен для разных валют и не допускается для одной валюты.
+func convert(amount int64, from, to, rate string) (int64, error) {
+	if from == to {
+		if rate != "" {
+			return 0, ErrInvalidFXRate
+		}
+
+		return amount, nil
+	}
+	if rate == "" {
+		return 0, ErrCurrencyMismatch
+	}
+
+	fromCur, err := currency.Lookup(from)
+	if err != nil {
+		return 0, err
+	}
+	toCur, err := currency.Lookup(to)
+	if err != nil {
+		return 0, err
+	}
+
+	credit, err := currency.Convert(amount, fromCur, toCur, rate)
+	switch {
+	case err == currency.ErrInvalidRate:
+		return 0, ErrInvalidFXRate
+	case err == currency.ErrOverflow:
+		return 0, ErrAmountOutOfRange
+	case err != nil:
+		return 0, err
+	case credit <= 0:
+		return 0, ErrNonPositiveTransfer
+	}
+
+	return credit, nil
+}
+
+//newId возвращает случайный идентификатор резервирования или расписания.
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+//holdIdForKey возвращает идентификатор резервирования на счёте id для ключа идемпотентности key. Он имеет тот же
+//вид, что и случайный идентификатор из newId.
+func holdIdForKey(id int32, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s", id, key)))
+
+	return hex.EncodeToString(sum[:16])
+}
@@ -3,11 +3,15 @@ package service
 import (
 	"context"
 	"errors"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/vps2/accounttesttask/internal/server/model"
 	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/internal/server/repository/inmem"
 	rmocks "github.com/vps2/accounttesttask/internal/server/repository/mocks"
+	"github.com/vps2/accounttesttask/pkg/cache/lru"
 	cmocks "github.com/vps2/accounttesttask/pkg/cache/mocks"
 
 	"github.com/stretchr/testify/mock"
@@ -29,7 +33,7 @@ func TestAccountsSvc_GetAmount(t *testing.T) {
 		{
 			name: "value in cache",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
-				cache.On("Get", input.Id).Return(input.Balance, true)
+				cache.On("Get", input.Id).Return(*input, true)
 			},
 			input: input.Id,
 			want:  input.Balance,
@@ -38,7 +42,7 @@ func TestAccountsSvc_GetAmount(t *testing.T) {
 			name: "in db",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
 				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
-				accountsRepo.On("GetById", context.Background(), input.Id).Return(input, nil)
+				accountsRepo.On("GetById", mock.Anything, input.Id).Return(input, nil)
 			},
 			input: input.Id,
 			want:  input.Balance,
@@ -47,7 +51,7 @@ func TestAccountsSvc_GetAmount(t *testing.T) {
 			name: "not in db",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
 				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
-				accountsRepo.On("GetById", context.Background(), input.Id).Return(nil, errors.New("account not found"))
+				accountsRepo.On("GetById", mock.Anything, input.Id).Return(nil, errors.New("account not found"))
 			},
 			input: input.Id,
 			want:  0,
@@ -78,29 +82,129 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 		name         string
 		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
 		input        *model.Account
+		currency     string
 		err          error
 	}{
 		{
 			name: "new account with positive balance",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
 				in := &model.Account{
+					Id:       1,
+					Balance:  300,
+					Currency: "USD",
+					Status:   model.StatusActive,
+				}
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+				accountsRepo.On("GetById", mock.Anything, in.Id).Return(nil, repository.ErrAccountNotFound)
+				accountsRepo.On("Create", mock.Anything, in).Return(in, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: 300,
+			},
+		},
+		{
+			name: "new account in the requested currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				in := &model.Account{
+					Id:       1,
+					Balance:  300,
+					Currency: "JPY",
+					Status:   model.StatusActive,
+				}
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+				accountsRepo.On("GetById", mock.Anything, in.Id).Return(nil, repository.ErrAccountNotFound)
+				accountsRepo.On("Create", mock.Anything, in).Return(in, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: 300,
+			},
+			currency: "jpy",
+		},
+		{
+			name: "currency mismatch",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
+					Id:       1,
+					Balance:  300,
+					Currency: "USD",
+				}
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: 300,
+			},
+			currency: "EUR",
+			err:      errors.New("the currency does not match the account currency"),
+		},
+		{
+			name:         "unknown currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			input: &model.Account{
+				Id:      1,
+				Balance: 300,
+			},
+			currency: "ABC",
+			err:      errors.New("unknown currency"),
+		},
+		{
+			name: "withdraw from an account with frozen debits",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
 					Id:      1,
 					Balance: 300,
+					Status:  model.StatusDebitFrozen,
 				}
-				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("int64")).Return(true)
-				accountsRepo.On("GetById", context.Background(), in.Id).Return(nil, repository.ErrAccountNotFound)
-				accountsRepo.On("Create", context.Background(), in).Return(in, nil)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
 			},
 			input: &model.Account{
 				Id:      1,
-				Balance: 300,
+				Balance: -100,
 			},
+			err: errors.New("the account is frozen"),
+		},
+		{
+			name: "top up an account with frozen debits",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
+					Id:      1,
+					Balance: 300,
+					Status:  model.StatusDebitFrozen,
+				}
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("Update", mock.Anything, existsAccount).Return(existsAccount, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: 100,
+			},
+		},
+		{
+			name: "top up a closed account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
+					Id:     1,
+					Status: model.StatusClosed,
+				}
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: 100,
+			},
+			err: errors.New("the account is closed"),
 		},
 		{
 			name: "new account with negative balance",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
 				id := int32(1)
-				accountsRepo.On("GetById", context.Background(), id).Return(nil, repository.ErrAccountNotFound)
+				accountsRepo.On("GetById", mock.Anything, id).Return(nil, repository.ErrAccountNotFound)
 			},
 			input: &model.Account{
 				Id:      1,
@@ -115,9 +219,10 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 					Id:      1,
 					Balance: 300,
 				}
-				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("int64")).Return(true)
-				accountsRepo.On("GetById", context.Background(), existsAccount.Id).Return(existsAccount, nil)
-				accountsRepo.On("Update", context.Background(), existsAccount).Return(existsAccount, nil)
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("Update", mock.Anything, existsAccount).Return(existsAccount, nil)
 			},
 			input: &model.Account{
 				Id:      1,
@@ -131,7 +236,7 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 					Id:      1,
 					Balance: 300,
 				}
-				accountsRepo.On("GetById", context.Background(), existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
 			},
 			input: &model.Account{
 				Id:      1,
@@ -146,9 +251,11 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 					Id:      1,
 					Balance: 300,
 				}
-				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("int64")).Return(true)
-				accountsRepo.On("GetById", context.Background(), existsAccount.Id).Return(existsAccount, nil)
-				accountsRepo.On("Update", context.Background(), existsAccount).Return(existsAccount, nil)
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("HeldAmount", mock.Anything, existsAccount.Id).Return(int64(0), time.Time{}, nil)
+				accountsRepo.On("Update", mock.Anything, existsAccount).Return(existsAccount, nil)
 			},
 			input: &model.Account{
 				Id:      1,
@@ -156,14 +263,84 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 			},
 		},
 		{
-			name: "repo error on create",
+			name: "withdraw within the overdraft limit",
 			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
-				in := &model.Account{
+				existsAccount := &model.Account{
+					Id:         1,
+					Balance:    300,
+					MinBalance: -500,
+				}
+				cache.On("Get", existsAccount.Id).Return(nil, false)
+				cache.On("Set", existsAccount.Id, model.Account{Id: 1, Balance: -100, MinBalance: -500}).Return(true)
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("HeldAmount", mock.Anything, existsAccount.Id).Return(int64(0), time.Time{}, nil)
+				accountsRepo.On("Update", mock.Anything, existsAccount).Return(existsAccount, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: -400,
+			},
+		},
+		{
+			name: "withdraw below the minimum reserve",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
+					Id:         1,
+					Balance:    300,
+					MinBalance: 100,
+				}
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: -250,
+			},
+			err: errors.New("the balance is less than the withdrawal amount"),
+		},
+		{
+			name: "withdraw the reserved amount",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
+					Id:      1,
+					Balance: 300,
+				}
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("HeldAmount", mock.Anything, existsAccount.Id).Return(int64(100), time.Time{}, nil)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: -250,
+			},
+			err: errors.New("the balance is less than the withdrawal amount"),
+		},
+		{
+			name: "repo rejects the balance below the minimum",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				existsAccount := &model.Account{
 					Id:      1,
 					Balance: 300,
 				}
-				accountsRepo.On("GetById", context.Background(), in.Id).Return(nil, repository.ErrAccountNotFound)
-				accountsRepo.On("Create", context.Background(), in).Return(nil, errors.New("some error"))
+				accountsRepo.On("GetById", mock.Anything, existsAccount.Id).Return(existsAccount, nil)
+				accountsRepo.On("HeldAmount", mock.Anything, existsAccount.Id).Return(int64(0), time.Time{}, nil)
+				accountsRepo.On("Update", mock.Anything, existsAccount).Return(nil, repository.ErrMinBalanceViolation)
+			},
+			input: &model.Account{
+				Id:      1,
+				Balance: -300,
+			},
+			err: errors.New("the balance is less than the withdrawal amount"),
+		},
+		{
+			name: "repo error on create",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				in := &model.Account{
+					Id:       1,
+					Balance:  300,
+					Currency: "USD",
+					Status:   model.StatusActive,
+				}
+				accountsRepo.On("GetById", mock.Anything, in.Id).Return(nil, repository.ErrAccountNotFound)
+				accountsRepo.On("Create", mock.Anything, in).Return(nil, errors.New("some error"))
 			},
 			input: &model.Account{
 				Id:      1,
@@ -178,8 +355,8 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 					Id:      1,
 					Balance: 300,
 				}
-				accountsRepo.On("GetById", context.Background(), existingAccount.Id).Return(existingAccount, nil)
-				accountsRepo.On("Update", context.Background(), existingAccount).Return(nil, errors.New("some error"))
+				accountsRepo.On("GetById", mock.Anything, existingAccount.Id).Return(existingAccount, nil)
+				accountsRepo.On("Update", mock.Anything, existingAccount).Return(nil, errors.New("some error"))
 			},
 			input: &model.Account{
 				Id:      1,
@@ -194,7 +371,7 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 					Id:      1,
 					Balance: 300,
 				}
-				accountsRepo.On("GetById", context.Background(), in.Id).Return(nil, errors.New("some error"))
+				accountsRepo.On("GetById", mock.Anything, in.Id).Return(nil, errors.New("some error"))
 			},
 			input: &model.Account{
 				Id:      1,
@@ -213,7 +390,7 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 			svc := NewAccountsSvc(accountsRepo, cache)
 			tt.expectations(accountsRepo, cache)
 
-			err := svc.AddAmount(ctx, tt.input.Id, tt.input.Balance)
+			err := svc.AddAmount(ctx, tt.input.Id, tt.input.Balance, tt.currency, "")
 			if err != nil {
 				if tt.err != nil {
 					assert.Equal(t, tt.err.Error(), err.Error())
@@ -230,3 +407,817 @@ func TestAccountsSvc_AddAmount(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountsSvc_SetMinBalance(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		minBalance   int64
+		err          error
+	}{
+		{
+			name: "set overdraft limit",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("SetMinBalance", mock.Anything, int32(1), int64(-500)).
+					Return(&model.Account{Id: 1, Balance: 300, MinBalance: -500, Version: 2}, nil)
+				cache.On("Get", int32(1)).Return(nil, false)
+				cache.On("Set", int32(1), model.Account{Id: 1, Balance: 300, MinBalance: -500, Version: 2}).Return(true)
+			},
+			minBalance: -500,
+		},
+		{
+			name: "minimum balance greater than the balance",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("SetMinBalance", mock.Anything, int32(1), int64(500)).
+					Return(nil, repository.ErrMinBalanceViolation)
+			},
+			minBalance: 500,
+			err:        ErrBalanceBelowMinimum,
+		},
+		{
+			name: "account not found",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("SetMinBalance", mock.Anything, int32(1), int64(100)).
+					Return(nil, repository.ErrAccountNotFound)
+			},
+			minBalance: 100,
+			err:        repository.ErrAccountNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			err := svc.SetMinBalance(context.Background(), 1, tt.minBalance)
+			assert.Equal(t, tt.err, err)
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_SetMinBalance_RefreshesCachedVersion(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAccountsSvc(inmem.NewAccountsRepo(), lru.NewCache(10))
+
+	assert.NilError(t, svc.AddAmount(ctx, 1, 300, "", ""))
+	before, err := svc.GetBalance(ctx, 1)
+	assert.NilError(t, err)
+
+	assert.NilError(t, svc.SetMinBalance(ctx, 1, -500))
+
+	after, err := svc.GetBalance(ctx, 1)
+	assert.NilError(t, err)
+	assert.Assert(t, after.Version > before.Version, "version %d, was %d", after.Version, before.Version)
+
+	//изменение по прочитанной версии не отклоняется
+	assert.NilError(t, svc.AddAmountIfVersion(ctx, 1, 100, "", after.Version, ""))
+}
+
+func TestAccountsSvc_GetBalance(t *testing.T) {
+	accountsRepo := &rmocks.AccountsRepo{}
+	cache := &cmocks.Cache{}
+	svc := NewAccountsSvc(accountsRepo, cache)
+
+	cache.On("Get", int32(1)).Return(model.Account{Id: 1, Balance: 300, Currency: "EUR"}, true)
+	cache.On("Get", heldKey(1)).Return(nil, false)
+	cache.On("Set", heldKey(1), heldEntry{amount: 120}).Return(true)
+	accountsRepo.On("HeldAmount", mock.Anything, int32(1)).Return(int64(120), time.Time{}, nil)
+
+	got, err := svc.GetBalance(context.Background(), 1)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, &model.Balance{Id: 1, Amount: 300, Available: 180, Currency: "EUR"}, got)
+
+	accountsRepo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+}
+
+func TestAccountsSvc_GetBalance_CachesHeldAmount(t *testing.T) {
+	accountsRepo := &rmocks.AccountsRepo{}
+	svc := NewAccountsSvc(accountsRepo, lru.NewCache(10))
+	ctx := context.Background()
+
+	accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300}, nil)
+	accountsRepo.On("HeldAmount", mock.Anything, int32(1)).Return(int64(100), time.Now().Add(time.Hour), nil).Once()
+	accountsRepo.On("HeldAmount", mock.Anything, int32(1)).Return(int64(0), time.Time{}, nil).Once()
+	accountsRepo.On("DeleteHold", mock.Anything, "hold").Return(&model.Hold{Id: "hold", BalanceId: 1}, nil)
+
+	available := func() int64 {
+		balance, err := svc.GetBalance(ctx, 1)
+		assert.NilError(t, err)
+		return balance.Available
+	}
+
+	assert.Equal(t, int64(200), available())
+	assert.Equal(t, int64(200), available()) //из кэша
+
+	//снятие резервирования делает сумму в кэше устаревшей
+	assert.NilError(t, svc.Void(ctx, "hold"))
+
+	assert.Equal(t, int64(300), available())
+	assert.Equal(t, int64(300), available()) //из кэша: резервирований нет
+
+	accountsRepo.AssertExpectations(t)
+	accountsRepo.AssertNumberOfCalls(t, "HeldAmount", 2)
+}
+
+func TestAccountsSvc_GetBalance_HeldAmountExpires(t *testing.T) {
+	accountsRepo := &rmocks.AccountsRepo{}
+	svc := NewAccountsSvc(accountsRepo, lru.NewCache(10))
+	ctx := context.Background()
+
+	//ближайшее резервирование уже истекло, поэтому сумма перечитывается из хранилища
+	accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300}, nil)
+	accountsRepo.On("HeldAmount", mock.Anything, int32(1)).Return(int64(100), time.Now().Add(-time.Second), nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.GetBalance(ctx, 1)
+		assert.NilError(t, err)
+	}
+
+	accountsRepo.AssertNumberOfCalls(t, "HeldAmount", 2)
+}
+
+func TestAccountsSvc_Authorize(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		amount       int64
+		currency     string
+		ttl          time.Duration
+		err          error
+	}{
+		{
+			name: "funds reserved",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1}, nil)
+				accountsRepo.On("CreateHold", mock.Anything, mock.MatchedBy(func(hold *model.Hold) bool {
+					return hold.Id != "" && hold.BalanceId == 1 && hold.Amount == 100 && hold.ExpiresAt.After(time.Now())
+				})).Return(&model.Hold{}, nil)
+				cache.On("Set", heldKey(1), heldEntry{stale: true}).Return(true)
+			},
+			amount: 100,
+			ttl:    time.Minute,
+		},
+		{
+			name: "insufficient funds",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1}, nil)
+				accountsRepo.On("CreateHold", mock.Anything, mock.Anything).Return(nil, repository.ErrMinBalanceViolation)
+			},
+			amount: 100,
+			ttl:    time.Minute,
+			err:    ErrInsufficientFunds,
+		},
+		{
+			name: "funds reserved in the account currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Currency: "USD"}, nil)
+				accountsRepo.On("CreateHold", mock.Anything, mock.Anything).Return(&model.Hold{}, nil)
+				cache.On("Set", heldKey(1), heldEntry{stale: true}).Return(true)
+			},
+			amount:   100,
+			currency: "USD",
+			ttl:      time.Minute,
+		},
+		{
+			name: "currency mismatch",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Currency: "USD"}, nil)
+			},
+			amount:   100,
+			currency: "EUR",
+			ttl:      time.Minute,
+			err:      ErrCurrencyMismatch,
+		},
+		{
+			name: "frozen account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Status: model.StatusDebitFrozen}, nil)
+			},
+			amount: 100,
+			ttl:    time.Minute,
+			err:    ErrAccountFrozen,
+		},
+		{
+			name:         "non-positive amount",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			amount:       0,
+			ttl:          time.Minute,
+			err:          ErrNonPositiveHoldAmount,
+		},
+		{
+			name:         "non-positive ttl",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			amount:       100,
+			err:          ErrNonPositiveHoldTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			holdId, err := svc.Authorize(context.Background(), 1, tt.amount, tt.currency, tt.ttl, "")
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.err == nil, holdId != "")
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_Capture(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		amount       int64
+		err          error
+	}{
+		{
+			name: "hold captured",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("CaptureHold", mock.Anything, "hold", int64(100)).
+					Return(&model.Account{Id: 1, Balance: 200}, nil)
+				cache.On("Get", int32(1)).Return(nil, false)
+				cache.On("Set", int32(1), model.Account{Id: 1, Balance: 200}).Return(true)
+				cache.On("Set", heldKey(1), heldEntry{stale: true}).Return(true)
+			},
+			amount: 100,
+		},
+		{
+			name: "hold not found",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("CaptureHold", mock.Anything, "hold", int64(100)).Return(nil, repository.ErrHoldNotFound)
+			},
+			amount: 100,
+			err:    repository.ErrHoldNotFound,
+		},
+		{
+			name: "account frozen after the hold",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("CaptureHold", mock.Anything, "hold", int64(100)).Return(nil, repository.ErrAccountFrozen)
+			},
+			amount: 100,
+			err:    ErrAccountFrozen,
+		},
+		{
+			name:         "non-positive amount",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			amount:       -100,
+			err:          ErrNonPositiveCaptureValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			err := svc.Capture(context.Background(), "hold", tt.amount)
+			assert.Equal(t, tt.err, err)
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_Transfer(t *testing.T) {
+	usd := &model.Account{Id: 1, Balance: 1000, Currency: "USD"}
+	eur := &model.Account{Id: 2, Balance: 500, Currency: "EUR"}
+	jpy := &model.Account{Id: 3, Balance: 500, Currency: "JPY"}
+
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		toId         int32
+		amount       int64
+		currency     string
+		fxRate       string
+		want         int64
+		err          error
+	}{
+		{
+			name: "same currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(4)).Return(&model.Account{Id: 4, Currency: "USD"}, nil)
+				accountsRepo.On("Transfer", mock.Anything, usd.Id, int32(4), int64(300), int64(300), "").
+					Return(&model.Account{Id: 1, Balance: 700}, &model.Account{Id: 4, Balance: 300}, nil)
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+			},
+			toId:   4,
+			amount: 300,
+			want:   300,
+		},
+		{
+			name: "with exchange rate",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, jpy.Id).Return(jpy, nil)
+				accountsRepo.On("Transfer", mock.Anything, usd.Id, jpy.Id, int64(250), int64(376), "").
+					Return(&model.Account{Id: 1, Balance: 750}, &model.Account{Id: 3, Balance: 876}, nil)
+				cache.On("Get", mock.AnythingOfType("int32")).Return(nil, false)
+				cache.On("Set", mock.AnythingOfType("int32"), mock.AnythingOfType("model.Account")).Return(true)
+			},
+			toId:     jpy.Id,
+			amount:   250,
+			currency: "usd",
+			fxRate:   "150.5",
+			want:     376,
+		},
+		{
+			name: "different currencies without exchange rate",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, eur.Id).Return(eur, nil)
+			},
+			toId:   eur.Id,
+			amount: 300,
+			err:    ErrCurrencyMismatch,
+		},
+		{
+			name: "amount in another currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, eur.Id).Return(eur, nil)
+			},
+			toId:     eur.Id,
+			amount:   300,
+			currency: "EUR",
+			fxRate:   "0.9",
+			err:      ErrCurrencyMismatch,
+		},
+		{
+			name: "exchange rate for the same currency",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(4)).Return(&model.Account{Id: 4, Currency: "USD"}, nil)
+			},
+			toId:   4,
+			amount: 300,
+			fxRate: "1",
+			err:    ErrInvalidFXRate,
+		},
+		{
+			name: "insufficient funds",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, eur.Id).Return(eur, nil)
+				accountsRepo.On("Transfer", mock.Anything, usd.Id, eur.Id, int64(2000), int64(1800), "").
+					Return(nil, nil, repository.ErrMinBalanceViolation)
+			},
+			toId:   eur.Id,
+			amount: 2000,
+			fxRate: "0.9",
+			err:    ErrInsufficientFunds,
+		},
+		{
+			name: "frozen target account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(4)).
+					Return(&model.Account{Id: 4, Currency: "USD", Status: model.StatusFrozen}, nil)
+			},
+			toId:   4,
+			amount: 300,
+			err:    ErrAccountFrozen,
+		},
+		{
+			name: "target account closed after reading",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, usd.Id).Return(usd, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(4)).Return(&model.Account{Id: 4, Currency: "USD"}, nil)
+				accountsRepo.On("Transfer", mock.Anything, usd.Id, int32(4), int64(300), int64(300), "").
+					Return(nil, nil, repository.ErrAccountClosed)
+			},
+			toId:   4,
+			amount: 300,
+			err:    ErrAccountClosed,
+		},
+		{
+			name:         "same account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			toId:         usd.Id,
+			amount:       300,
+			err:          ErrSelfTransfer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			got, err := svc.Transfer(context.Background(), usd.Id, tt.toId, tt.amount, tt.currency, tt.fxRate, "")
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want, got)
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_SetStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		status       model.AccountStatus
+		err          error
+	}{
+		{
+			name: "freeze",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Balance: 300, Status: model.StatusActive, Version: 1}, nil).Once()
+				accountsRepo.On("SetStatus", mock.Anything, int32(1), model.StatusFrozen, "court order").
+					Return(&model.StatusChange{AccountId: 1, From: model.StatusActive, To: model.StatusFrozen}, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Balance: 300, Status: model.StatusFrozen, Version: 2}, nil).Once()
+				cache.On("Get", int32(1)).Return(model.Account{Id: 1, Balance: 300, Version: 1}, true)
+				cache.On("Set", int32(1), model.Account{Id: 1, Balance: 300, Status: model.StatusFrozen, Version: 2}).Return(true)
+			},
+			status: model.StatusFrozen,
+		},
+		{
+			name: "unchanged status",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Status: model.StatusFrozen}, nil)
+			},
+			status: model.StatusFrozen,
+		},
+		{
+			name: "close an account with a non-zero balance",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Balance: 300, Status: model.StatusActive}, nil)
+				accountsRepo.On("SetStatus", mock.Anything, int32(1), model.StatusClosed, "court order").
+					Return(nil, repository.ErrAccountNotEmpty)
+			},
+			status: model.StatusClosed,
+			err:    repository.ErrAccountNotEmpty,
+		},
+		{
+			name: "reopen a closed account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).
+					Return(&model.Account{Id: 1, Status: model.StatusClosed}, nil)
+			},
+			status: model.StatusActive,
+			err:    ErrAccountClosed,
+		},
+		{
+			name:         "unknown status",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {},
+			status:       "deleted",
+			err:          ErrUnknownStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			err := svc.SetStatus(context.Background(), 1, tt.status, "court order")
+			assert.Equal(t, tt.err, err)
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_ListAccounts(t *testing.T) {
+	accountsRepo := &rmocks.AccountsRepo{}
+	svc := NewAccountsSvc(accountsRepo, &cmocks.Cache{})
+
+	accounts := []*model.Account{{Id: 3, Balance: 500}, {Id: 1, Balance: 400}, {Id: 2, Balance: 300}}
+	accountsRepo.On("ListAccounts", mock.Anything, mock.MatchedBy(func(q *repository.ListQuery) bool {
+		return q.After == nil
+	})).Return(accounts, nil).Once()
+	accountsRepo.On("ListAccounts", mock.Anything, mock.MatchedBy(func(q *repository.ListQuery) bool {
+		return q.After != nil && q.After.Id == 1 && q.After.Balance == 400
+	})).Return(accounts[2:], nil).Once()
+
+	req := &ListAccountsRequest{LabelSelector: "env=prod", OrderBy: "Balance DESC", PageSize: 2}
+	page, token, err := svc.ListAccounts(context.Background(), req)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, accounts[:2], page)
+	assert.Assert(t, token != "")
+
+	req.PageToken = token
+	page, token, err = svc.ListAccounts(context.Background(), req)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, accounts[2:], page)
+	assert.Equal(t, "", token)
+
+	accountsRepo.AssertExpectations(t)
+	accountsRepo.AssertCalled(t, "ListAccounts", mock.Anything, mock.MatchedBy(func(q *repository.ListQuery) bool {
+		return q.OrderBy == repository.OrderByBalance && q.Desc && q.Limit == 3 && len(q.Selector) == 1
+	}))
+
+	//признак продолжения нельзя использовать с другим порядком
+	_, _, err = svc.ListAccounts(context.Background(), &ListAccountsRequest{OrderBy: "balance", PageToken: req.PageToken})
+	assert.Equal(t, ErrInvalidPageToken, err)
+}
+
+func TestAccountsSvc_ListAccounts_InvalidRequest(t *testing.T) {
+	one, two := int64(1), int64(2)
+
+	tests := []struct {
+		name string
+		req  *ListAccountsRequest
+		err  error
+	}{
+		{name: "invalid label selector", req: &ListAccountsRequest{LabelSelector: "env=a b"}, err: ErrInvalidLabelSelector},
+		{name: "unknown status", req: &ListAccountsRequest{Status: "deleted"}, err: ErrUnknownStatus},
+		{name: "inverted balance range", req: &ListAccountsRequest{MinBalance: &two, MaxBalance: &one}, err: ErrInvalidBalanceRange},
+		{name: "unknown order field", req: &ListAccountsRequest{OrderBy: "owner"}, err: ErrInvalidOrderBy},
+		{name: "unknown order direction", req: &ListAccountsRequest{OrderBy: "id up"}, err: ErrInvalidOrderBy},
+		{name: "negative page size", req: &ListAccountsRequest{PageSize: -1}, err: ErrInvalidPageSize},
+		{name: "malformed page token", req: &ListAccountsRequest{PageToken: "!"}, err: ErrInvalidPageToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAccountsSvc(&rmocks.AccountsRepo{}, &cmocks.Cache{})
+
+			_, _, err := svc.ListAccounts(context.Background(), tt.req)
+			assert.Assert(t, errors.Is(err, tt.err), "ListAccounts() error = %v, want %v", err, tt.err)
+		})
+	}
+}
+
+func TestAccountsSvc_SetMetadata(t *testing.T) {
+	t.Run("valid labels", func(t *testing.T) {
+		accountsRepo := &rmocks.AccountsRepo{}
+		cache := &cmocks.Cache{}
+		svc := NewAccountsSvc(accountsRepo, cache)
+
+		labels := map[string]string{"env": "prod"}
+		account := &model.Account{Id: 1, Owner: "alice", Labels: labels}
+		accountsRepo.On("SetMetadata", mock.Anything, int32(1), "alice", labels).Return(account, nil)
+		cache.On("Get", int32(1)).Return(nil, false)
+		cache.On("Set", int32(1), *account).Return(true)
+
+		assert.NilError(t, svc.SetMetadata(context.Background(), 1, "alice", labels))
+
+		accountsRepo.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("invalid labels", func(t *testing.T) {
+		svc := NewAccountsSvc(&rmocks.AccountsRepo{}, &cmocks.Cache{})
+
+		err := svc.SetMetadata(context.Background(), 1, "alice", map[string]string{"-env": "prod"})
+		assert.Assert(t, errors.Is(err, ErrInvalidLabels))
+	})
+}
+
+func TestAccountsSvc_SetAmountIfVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache)
+		amount       int64
+		version      int64
+		err          error
+	}{
+		{
+			name: "matching version",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300, Version: 4}, nil)
+				accountsRepo.On("Update", mock.Anything, &model.Account{Id: 1, Balance: 500, Version: 4}).
+					Return(&model.Account{Id: 1, Balance: 500, Version: 5}, nil)
+				cache.On("Get", int32(1)).Return(nil, false)
+				cache.On("Set", int32(1), model.Account{Id: 1, Balance: 500, Version: 5}).Return(true)
+			},
+			amount:  500,
+			version: 4,
+		},
+		{
+			name: "stale version",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300, Version: 5}, nil)
+			},
+			amount:  500,
+			version: 4,
+			err:     ErrVersionMismatch,
+		},
+		{
+			name: "concurrent change after read",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300, Version: 4}, nil)
+				accountsRepo.On("Update", mock.Anything, mock.Anything).Return(nil, repository.ErrVersionConflict)
+			},
+			amount:  500,
+			version: 4,
+			err:     ErrVersionMismatch,
+		},
+		{
+			name: "new account",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				in := &model.Account{Id: 1, Balance: 500, Currency: "USD", Status: model.StatusActive}
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(nil, repository.ErrAccountNotFound)
+				accountsRepo.On("Create", mock.Anything, in).Return(in, nil)
+				cache.On("Get", int32(1)).Return(nil, false)
+				cache.On("Set", int32(1), *in).Return(true)
+			},
+			amount: 500,
+		},
+		{
+			name: "account expected to exist",
+			expectations: func(accountsRepo *rmocks.AccountsRepo, cache *cmocks.Cache) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(nil, repository.ErrAccountNotFound)
+			},
+			amount:  500,
+			version: 1,
+			err:     ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			cache := &cmocks.Cache{}
+			svc := NewAccountsSvc(accountsRepo, cache)
+			tt.expectations(accountsRepo, cache)
+
+			err := svc.SetAmountIfVersion(context.Background(), 1, tt.amount, "", tt.version)
+			assert.Equal(t, tt.err, err)
+
+			accountsRepo.AssertExpectations(t)
+			cache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccountsSvc_AddAmount_RetriesOnConflict(t *testing.T) {
+	accountsRepo := &rmocks.AccountsRepo{}
+	cache := &cmocks.Cache{}
+	svc := NewAccountsSvc(accountsRepo, cache)
+
+	accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 300, Version: 4}, nil).Once()
+	accountsRepo.On("Update", mock.Anything, &model.Account{Id: 1, Balance: 400, Version: 4}).
+		Return(nil, repository.ErrVersionConflict)
+	accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 350, Version: 5}, nil).Once()
+	accountsRepo.On("Update", mock.Anything, &model.Account{Id: 1, Balance: 450, Version: 5}).
+		Return(&model.Account{Id: 1, Balance: 450, Version: 6}, nil)
+	//в кэше уже более новая версия, поэтому она не заменяется
+	cache.On("Get", int32(1)).Return(model.Account{Id: 1, Balance: 470, Version: 7}, true)
+
+	assert.NilError(t, svc.AddAmount(context.Background(), 1, 100, "", ""))
+
+	accountsRepo.AssertExpectations(t)
+	cache.AssertExpectations(t)
+	cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything)
+}
+
+func TestAccountsSvc_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAccountsSvc(inmem.NewAccountsRepo(), lru.NewCache(10))
+
+	balance := func(id int32) *model.Balance {
+		b, err := svc.GetBalance(ctx, id)
+		assert.NilError(t, err)
+		return b
+	}
+
+	//повторное пополнение, в том числе создающее счёт, не изменяет баланс
+	for i := 0; i < 2; i++ {
+		assert.NilError(t, svc.AddAmount(ctx, 1, 1000, "", "add-1"))
+	}
+	assert.Equal(t, int64(1000), balance(1).Amount)
+
+	//повтор изменения по версии завершается успешно, хотя версия уже изменилась
+	version := balance(1).Version
+	for i := 0; i < 2; i++ {
+		assert.NilError(t, svc.AddAmountIfVersion(ctx, 1, -300, "", version, "add-2"))
+	}
+	assert.Equal(t, int64(700), balance(1).Amount)
+
+	assert.NilError(t, svc.AddAmount(ctx, 2, 100, "", ""))
+	for i := 0; i < 2; i++ {
+		credited, err := svc.Transfer(ctx, 1, 2, 200, "", "", "transfer-1")
+		assert.NilError(t, err)
+		assert.Equal(t, int64(200), credited)
+	}
+	assert.Equal(t, int64(500), balance(1).Amount)
+	assert.Equal(t, int64(300), balance(2).Amount)
+
+	//повтор не резервирует сумму ещё раз, хотя на второе резервирование доступной суммы не хватило бы
+	first, err := svc.Authorize(ctx, 1, 400, "", time.Minute, "hold-1")
+	assert.NilError(t, err)
+	second, err := svc.Authorize(ctx, 1, 400, "", time.Minute, "hold-1")
+	assert.NilError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, int64(100), balance(1).Available)
+
+	//ключ действует в пределах счёта
+	other, err := svc.Authorize(ctx, 2, 100, "", time.Minute, "hold-1")
+	assert.NilError(t, err)
+	assert.Assert(t, other != first)
+}
+
+func TestAccountsSvc_Validation(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations func(accountsRepo *rmocks.AccountsRepo)
+		call         func(svc *AccountsSvc) error
+		err          error
+	}{
+		{
+			name:         "zero amount",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {},
+			call:         func(svc *AccountsSvc) error { return svc.AddAmount(context.Background(), 1, 0, "", "") },
+			err:          ErrZeroAmount,
+		},
+		{
+			name:         "negative balance id",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {},
+			call:         func(svc *AccountsSvc) error { return svc.AddAmount(context.Background(), -1, 100, "", "") },
+			err:          ErrInvalidBalanceId,
+		},
+		{
+			name:         "negative balance id on read",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {},
+			call: func(svc *AccountsSvc) error {
+				_, err := svc.GetBalance(context.Background(), -1)
+				return err
+			},
+			err: ErrInvalidBalanceId,
+		},
+		{
+			name:         "operation amount too large",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {},
+			call:         func(svc *AccountsSvc) error { return svc.AddAmount(context.Background(), 1, -1001, "", "") },
+			err:          ErrAmountTooLarge,
+		},
+		{
+			name:         "minimal int64 amount",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {},
+			call:         func(svc *AccountsSvc) error { return svc.AddAmount(context.Background(), 1, math.MinInt64, "", "") },
+			err:          ErrAmountOutOfRange,
+		},
+		{
+			name: "balance limit exceeded",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 9500}, nil)
+			},
+			call: func(svc *AccountsSvc) error { return svc.AddAmount(context.Background(), 1, 600, "", "") },
+			err:  ErrBalanceLimitExceeded,
+		},
+		{
+			name: "set amount above the balance limit",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 9500}, nil)
+			},
+			call: func(svc *AccountsSvc) error { return svc.SetAmountIfVersion(context.Background(), 1, 10100, "", 0) },
+			err:  ErrBalanceLimitExceeded,
+		},
+		{
+			name: "transfer credit above the balance limit",
+			expectations: func(accountsRepo *rmocks.AccountsRepo) {
+				accountsRepo.On("GetById", mock.Anything, int32(1)).Return(&model.Account{Id: 1, Balance: 1000}, nil)
+				accountsRepo.On("GetById", mock.Anything, int32(2)).Return(&model.Account{Id: 2, Balance: 9500}, nil)
+			},
+			call: func(svc *AccountsSvc) error {
+				_, err := svc.Transfer(context.Background(), 1, 2, 600, "", "", "")
+				return err
+			},
+			err: ErrBalanceLimitExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountsRepo := &rmocks.AccountsRepo{}
+			svc := NewAccountsSvc(accountsRepo, &cmocks.Cache{}).WithLimits(1000, 10000)
+			tt.expectations(accountsRepo)
+
+			assert.Equal(t, tt.err, tt.call(svc))
+
+			accountsRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/labels"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//AccrualKind - вид начисления
+type AccrualKind string
+
+const (
+	//AccrualInterest - проценты на положительный баланс по годовой ставке
+	AccrualInterest AccrualKind = "interest"
+	//AccrualFee - списание фиксированной комиссии
+	AccrualFee AccrualKind = "fee"
+)
+
+//AccrualPeriod - период начисления. Каждый период начисляется один раз после его завершения (UTC).
+type AccrualPeriod string
+
+const (
+	AccrualDaily   AccrualPeriod = "daily"
+	AccrualMonthly AccrualPeriod = "monthly"
+)
+
+//daysPerYear - количество дней в году для дневных процентов (конвенция actual/365 fixed)
+const daysPerYear = 365
+
+//AccrualRule - правило начисления
+type AccrualRule struct {
+	//Name - уникальное имя правила. Входит в ключ идемпотентности проводок, поэтому не должно изменяться.
+	Name   string
+	Kind   AccrualKind
+	Period AccrualPeriod
+	//AnnualRate - годовая ставка процентов в виде десятичной дроби, например "0.035". Только для AccrualInterest.
+	AnnualRate string
+	//Amount - комиссия в минорных единицах валюты счёта. Только для AccrualFee.
+	Amount int64
+	//LabelSelector - селектор меток счетов, к которым применяется правило. Пустой селектор означает все счета.
+	LabelSelector string
+}
+
+//errNothingToPost - для счёта нет суммы к начислению
+var errNothingToPost = errors.New("nothing to post")
+
+//accrualRule - проверенное правило начисления
+type accrualRule struct {
+	AccrualRule
+	rate *big.Rat
+}
+
+//AccrualSvc начисляет проценты и списывает периодические комиссии по правилам. Проводки выполняются через
+//AccountsService.PostEntry с ключом "<правило>:<период>", поэтому повторный обход счетов за тот же период, в том
+//числе после перезапуска сервера, не изменяет балансы повторно.
+type AccrualSvc struct {
+	accounts   AccountsService
+	statistics StatisticsService
+	rules      []*accrualRule
+	//completed - последний период каждого правила, обход счетов за который завершён без ошибок
+	completed map[string]string
+}
+
+//NewAccrualSvc проверяет правила и создаёт сервис начислений. Если правило некорректно, то возвращается ошибка,
+//содержащая ErrInvalidAccrualRule.
+func NewAccrualSvc(accounts AccountsService, statistics StatisticsService, rules []AccrualRule) (*AccrualSvc, error) {
+	svc := &AccrualSvc{
+		accounts:   accounts,
+		statistics: statistics,
+		completed:  make(map[string]string),
+	}
+
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		r, err := newAccrualRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule %q: %s", ErrInvalidAccrualRule, rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("%w: duplicate rule %q", ErrInvalidAccrualRule, rule.Name)
+		}
+		names[rule.Name] = true
+		svc.rules = append(svc.rules, r)
+	}
+
+	return svc, nil
+}
+
+func newAccrualRule(rule AccrualRule) (*accrualRule, error) {
+	if rule.Name == "" {
+		return nil, errors.New("the name is empty")
+	}
+	if rule.Period != AccrualDaily && rule.Period != AccrualMonthly {
+		return nil, errors.New("the period must be one of daily, monthly")
+	}
+	if _, err := labels.Parse(rule.LabelSelector); err != nil {
+		return nil, err
+	}
+
+	r := &accrualRule{AccrualRule: rule}
+	switch rule.Kind {
+	case AccrualInterest:
+		rate, ok := new(big.Rat).SetString(rule.AnnualRate)
+		if !ok || rate.Sign() <= 0 {
+			return nil, errors.New("the annual rate must be a positive decimal number")
+		}
+		r.rate = rate
+	case AccrualFee:
+		if rule.Amount <= 0 {
+			return nil, errors.New("the fee amount must be positive")
+		}
+	default:
+		return nil, errors.New("the kind must be one of interest, fee")
+	}
+
+	return r, nil
+}
+
+//Start запускает начисления сразу и затем с интервалом interval до завершения ctx. Каждый запуск обрабатывает
+//последний завершённый период каждого правила.
+func (svc *AccrualSvc) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			svc.run(ctx, time.Now())
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+//run выполняет начисления за последние завершённые к now периоды. Период правила обрабатывается повторно, пока
+//обход счетов не завершится без ошибок.
+func (svc *AccrualSvc) run(ctx context.Context, now time.Time) {
+	for _, rule := range svc.rules {
+		if ctx.Err() != nil {
+			return
+		}
+
+		period := rule.completedPeriod(now)
+		if svc.completed[rule.Name] == period {
+			continue
+		}
+		if svc.accrue(ctx, rule, period) {
+			svc.completed[rule.Name] = period
+		}
+	}
+}
+
+//accrue обходит счета правила и выполняет проводки за период. Возвращает true, если для всех счетов проводки
+//выполнены или отклонены, то есть не было временных ошибок, после которых период нужно повторить.
+func (svc *AccrualSvc) accrue(ctx context.Context, rule *accrualRule, period string) bool {
+	ctx, span := tracer.Start(ctx, "AccrualSvc.accrue",
+		trace.WithAttributes(label.String("accrual.rule", rule.Name), label.String("accrual.period", period)))
+	defer span.End()
+
+	progress := AccrualProgress{Rule: rule.Name, Period: period}
+	req := &ListAccountsRequest{LabelSelector: rule.LabelSelector, PageSize: MaxPageSize}
+	for {
+		accounts, next, err := svc.accounts.ListAccounts(ctx, req)
+		if err != nil {
+			log.Error("accounts are not listed", log.F("rule", rule.Name), log.F(log.FieldError, err))
+			return false
+		}
+
+		for _, account := range accounts {
+			progress.Processed++
+
+			switch err := svc.post(ctx, rule, period, account); {
+			case err == nil:
+				progress.Posted++
+			case err == errNothingToPost, err == repository.ErrDuplicateEntry:
+				progress.Skipped++
+			case isRejected(err):
+				//повтор не изменит решение, поэтому период не откладывается из-за отклонённой проводки
+				progress.Skipped++
+				log.Info("accrual is rejected", log.F("rule", rule.Name), log.F("period", period),
+					log.F(log.FieldBalanceId, account.Id), log.F(log.FieldError, err))
+			default:
+				progress.Failed++
+				log.Warning("accrual is not posted", log.F("rule", rule.Name), log.F("period", period),
+					log.F(log.FieldBalanceId, account.Id), log.F(log.FieldError, err))
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		svc.statistics.ReportAccrual(progress)
+		req.PageToken = next
+	}
+
+	progress.Done = true
+	svc.statistics.ReportAccrual(progress)
+
+	return progress.Failed == 0
+}
+
+//isRejected сообщает, что проводка отклонена из-за состояния счёта, а не из-за временной ошибки хранилища.
+func isRejected(err error) bool {
+	switch err {
+	case ErrInsufficientFunds, ErrBalanceLimitExceeded, ErrAccountFrozen, ErrAccountClosed:
+		return true
+	}
+
+	return false
+}
+
+//post выполняет проводку правила за период по счёту. Сумма процентов вычисляется от баланса счёта на момент
+//обхода.
+func (svc *AccrualSvc) post(ctx context.Context, rule *accrualRule, period string, account *model.Account) error {
+	if account.Status == model.StatusClosed {
+		return errNothingToPost
+	}
+
+	amount, err := rule.amount(account.Balance)
+	if err != nil {
+		return err
+	}
+	if amount == 0 {
+		return errNothingToPost
+	}
+
+	return svc.accounts.PostEntry(ctx, &model.LedgerEntry{
+		AccountId: account.Id,
+		Key:       rule.Name + ":" + period,
+		Tag:       string(rule.Kind),
+		Amount:    amount,
+	})
+}
+
+//amount возвращает сумму проводки правила для баланса balance: начисленные за период проценты или
+//отрицательную сумму комиссии.
+func (rule *accrualRule) amount(balance int64) (int64, error) {
+	if rule.Kind == AccrualFee {
+		return -rule.Amount, nil
+	}
+	if balance <= 0 {
+		return 0, nil
+	}
+
+	periodsPerYear := int64(12)
+	if rule.Period == AccrualDaily {
+		periodsPerYear = daysPerYear
+	}
+
+	interest := new(big.Rat).SetInt64(balance)
+	interest.Mul(interest, rule.rate)
+	interest.Quo(interest, new(big.Rat).SetInt64(periodsPerYear))
+
+	amount, ok := roundHalfEven(interest)
+	if !ok {
+		return 0, ErrAmountOutOfRange
+	}
+
+	return amount, nil
+}
+
+//completedPeriod возвращает обозначение последнего завершённого к now периода правила: дату предыдущего дня
+//или месяц предыдущего месяца в UTC.
+func (rule *accrualRule) completedPeriod(now time.Time) string {
+	now = now.UTC()
+	if rule.Period == AccrualDaily {
+		return time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	}
+
+	return time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+}
+
+//roundHalfEven округляет r до целого. Половины округляются до чётного, поэтому при многократных начислениях
+//ошибки округления не накапливаются в одну сторону.
+func roundHalfEven(r *big.Rat) (int64, bool) {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+
+	twice := new(big.Int).Abs(m)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(r.Denom()); c > 0 || c == 0 && q.Bit(0) == 1 {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return 0, false
+	}
+
+	return q.Int64(), true
+}
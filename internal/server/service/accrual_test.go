@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/repository/inmem"
+	"github.com/vps2/accounttesttask/pkg/cache"
+
+	"gotest.tools/assert"
+)
+
+func TestRoundHalfEven(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"0.4", 0},
+		{"0.5", 0},
+		{"1.5", 2},
+		{"2.5", 2},
+		{"2.51", 3},
+		{"-0.5", 0},
+		{"-1.5", -2},
+		{"-2.6", -3},
+		{"7", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			r, _ := new(big.Rat).SetString(tt.value)
+
+			got, ok := roundHalfEven(r)
+			assert.Assert(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewAccrualSvc(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []AccrualRule
+		ok    bool
+	}{
+		{
+			name: "valid rules",
+			rules: []AccrualRule{
+				{Name: "interest", Kind: AccrualInterest, Period: AccrualDaily, AnnualRate: "0.035"},
+				{Name: "fee", Kind: AccrualFee, Period: AccrualMonthly, Amount: 100, LabelSelector: "plan=basic"},
+			},
+			ok: true,
+		},
+		{
+			name: "duplicate name",
+			rules: []AccrualRule{
+				{Name: "fee", Kind: AccrualFee, Period: AccrualMonthly, Amount: 100},
+				{Name: "fee", Kind: AccrualFee, Period: AccrualDaily, Amount: 1},
+			},
+		},
+		{
+			name:  "invalid rate",
+			rules: []AccrualRule{{Name: "interest", Kind: AccrualInterest, Period: AccrualDaily, AnnualRate: "3.5%"}},
+		},
+		{
+			name:  "unknown period",
+			rules: []AccrualRule{{Name: "fee", Kind: AccrualFee, Period: "weekly", Amount: 100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAccrualSvc(nil, nil, tt.rules)
+			if tt.ok {
+				assert.NilError(t, err)
+			} else {
+				assert.Assert(t, errors.Is(err, ErrInvalidAccrualRule), "NewAccrualSvc() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAccrualSvc_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := inmem.NewAccountsRepo()
+	accountsSvc := NewAccountsSvc(repo, cache.Nop{})
+	for id, balance := range map[int32]int64{1: 7500, 2: 2500, 3: 50} {
+		assert.NilError(t, accountsSvc.AddAmount(ctx, id, balance, "", ""))
+	}
+	assert.NilError(t, accountsSvc.SetMetadata(ctx, 3, "", map[string]string{"plan": "basic"}))
+
+	rules := []AccrualRule{
+		//7500 * 0.073 / 365 = 1.5 округляется до 2, 2500 * 0.073 / 365 = 0.5 - до 0
+		{Name: "interest", Kind: AccrualInterest, Period: AccrualDaily, AnnualRate: "0.073"},
+		{Name: "fee", Kind: AccrualFee, Period: AccrualMonthly, Amount: 100, LabelSelector: "plan=basic"},
+	}
+	statisticsSvc := NewStatisticsSvc(ctx, time.Hour)
+	now := time.Date(2021, 3, 1, 0, 30, 0, 0, time.UTC)
+
+	svc, err := NewAccrualSvc(accountsSvc, statisticsSvc, rules)
+	assert.NilError(t, err)
+	svc.run(ctx, now)
+
+	//комиссия, которую нельзя списать, пропускается, и период считается завершённым
+	progress := []AccrualProgress{
+		{Rule: "fee", Period: "2021-02", Processed: 1, Skipped: 1, Done: true},
+		{Rule: "interest", Period: "2021-02-28", Processed: 3, Posted: 1, Skipped: 2, Done: true},
+	}
+	assert.DeepEqual(t, progress, statisticsSvc.AccrualProgress())
+
+	assert.NilError(t, accountsSvc.AddAmount(ctx, 3, 100, "", ""))
+	svc.run(ctx, now.Add(time.Hour))
+	assert.DeepEqual(t, progress, statisticsSvc.AccrualProgress())
+
+	//новый экземпляр сервиса обходит период заново: комиссия списывается после пополнения счёта, а проценты за
+	//тот же период не начисляются повторно
+	svc, err = NewAccrualSvc(accountsSvc, statisticsSvc, rules)
+	assert.NilError(t, err)
+	svc.run(ctx, now.Add(time.Hour))
+
+	assert.DeepEqual(t, []AccrualProgress{
+		{Rule: "fee", Period: "2021-02", Processed: 1, Posted: 1, Done: true},
+		{Rule: "interest", Period: "2021-02-28", Processed: 3, Skipped: 3, Done: true},
+	}, statisticsSvc.AccrualProgress())
+
+	for id, want := range map[int32]int64{1: 7502, 2: 2500, 3: 50} {
+		balance, err := accountsSvc.GetAmount(ctx, id)
+		assert.NilError(t, err)
+		assert.Equal(t, want, balance, "balance of account %d", id)
+	}
+}
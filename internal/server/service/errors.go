@@ -0,0 +1,39 @@
+package service
+
+import "errors"
+
+//Ошибки, которые могут возвратить сервисы
+var (
+	ErrInsufficientFunds       = errors.New("the balance is less than the withdrawal amount")
+	ErrNonPositiveInitialValue = errors.New("cannot create an account with a negative or zero balance")
+	ErrBalanceBelowMinimum     = errors.New("the balance is less than the requested minimum balance")
+	ErrNonPositiveHoldAmount   = errors.New("the hold amount must be positive")
+	ErrNonPositiveHoldTTL      = errors.New("the hold ttl must be positive")
+	ErrNonPositiveCaptureValue = errors.New("the capture amount must be positive")
+	ErrUnknownCurrency         = errors.New("unknown currency")
+	ErrCurrencyMismatch        = errors.New("the currency does not match the account currency")
+	ErrInvalidFXRate           = errors.New("the exchange rate must be a positive decimal number and is allowed only between different currencies")
+	ErrNonPositiveTransfer     = errors.New("the transfer amount must be positive")
+	ErrSelfTransfer            = errors.New("cannot transfer to the same account")
+	ErrAmountOutOfRange        = errors.New("the amount is out of range")
+	ErrInvalidBalanceId        = errors.New("the balance id must not be negative")
+	ErrZeroAmount              = errors.New("the amount must not be zero")
+	ErrAmountTooLarge          = errors.New("the amount exceeds the maximum amount of an operation")
+	ErrBalanceLimitExceeded    = errors.New("the balance would exceed the maximum balance of an account")
+	ErrAccountFrozen           = errors.New("the account is frozen")
+	ErrAccountClosed           = errors.New("the account is closed")
+	ErrUnknownStatus           = errors.New("unknown account status")
+	ErrVersionMismatch         = errors.New("the account version does not match the expected one")
+	ErrInvalidLabels           = errors.New("invalid labels")
+	ErrInvalidLabelSelector    = errors.New("invalid label selector")
+	ErrInvalidBalanceRange     = errors.New("the minimum balance of the range is greater than the maximum")
+	ErrInvalidOrderBy          = errors.New("the order must be one of id, balance, created_at, updated_at optionally followed by asc or desc")
+	ErrInvalidPageSize         = errors.New("the page size must not be negative")
+	ErrInvalidPageToken        = errors.New("invalid page token")
+	ErrInvalidCron             = errors.New("invalid cron expression")
+	ErrUnknownCatchUpPolicy    = errors.New("the catch-up policy must be one of skip, latest, all")
+	ErrMissingStartTime        = errors.New("a one-time schedule requires a start time")
+	ErrScheduleNeverRuns       = errors.New("the cron expression has no upcoming runs")
+	ErrScheduleNotActive       = errors.New("the schedule is completed or cancelled")
+	ErrInvalidAccrualRule      = errors.New("invalid accrual rule")
+)
@@ -0,0 +1,102 @@
+//go:build go1.18
+// +build go1.18
+
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/vps2/accounttesttask/internal/server/repository/inmem"
+	"github.com/vps2/accounttesttask/pkg/cache"
+)
+
+const (
+	fuzzMaxOperationAmount = 1 << 40
+	fuzzMaxBalance         = 1 << 50
+)
+
+func FuzzAccountsSvc_AddAmount(f *testing.F) {
+	f.Add(int64(100), int64(50))
+	f.Add(int64(100), int64(-150))
+	f.Add(int64(fuzzMaxBalance), int64(1))
+	f.Add(int64(1), int64(-1<<63))
+
+	f.Fuzz(func(t *testing.T, initial, amount int64) {
+		ctx := context.Background()
+		svc := NewAccountsSvc(inmem.NewAccountsRepo(), cache.Nop{}).WithLimits(fuzzMaxOperationAmount, fuzzMaxBalance)
+
+		if err := svc.AddAmount(ctx, 1, initial, "", ""); err != nil {
+			if initial > 0 && initial <= fuzzMaxOperationAmount {
+				t.Fatalf("AddAmount(%d): unexpected error on account creation: %v", initial, err)
+			}
+			return
+		}
+
+		err := svc.AddAmount(ctx, 1, amount, "", "")
+		balance, getErr := svc.GetAmount(ctx, 1)
+		if getErr != nil {
+			t.Fatalf("GetAmount: %v", getErr)
+		}
+
+		expected := new(big.Int).Add(big.NewInt(initial), big.NewInt(amount))
+		if err != nil {
+			if balance != initial {
+				t.Fatalf("AddAmount(%d, %d) failed with %v, but the balance changed to %d", initial, amount, err, balance)
+			}
+			return
+		}
+
+		if !expected.IsInt64() || expected.Int64() != balance {
+			t.Fatalf("AddAmount(%d, %d): expected balance %s, got %d", initial, amount, expected, balance)
+		}
+		if balance < 0 {
+			t.Fatalf("AddAmount(%d, %d): negative balance %d", initial, amount, balance)
+		}
+		if balance > initial && balance > fuzzMaxBalance {
+			t.Fatalf("AddAmount(%d, %d): balance %d exceeds the limit", initial, amount, balance)
+		}
+		if amount == 0 || amount > fuzzMaxOperationAmount || amount < -fuzzMaxOperationAmount {
+			t.Fatalf("AddAmount(%d, %d): amount must be rejected", initial, amount)
+		}
+	})
+}
+
+func FuzzAccountsSvc_Transfer(f *testing.F) {
+	f.Add(int64(100), int64(200), int64(50))
+	f.Add(int64(100), int64(fuzzMaxBalance), int64(1))
+	f.Add(int64(100), int64(1), int64(1<<62))
+
+	f.Fuzz(func(t *testing.T, from, to, amount int64) {
+		ctx := context.Background()
+		svc := NewAccountsSvc(inmem.NewAccountsRepo(), cache.Nop{}).WithLimits(fuzzMaxOperationAmount, fuzzMaxBalance)
+
+		if svc.AddAmount(ctx, 1, from, "", "") != nil || svc.AddAmount(ctx, 2, to, "", "") != nil {
+			return
+		}
+
+		_, err := svc.Transfer(ctx, 1, 2, amount, "", "", "")
+
+		fromBalance, _ := svc.GetAmount(ctx, 1)
+		toBalance, _ := svc.GetAmount(ctx, 2)
+		total := new(big.Int).Add(big.NewInt(fromBalance), big.NewInt(toBalance))
+		expected := new(big.Int).Add(big.NewInt(from), big.NewInt(to))
+		if total.Cmp(expected) != 0 {
+			t.Fatalf("Transfer(%d -> %d, %d): total changed from %s to %s", from, to, amount, expected, total)
+		}
+		if err != nil {
+			if fromBalance != from || toBalance != to {
+				t.Fatalf("Transfer(%d -> %d, %d) failed with %v, but the balances changed", from, to, amount, err)
+			}
+			if errors.Is(err, ErrAmountOutOfRange) && amount <= fuzzMaxOperationAmount {
+				t.Fatalf("Transfer(%d -> %d, %d): unexpected overflow", from, to, amount)
+			}
+			return
+		}
+		if fromBalance < 0 || toBalance > fuzzMaxBalance {
+			t.Fatalf("Transfer(%d -> %d, %d): balances %d and %d out of limits", from, to, amount, fromBalance, toBalance)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/labels"
+)
+
+//Ограничения размера страницы ListAccounts
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+//ListAccountsRequest - условия отбора, порядок и страница счетов
+type ListAccountsRequest struct {
+	//LabelSelector - селектор меток, например "env=prod,team,!legacy"
+	LabelSelector string
+	//MinBalance и MaxBalance - границы баланса включительно. nil означает отсутствие границы.
+	MinBalance *int64
+	MaxBalance *int64
+	//Status - состояние счетов. Пустое значение означает любое состояние.
+	Status model.AccountStatus
+	//OrderBy - поле упорядочивания (id, balance, created_at или updated_at) и необязательное направление desc,
+	//например "balance desc". По умолчанию счета упорядочиваются по идентификатору.
+	OrderBy string
+	//PageSize - максимальное количество счетов на странице. Ноль означает DefaultPageSize, значения больше
+	//MaxPageSize уменьшаются до него.
+	PageSize int
+	//PageToken - признак продолжения, возвращённый с предыдущей страницей. Пустое значение означает первую страницу.
+	PageToken string
+}
+
+//pageToken - позиция последнего счёта страницы. Вместе с ней сохраняется порядок, чтобы признак продолжения
+//нельзя было использовать с другим порядком.
+type pageToken struct {
+	OrderBy   string    `json:"o"`
+	Id        int32     `json:"i"`
+	Balance   int64     `json:"b"`
+	CreatedAt time.Time `json:"c"`
+	UpdatedAt time.Time `json:"u"`
+}
+
+//newListQuery проверяет параметры запроса и преобразует их в запрос к хранилищу.
+func newListQuery(req *ListAccountsRequest) (*repository.ListQuery, string, error) {
+	selector, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidLabelSelector, err)
+	}
+
+	switch req.Status {
+	case "", model.StatusActive, model.StatusDebitFrozen, model.StatusFrozen, model.StatusClosed:
+	default:
+		return nil, "", ErrUnknownStatus
+	}
+
+	if req.MinBalance != nil && req.MaxBalance != nil && *req.MinBalance > *req.MaxBalance {
+		return nil, "", ErrInvalidBalanceRange
+	}
+
+	query := &repository.ListQuery{
+		Selector:   selector,
+		MinBalance: req.MinBalance,
+		MaxBalance: req.MaxBalance,
+		Status:     req.Status,
+		OrderBy:    repository.OrderById,
+		Limit:      req.PageSize,
+	}
+
+	fields := strings.Fields(strings.ToLower(req.OrderBy))
+	if len(fields) > 0 {
+		query.OrderBy = fields[0]
+	}
+	switch query.OrderBy {
+	case repository.OrderById, repository.OrderByBalance, repository.OrderByCreatedAt, repository.OrderByUpdatedAt:
+	default:
+		return nil, "", ErrInvalidOrderBy
+	}
+	switch {
+	case len(fields) < 2 || len(fields) == 2 && fields[1] == "asc":
+	case len(fields) == 2 && fields[1] == "desc":
+		query.Desc = true
+	default:
+		return nil, "", ErrInvalidOrderBy
+	}
+
+	orderBy := query.OrderBy
+	if query.Desc {
+		orderBy += " desc"
+	}
+
+	switch {
+	case query.Limit < 0:
+		return nil, "", ErrInvalidPageSize
+	case query.Limit == 0:
+		query.Limit = DefaultPageSize
+	case query.Limit > MaxPageSize:
+		query.Limit = MaxPageSize
+	}
+
+	if req.PageToken != "" {
+		token, err := decodePageToken(req.PageToken)
+		if err != nil || token.OrderBy != orderBy {
+			return nil, "", ErrInvalidPageToken
+		}
+
+		query.After = &model.Account{
+			Id:        token.Id,
+			Balance:   token.Balance,
+			CreatedAt: token.CreatedAt,
+			UpdatedAt: token.UpdatedAt,
+		}
+	}
+
+	return query, orderBy, nil
+}
+
+func encodePageToken(orderBy string, last *model.Account) string {
+	b, _ := json.Marshal(&pageToken{
+		OrderBy:   orderBy,
+		Id:        last.Id,
+		Balance:   last.Balance,
+		CreatedAt: last.CreatedAt,
+		UpdatedAt: last.UpdatedAt,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodePageToken(s string) (*pageToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &pageToken{}
+	if err := json.Unmarshal(b, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository"
+	"github.com/vps2/accounttesttask/pkg/cron"
+	"github.com/vps2/accounttesttask/pkg/log"
+
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	//DefaultMisfireThreshold - опоздание запуска, после которого он считается пропущенным
+	DefaultMisfireThreshold = time.Minute
+	//dueBatchSize - количество расписаний, обрабатываемых за один запрос к хранилищу
+	dueBatchSize = 100
+	//maxRunsPerTick - максимальное количество запусков одного расписания за один проход. Оставшиеся пропущенные
+	//запуски политики CatchUpAll выполняются на следующих проходах.
+	maxRunsPerTick = 100
+)
+
+//CreateScheduleRequest - параметры отложенной или повторяющейся операции
+type CreateScheduleRequest struct {
+	BalanceId int32
+	//ToBalanceId - счёт зачисления перевода. Если не задан, то Amount зачисляется на счёт BalanceId, а отрицательная
+	//сумма списывается с него.
+	ToBalanceId *int32
+	Amount      int64
+	Currency    string
+	//Cron - расписание повторяющейся операции в формате cron (UTC). Пустое для однократной операции.
+	Cron string
+	//StartAt - момент однократной операции или момент, не раньше которого начинаются повторяющиеся. Для
+	//повторяющейся операции нулевое значение означает текущий момент.
+	StartAt time.Time
+	//CatchUp - политика пропущенных запусков. По умолчанию model.CatchUpLatest.
+	CatchUp     model.CatchUpPolicy
+	Description string
+}
+
+//SchedulerSvc выполняет отложенные и повторяющиеся операции со счетами через AccountsService. Перед выполнением
+//запуск записывается в журнал, а расписание переносится на следующий момент в одной операции с хранилищем, поэтому
+//каждый запуск выполняется не более одного раза, в том числе при нескольких экземплярах сервера.
+type SchedulerSvc struct {
+	repo     repository.Schedules
+	accounts AccountsService
+	//misfireThreshold - опоздание запуска, после которого к нему применяется политика пропущенных запусков
+	misfireThreshold time.Duration
+}
+
+func NewSchedulerSvc(repo repository.Schedules, accounts AccountsService) *SchedulerSvc {
+	return &SchedulerSvc{
+		repo:             repo,
+		accounts:         accounts,
+		misfireThreshold: DefaultMisfireThreshold,
+	}
+}
+
+//WithMisfireThreshold задаёт опоздание запуска, после которого он считается пропущенным.
+func (svc *SchedulerSvc) WithMisfireThreshold(threshold time.Duration) *SchedulerSvc {
+	svc.misfireThreshold = threshold
+
+	return svc
+}
+
+//CreateSchedule проверяет параметры и сохраняет расписание.
+func (svc *SchedulerSvc) CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*model.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.CreateSchedule",
+		trace.WithAttributes(label.Int32("balance.id", req.BalanceId), label.String("cron", req.Cron)))
+	defer span.End()
+
+	if err := validateId(req.BalanceId); err != nil {
+		return nil, err
+	}
+	if req.ToBalanceId != nil {
+		if err := validateId(*req.ToBalanceId); err != nil {
+			return nil, err
+		}
+		if req.Amount <= 0 {
+			return nil, ErrNonPositiveTransfer
+		}
+		if *req.ToBalanceId == req.BalanceId {
+			return nil, ErrSelfTransfer
+		}
+	}
+	if req.Amount == 0 {
+		return nil, ErrZeroAmount
+	}
+	code, err := currencyCode(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	catchUp := req.CatchUp
+	switch catchUp {
+	case "":
+		catchUp = model.CatchUpLatest
+	case model.CatchUpSkip, model.CatchUpLatest, model.CatchUpAll:
+	default:
+		return nil, ErrUnknownCatchUpPolicy
+	}
+
+	var nextRunAt time.Time
+	if req.Cron == "" {
+		if req.StartAt.IsZero() {
+			return nil, ErrMissingStartTime
+		}
+		nextRunAt = req.StartAt.UTC()
+	} else {
+		spec, err := cron.Parse(req.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCron, err)
+		}
+
+		//первый запуск - не раньше StartAt и не в прошлом
+		from := time.Now().UTC()
+		if req.StartAt.After(from) {
+			from = req.StartAt.UTC().Add(-time.Nanosecond)
+		}
+		if nextRunAt = spec.Next(from); nextRunAt.IsZero() {
+			return nil, ErrScheduleNeverRuns
+		}
+	}
+
+	schedule := &model.Schedule{
+		Id:          newId(),
+		BalanceId:   req.BalanceId,
+		ToBalanceId: req.ToBalanceId,
+		Amount:      req.Amount,
+		Currency:    code,
+		Cron:        req.Cron,
+		CatchUp:     catchUp,
+		Status:      model.ScheduleActive,
+		Description: req.Description,
+		NextRunAt:   nextRunAt,
+	}
+
+	return svc.repo.CreateSchedule(ctx, schedule)
+}
+
+//ListSchedules возвращает расписания счёта balanceId, а если он не задан - все расписания.
+func (svc *SchedulerSvc) ListSchedules(ctx context.Context, balanceId *int32) ([]*model.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.ListSchedules")
+	defer span.End()
+
+	if balanceId != nil {
+		if err := validateId(*balanceId); err != nil {
+			return nil, err
+		}
+	}
+
+	return svc.repo.ListSchedules(ctx, balanceId)
+}
+
+//CancelSchedule отменяет расписание. Уже начатый запуск завершается.
+func (svc *SchedulerSvc) CancelSchedule(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.CancelSchedule", trace.WithAttributes(label.String("schedule.id", id)))
+	defer span.End()
+
+	if _, err := svc.repo.CancelSchedule(ctx, id); err != nil {
+		if err == repository.ErrScheduleNotActive {
+			return ErrScheduleNotActive
+		}
+
+		return err
+	}
+
+	log.FromContext(ctx).Info("schedule cancelled", log.F("schedule_id", id))
+
+	return nil
+}
+
+//ListScheduleRuns возвращает не более limit последних запусков расписания, начиная с последнего. Ноль означает
+//DefaultPageSize, значения больше MaxPageSize уменьшаются до него.
+func (svc *SchedulerSvc) ListScheduleRuns(ctx context.Context, id string, limit int) ([]*model.ScheduleRun, error) {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.ListScheduleRuns", trace.WithAttributes(label.String("schedule.id", id)))
+	defer span.End()
+
+	switch {
+	case limit < 0:
+		return nil, ErrInvalidPageSize
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit > MaxPageSize:
+		limit = MaxPageSize
+	}
+
+	return svc.repo.ListRuns(ctx, id, limit)
+}
+
+//Start запускает периодическое выполнение наступивших запусков расписаний. Выполнение прекращается при отмене ctx.
+func (svc *SchedulerSvc) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				svc.runDue(ctx, time.Now().UTC())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+//runDue выполняет запуски расписаний, наступившие к now.
+func (svc *SchedulerSvc) runDue(ctx context.Context, now time.Time) {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.runDue")
+	defer span.End()
+
+	for {
+		schedules, err := svc.repo.DueSchedules(ctx, now, dueBatchSize)
+		if err != nil {
+			log.Error("due schedules are not loaded", log.F(log.FieldError, err))
+			return
+		}
+
+		progress := false
+		for _, schedule := range schedules {
+			if svc.process(ctx, schedule, now) {
+				progress = true
+			}
+		}
+
+		//расписания, у которых остались наступившие запуски, обрабатываются на следующем проходе
+		if len(schedules) < dueBatchSize || !progress || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+//process выполняет наступившие к now запуски расписания с учётом политики пропущенных запусков и возвращает
+//признак того, что у расписания не осталось наступивших запусков.
+func (svc *SchedulerSvc) process(ctx context.Context, schedule *model.Schedule, now time.Time) bool {
+	var spec *cron.Schedule
+	if schedule.Cron != "" {
+		var err error
+		if spec, err = cron.Parse(schedule.Cron); err != nil {
+			log.Error("invalid schedule", log.F("schedule_id", schedule.Id), log.F(log.FieldError, err))
+			return false
+		}
+	}
+
+	for i := 0; i < maxRunsPerTick; i++ {
+		if schedule.NextRunAt.IsZero() || schedule.NextRunAt.After(now) {
+			return true
+		}
+
+		from := schedule.NextRunAt
+		runAt, next := from, nextRun(spec, from)
+		var runs []*model.ScheduleRun
+
+		if now.Sub(from) > svc.misfireThreshold && schedule.CatchUp != model.CatchUpAll {
+			count, last, after := missedRuns(spec, from, now)
+			skipped := count
+			if schedule.CatchUp == model.CatchUpLatest {
+				skipped--
+			}
+			if skipped > 0 {
+				runs = append(runs, &model.ScheduleRun{
+					ScheduledAt: from,
+					StartedAt:   now,
+					FinishedAt:  now,
+					Status:      model.RunSkipped,
+					Detail:      fmt.Sprintf("%d missed runs skipped", skipped),
+				})
+			}
+			runAt, next = last, after
+		}
+
+		execute := schedule.CatchUp != model.CatchUpSkip || now.Sub(from) <= svc.misfireThreshold
+		if execute {
+			runs = append(runs, &model.ScheduleRun{ScheduledAt: runAt, StartedAt: now, Status: model.RunPending})
+		}
+
+		added, err := svc.repo.AdvanceSchedule(ctx, schedule.Id, from, next, runs)
+		if err != nil {
+			if err != repository.ErrScheduleConflict {
+				log.Error("schedule is not advanced", log.F("schedule_id", schedule.Id), log.F(log.FieldError, err))
+			}
+			return false
+		}
+		schedule.NextRunAt = next
+
+		if execute {
+			svc.execute(ctx, schedule, added[len(added)-1])
+		}
+	}
+
+	return false
+}
+
+//execute выполняет операцию расписания и сохраняет результат запуска.
+func (svc *SchedulerSvc) execute(ctx context.Context, schedule *model.Schedule, run *model.ScheduleRun) {
+	ctx, span := tracer.Start(ctx, "SchedulerSvc.execute", trace.WithAttributes(
+		label.String("schedule.id", schedule.Id), label.Int32("balance.id", schedule.BalanceId)))
+	defer span.End()
+
+	var err error
+	if schedule.ToBalanceId != nil {
+		_, err = svc.accounts.Transfer(ctx, schedule.BalanceId, *schedule.ToBalanceId, schedule.Amount, schedule.Currency, "", "")
+	} else {
+		err = svc.accounts.AddAmount(ctx, schedule.BalanceId, schedule.Amount, schedule.Currency, "")
+	}
+
+	run.FinishedAt = time.Now().UTC()
+	run.Status = model.RunSucceeded
+	if err != nil {
+		run.Status, run.Detail = model.RunFailed, err.Error()
+		log.Warning("scheduled operation failed",
+			log.F("schedule_id", schedule.Id), log.F(log.FieldBalanceId, schedule.BalanceId), log.F(log.FieldError, err))
+	}
+
+	if err := svc.repo.FinishRun(ctx, run); err != nil {
+		log.Error("schedule run result is not saved",
+			log.F("schedule_id", schedule.Id), log.F("run_id", run.Id), log.F(log.FieldError, err))
+	}
+}
+
+//nextRun возвращает момент запуска после from. Для однократной операции (spec == nil) возвращается нулевое время.
+func nextRun(spec *cron.Schedule, from time.Time) time.Time {
+	if spec == nil {
+		return time.Time{}
+	}
+
+	return spec.Next(from.UTC())
+}
+
+//missedRuns возвращает количество запусков с момента from по now включительно, последний из них и момент
+//следующего запуска после now.
+func missedRuns(spec *cron.Schedule, from, now time.Time) (int, time.Time, time.Time) {
+	count, last := 1, from
+	next := nextRun(spec, from)
+	for !next.IsZero() && !next.After(now) {
+		count, last = count+1, next
+		next = nextRun(spec, next)
+	}
+
+	return count, last, next
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vps2/accounttesttask/internal/server/model"
+	"github.com/vps2/accounttesttask/internal/server/repository/inmem"
+	"github.com/vps2/accounttesttask/pkg/cache"
+
+	"gotest.tools/assert"
+)
+
+func TestSchedulerSvc_CreateSchedule(t *testing.T) {
+	to := int32(1)
+	negative := int32(-2)
+
+	tests := []struct {
+		name string
+		req  *CreateScheduleRequest
+		err  error
+	}{
+		{
+			name: "recurring credit",
+			req:  &CreateScheduleRequest{BalanceId: 1, Amount: 100, Cron: "0 9 1 * *"},
+		},
+		{
+			name: "one-time transfer",
+			req:  &CreateScheduleRequest{BalanceId: 2, ToBalanceId: &to, Amount: 100, StartAt: time.Now().Add(time.Hour)},
+		},
+		{
+			name: "one-time operation without start time",
+			req:  &CreateScheduleRequest{BalanceId: 1, Amount: 100},
+			err:  ErrMissingStartTime,
+		},
+		{
+			name: "invalid cron expression",
+			req:  &CreateScheduleRequest{BalanceId: 1, Amount: 100, Cron: "0 9 1 *"},
+			err:  ErrInvalidCron,
+		},
+		{
+			name: "cron expression without upcoming runs",
+			req:  &CreateScheduleRequest{BalanceId: 1, Amount: 100, Cron: "0 0 30 2 *"},
+			err:  ErrScheduleNeverRuns,
+		},
+		{
+			name: "unknown catch-up policy",
+			req:  &CreateScheduleRequest{BalanceId: 1, Amount: 100, Cron: "@daily", CatchUp: "never"},
+			err:  ErrUnknownCatchUpPolicy,
+		},
+		{
+			name: "zero amount",
+			req:  &CreateScheduleRequest{BalanceId: 1, Cron: "@daily"},
+			err:  ErrZeroAmount,
+		},
+		{
+			name: "negative transfer",
+			req:  &CreateScheduleRequest{BalanceId: 2, ToBalanceId: &to, Amount: -100, Cron: "@daily"},
+			err:  ErrNonPositiveTransfer,
+		},
+		{
+			name: "self transfer",
+			req:  &CreateScheduleRequest{BalanceId: 1, ToBalanceId: &to, Amount: 100, Cron: "@daily"},
+			err:  ErrSelfTransfer,
+		},
+		{
+			name: "negative target account",
+			req:  &CreateScheduleRequest{BalanceId: 1, ToBalanceId: &negative, Amount: 100, Cron: "@daily"},
+			err:  ErrInvalidBalanceId,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewSchedulerSvc(inmem.NewSchedulesRepo(), nil)
+
+			schedule, err := svc.CreateSchedule(context.Background(), tt.req)
+			if tt.err != nil {
+				assert.Assert(t, errors.Is(err, tt.err), "CreateSchedule() error = %v, expected %v", err, tt.err)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, model.ScheduleActive, schedule.Status)
+			assert.Equal(t, model.CatchUpLatest, schedule.CatchUp)
+			assert.Assert(t, schedule.NextRunAt.After(time.Now()))
+		})
+	}
+}
+
+func TestSchedulerSvc_RunDue(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule *model.Schedule
+		balance  int64
+		status   model.ScheduleStatus
+		next     time.Time
+		runs     []model.RunStatus
+	}{
+		{
+			name: "one-time credit",
+			schedule: &model.Schedule{
+				Id: "once", BalanceId: 1, Amount: 50, CatchUp: model.CatchUpLatest, NextRunAt: now.Add(-10 * time.Second)},
+			balance: 150,
+			status:  model.ScheduleCompleted,
+			runs:    []model.RunStatus{model.RunSucceeded},
+		},
+		{
+			name: "not due yet",
+			schedule: &model.Schedule{
+				Id: "future", BalanceId: 1, Amount: 50, CatchUp: model.CatchUpLatest, NextRunAt: now.Add(time.Second)},
+			balance: 100,
+			status:  model.ScheduleActive,
+			next:    now.Add(time.Second),
+		},
+		{
+			name: "failed debit",
+			schedule: &model.Schedule{
+				Id: "debit", BalanceId: 1, Amount: -500, Cron: "@hourly", CatchUp: model.CatchUpLatest, NextRunAt: now},
+			balance: 100,
+			status:  model.ScheduleActive,
+			next:    now.Add(30 * time.Minute),
+			runs:    []model.RunStatus{model.RunFailed},
+		},
+		{
+			//запуски в 10:00, 11:00 и 12:00 пропущены из-за простоя
+			name: "missed runs are skipped",
+			schedule: &model.Schedule{
+				Id: "skip", BalanceId: 1, Amount: 10, Cron: "@hourly", CatchUp: model.CatchUpSkip,
+				NextRunAt: now.Add(-150 * time.Minute)},
+			balance: 100,
+			status:  model.ScheduleActive,
+			next:    now.Add(30 * time.Minute),
+			runs:    []model.RunStatus{model.RunSkipped},
+		},
+		{
+			name: "the latest missed run is executed",
+			schedule: &model.Schedule{
+				Id: "latest", BalanceId: 1, Amount: 10, Cron: "@hourly", CatchUp: model.CatchUpLatest,
+				NextRunAt: now.Add(-150 * time.Minute)},
+			balance: 110,
+			status:  model.ScheduleActive,
+			next:    now.Add(30 * time.Minute),
+			runs:    []model.RunStatus{model.RunSucceeded, model.RunSkipped},
+		},
+		{
+			name: "all missed runs are executed",
+			schedule: &model.Schedule{
+				Id: "all", BalanceId: 1, Amount: 10, Cron: "@hourly", CatchUp: model.CatchUpAll,
+				NextRunAt: now.Add(-150 * time.Minute)},
+			balance: 130,
+			status:  model.ScheduleActive,
+			next:    now.Add(30 * time.Minute),
+			runs:    []model.RunStatus{model.RunSucceeded, model.RunSucceeded, model.RunSucceeded},
+		},
+		{
+			name: "missed one-time transfer is skipped",
+			schedule: &model.Schedule{
+				Id: "transfer", BalanceId: 1, ToBalanceId: int32Ptr(2), Amount: 10, CatchUp: model.CatchUpSkip,
+				NextRunAt: now.Add(-time.Hour)},
+			balance: 100,
+			status:  model.ScheduleCompleted,
+			runs:    []model.RunStatus{model.RunSkipped},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			accountsSvc := NewAccountsSvc(inmem.NewAccountsRepo(), cache.Nop{})
+			assert.NilError(t, accountsSvc.AddAmount(ctx, 1, 100, "", ""))
+			assert.NilError(t, accountsSvc.AddAmount(ctx, 2, 100, "", ""))
+
+			repo := inmem.NewSchedulesRepo()
+			tt.schedule.Status = model.ScheduleActive
+			_, err := repo.CreateSchedule(ctx, tt.schedule)
+			assert.NilError(t, err)
+
+			NewSchedulerSvc(repo, accountsSvc).runDue(ctx, now)
+
+			balance, err := accountsSvc.GetAmount(ctx, 1)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.balance, balance)
+
+			schedule, err := repo.GetSchedule(ctx, tt.schedule.Id)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.status, schedule.Status)
+			assert.Equal(t, tt.next, schedule.NextRunAt)
+
+			runs, err := repo.ListRuns(ctx, tt.schedule.Id, 10)
+			assert.NilError(t, err)
+			var statuses []model.RunStatus
+			for _, run := range runs {
+				statuses = append(statuses, run.Status)
+			}
+			assert.DeepEqual(t, tt.runs, statuses)
+		})
+	}
+}
+
+func TestSchedulerSvc_CancelSchedule(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSchedulerSvc(inmem.NewSchedulesRepo(), nil)
+
+	schedule, err := svc.CreateSchedule(ctx, &CreateScheduleRequest{BalanceId: 1, Amount: 100, Cron: "@daily"})
+	assert.NilError(t, err)
+
+	assert.NilError(t, svc.CancelSchedule(ctx, schedule.Id))
+	assert.Equal(t, ErrScheduleNotActive, svc.CancelSchedule(ctx, schedule.Id))
+
+	schedules, err := svc.ListSchedules(ctx, &schedule.BalanceId)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(schedules))
+	assert.Equal(t, model.ScheduleCancelled, schedules[0].Status)
+	assert.Assert(t, schedules[0].NextRunAt.IsZero())
+}
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
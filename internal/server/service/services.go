@@ -1,12 +1,43 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
 
+	"github.com/vps2/accounttesttask/internal/server/model"
+)
+
+//go:generate mockery --dir . --name AccountsService --filename accounts.go --output ./mocks
 type AccountsService interface {
 	GetAmount(ctx context.Context, id int32) (int64, error)
-	AddAmount(ctx context.Context, id int32, amount int64) error
+	AddAmount(ctx context.Context, id int32, amount int64, currency, key string) error
+	AddAmountIfVersion(ctx context.Context, id int32, amount int64, currency string, version int64, key string) error
+	SetAmountIfVersion(ctx context.Context, id int32, amount int64, currency string, version int64) error
+	GetBalance(ctx context.Context, id int32) (*model.Balance, error)
+	Authorize(ctx context.Context, id int32, amount int64, currency string, ttl time.Duration, key string) (string, error)
+	Capture(ctx context.Context, holdId string, amount int64) error
+	Void(ctx context.Context, holdId string) error
+	Transfer(ctx context.Context, fromId, toId int32, amount int64, currency, fxRate, key string) (int64, error)
+	ListAccounts(ctx context.Context, req *ListAccountsRequest) ([]*model.Account, string, error)
+	PostEntry(ctx context.Context, entry *model.LedgerEntry) error
+}
+
+//go:generate mockery --dir . --name AdminService --filename admin.go --output ./mocks
+type AdminService interface {
+	SetMinBalance(ctx context.Context, id int32, minBalance int64) error
+	SetStatus(ctx context.Context, id int32, status model.AccountStatus, reason string) error
+	SetMetadata(ctx context.Context, id int32, owner string, labels map[string]string) error
+}
+
+//go:generate mockery --dir . --name SchedulerService --filename scheduler.go --output ./mocks
+type SchedulerService interface {
+	CreateSchedule(ctx context.Context, req *CreateScheduleRequest) (*model.Schedule, error)
+	ListSchedules(ctx context.Context, balanceId *int32) ([]*model.Schedule, error)
+	CancelSchedule(ctx context.Context, id string) error
+	ListScheduleRuns(ctx context.Context, id string, limit int) ([]*model.ScheduleRun, error)
 }
 
+//go:generate mockery --dir . --name StatisticsService --filename statistics.go --output ./mocks
 type StatisticsService interface {
 	IncReadOperations()
 	IncWriteOperations()
@@ -15,4 +46,6 @@ type StatisticsService interface {
 	TotalWriteOperations() int64
 	ReadOperationsPerSecond() int64
 	WriteOperationsPerSecond() int64
+	ReportAccrual(progress AccrualProgress)
+	AccrualProgress() []AccrualProgress
 }
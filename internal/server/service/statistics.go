@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -14,6 +16,29 @@ type StatisticsSvc struct {
 	readOpsPerSec  int64
 	writeOps       int64
 	writeOpsPerSec int64
+
+	pollIntervalCh chan time.Duration
+	done           <-chan struct{}
+
+	mu sync.Mutex
+	//accruals - последний отчёт о ходе начисления по каждому правилу
+	accruals map[string]AccrualProgress
+}
+
+//AccrualProgress представляет ход начисления по правилу за период.
+type AccrualProgress struct {
+	Rule   string
+	Period string
+	//Processed - количество обработанных счетов
+	Processed int64
+	//Posted - количество выполненных проводок
+	Posted int64
+	//Skipped - количество счетов, для которых проводка не требуется, уже выполнена или отклонена из-за состояния счёта
+	Skipped int64
+	//Failed - количество счетов, проводка по которым не выполнена из-за временной ошибки. Период будет повторён.
+	Failed int64
+	//Done - обход счетов завершён
+	Done bool
 }
 
 func NewStatisticsSvc(ctx context.Context, pollInterval time.Duration) *StatisticsSvc {
@@ -22,6 +47,9 @@ func NewStatisticsSvc(ctx context.Context, pollInterval time.Duration) *Statisti
 		writeOps:       0,
 		readOpsPerSec:  0,
 		writeOpsPerSec: 0,
+		pollIntervalCh: make(chan time.Duration),
+		done:           ctx.Done(),
+		accruals:       make(map[string]AccrualProgress),
 	}
 
 	go func() {
@@ -49,11 +77,15 @@ func NewStatisticsSvc(ctx context.Context, pollInterval time.Duration) *Statisti
 				atomic.StoreInt64(&statistics.readOpsPerSec, readOpsPerSec)
 				atomic.StoreInt64(&statistics.writeOpsPerSec, writeOpsPerSec)
 
-				log.Infof("read operations per sec: %d, total read operations: %d, write operations per sec: %d, total write operations: %d\n",
-					readOpsPerSec,
-					totalReadOps,
-					writeOpsPerSec,
-					totalWriteOps)
+				log.Info("statistics",
+					log.F("read_ops_per_sec", readOpsPerSec),
+					log.F("total_read_ops", totalReadOps),
+					log.F("write_ops_per_sec", writeOpsPerSec),
+					log.F("total_write_ops", totalWriteOps))
+			case interval := <-statistics.pollIntervalCh:
+				ticker.Stop()
+				ticker = time.NewTicker(interval)
+				pollInterval = interval
 			case <-ctx.Done():
 				break loop
 			}
@@ -65,6 +97,14 @@ func NewStatisticsSvc(ctx context.Context, pollInterval time.Duration) *Statisti
 	return statistics
 }
 
+//SetPollInterval изменяет интервал сбора статистики. Текущий интервал сбора при этом начинается заново.
+func (svc *StatisticsSvc) SetPollInterval(interval time.Duration) {
+	select {
+	case svc.pollIntervalCh <- interval:
+	case <-svc.done:
+	}
+}
+
 func (svc *StatisticsSvc) IncReadOperations() {
 	atomic.AddInt64(&svc.readOps, 1)
 }
@@ -93,3 +133,38 @@ func (svc *StatisticsSvc) ReadOperationsPerSecond() int64 {
 func (svc *StatisticsSvc) WriteOperationsPerSecond() int64 {
 	return atomic.LoadInt64(&svc.writeOpsPerSec)
 }
+
+//ReportAccrual сохраняет ход начисления по правилу. Завершение обхода счетов записывается в журнал.
+func (svc *StatisticsSvc) ReportAccrual(progress AccrualProgress) {
+	svc.mu.Lock()
+	svc.accruals[progress.Rule] = progress
+	svc.mu.Unlock()
+
+	fields := []log.Field{
+		log.F("rule", progress.Rule),
+		log.F("period", progress.Period),
+		log.F("processed", progress.Processed),
+		log.F("posted", progress.Posted),
+		log.F("skipped", progress.Skipped),
+		log.F("failed", progress.Failed),
+	}
+	if progress.Done {
+		log.Info("accrual completed", fields...)
+	} else {
+		log.Debug("accrual progress", fields...)
+	}
+}
+
+//AccrualProgress возвращает последние отчёты о ходе начисления, упорядоченные по имени правила.
+func (svc *StatisticsSvc) AccrualProgress() []AccrualProgress {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	res := make([]AccrualProgress, 0, len(svc.accruals))
+	for _, progress := range svc.accruals {
+		res = append(res, progress)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Rule < res[j].Rule })
+
+	return res
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"math"
+
+	"github.com/vps2/accounttesttask/pkg/currency"
+)
+
+//validateId проверяет идентификатор счёта из запроса.
+func validateId(id int32) error {
+	if id < 0 {
+		return ErrInvalidBalanceId
+	}
+
+	return nil
+}
+
+//validateAddAmount проверяет запрос изменения баланса счёта id на amount.
+func (svc *AccountsSvc) validateAddAmount(id int32, amount int64) error {
+	if err := validateId(id); err != nil {
+		return err
+	}
+	if amount == 0 {
+		return ErrZeroAmount
+	}
+
+	return svc.checkOperationAmount(amount)
+}
+
+//checkOperationAmount проверяет, что сумма операции по модулю не превышает максимальную.
+func (svc *AccountsSvc) checkOperationAmount(amount int64) error {
+	if amount == math.MinInt64 {
+		return ErrAmountOutOfRange
+	}
+	if amount < 0 {
+		amount = -amount
+	}
+	if svc.maxOperationAmount > 0 && amount > svc.maxOperationAmount {
+		return ErrAmountTooLarge
+	}
+
+	return nil
+}
+
+//checkBalance проверяет, что баланс, увеличенный с prev до balance, не превышает максимальный. Уменьшение баланса
+//допускается всегда, даже если после снижения ограничения он остаётся больше максимального.
+func (svc *AccountsSvc) checkBalance(prev, balance int64) error {
+	if svc.maxBalance > 0 && balance > prev && balance > svc.maxBalance {
+		return ErrBalanceLimitExceeded
+	}
+
+	return nil
+}
+
+//add и sub - сложение и вычитание сумм с проверкой переполнения
+func add(a, b int64) (int64, error) {
+	sum, err := currency.Add(a, b)
+	if err != nil {
+		return 0, ErrAmountOutOfRange
+	}
+
+	return sum, nil
+}
+
+func sub(a, b int64) (int64, error) {
+	diff, err := currency.Sub(a, b)
+	if err != nil {
+		return 0, ErrAmountOutOfRange
+	}
+
+	return diff, nil
+}
@@ -54,7 +54,7 @@ func (c *Cache) Set(key, value interface{}) bool {
 		return true
 	}
 
-	if c.queue.Len() == c.capacity {
+	if c.queue.Len() >= c.capacity {
 		c.purge()
 	}
 
@@ -68,6 +68,25 @@ func (c *Cache) Set(key, value interface{}) bool {
 	return true
 }
 
+//Resize изменяет ёмкость кэша. Если элементов больше новой ёмкости, то удаляются давно не используемые элементы.
+func (c *Cache) Resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = capacity
+	for c.queue.Len() > c.capacity {
+		c.purge()
+	}
+}
+
+//Capacity возвращает ёмкость кэша.
+func (c *Cache) Capacity() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.capacity
+}
+
 func (c *Cache) purge() {
 	if lastElem := c.queue.Back(); lastElem != nil {
 		c.queue.Remove(lastElem)
@@ -160,3 +160,56 @@ func fillCache(cache *Cache) {
 	cache.htable[2] = cache.queue.PushBack(&entry{key: 2, value: 20})
 	cache.htable[3] = cache.queue.PushBack(&entry{key: 3, value: 30})
 }
+
+func TestCache_Resize(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		want     []entry
+	}{
+		{
+			name:     "grow",
+			capacity: 5,
+			want:     []entry{{key: 1, value: 10}, {key: 2, value: 20}, {key: 3, value: 30}},
+		},
+		{
+			name:     "shrink",
+			capacity: 2,
+			want:     []entry{{key: 1, value: 10}, {key: 2, value: 20}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(3)
+			fillCache(c)
+
+			c.Resize(tt.capacity)
+
+			if got := c.Capacity(); got != tt.capacity {
+				t.Errorf("Cache.Capacity() = %d, want %d", got, tt.capacity)
+			}
+			if size := c.queue.Len(); size != len(tt.want) {
+				t.Fatalf("Cache.Resize(): wrong size of the cache. Want %d, got %d", len(tt.want), size)
+			}
+
+			cacheElem := c.queue.Front()
+			for i := 0; i < c.queue.Len(); i++ {
+				if !reflect.DeepEqual(tt.want[i], *cacheElem.Value.(*entry)) {
+					t.Errorf("Cache.Resize(): wrong cache content in pos %d. Want %v, get %v",
+						i,
+						tt.want[i],
+						*cacheElem.Value.(*entry))
+				}
+				if _, ok := c.htable[tt.want[i].key]; !ok {
+					t.Errorf("Cache.Resize(): key %v not in the hash table", tt.want[i].key)
+				}
+
+				cacheElem = cacheElem.Next()
+			}
+
+			if len(c.htable) != len(tt.want) {
+				t.Errorf("Cache.Resize(): wrong size of the hash table. Want %d, got %d", len(tt.want), len(c.htable))
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package cache
+
+//Nop - это кэш, который ничего не хранит. Используется, когда кэширование отключено.
+type Nop struct{}
+
+func (Nop) Get(key interface{}) (interface{}, bool) {
+	return nil, false
+}
+
+func (Nop) Set(key, value interface{}) bool {
+	return false
+}
@@ -0,0 +1,212 @@
+//Пакет cron разбирает расписания в формате cron и вычисляет моменты их срабатывания.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid cron expression")
+
+//maxSearchYears - на сколько лет вперёд ищется момент срабатывания. Расписание вроде "0 0 30 2 *" не срабатывает
+//никогда.
+const maxSearchYears = 5
+
+//field описывает поле расписания и допустимые значения
+type field struct {
+	name     string
+	min, max int
+	//names - наименования значений, начиная с min
+	names []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	//воскресенье можно указать как 0 или 7
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//Schedule - разобранное расписание. Каждое поле - битовая маска допустимых значений.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	//domAny и dowAny - поля дня месяца и дня недели не ограничены ('*')
+	domAny, dowAny bool
+}
+
+//Parse разбирает расписание из пяти полей: минута, час, день месяца, месяц, день недели. Поле может содержать '*',
+//значение, диапазон a-b и их перечисление через запятую, за каждым из которых может следовать шаг /n. Месяцы и дни
+//недели можно задавать трёхбуквенными английскими наименованиями. Поддерживаются также @yearly, @monthly, @weekly,
+//@daily и @hourly. Если ограничены и день месяца, и день недели, то достаточно совпадения одного из них.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w %q: expected 5 fields, got %d", ErrInvalidSpec, spec, len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, _, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, _, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, s.domAny, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, _, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, s.dowAny, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+//Next возвращает первый момент срабатывания расписания строго после t с точностью до минуты в часовом поясе t.
+//Если в ближайшие годы расписание не срабатывает, то возвращается нулевое время.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxSearchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+
+	return dom || dow
+}
+
+//parseField разбирает поле расписания и возвращает маску допустимых значений и признак того, что поле не
+//ограничено.
+func parseField(s string, f field) (uint64, bool, error) {
+	var mask uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("%w: invalid step in the %s field %q", ErrInvalidSpec, f.name, s)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+			if s == "*" {
+				return bits(lo, hi, 1), true, nil
+			}
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, false, fmt.Errorf("%w: %s in %q", ErrInvalidSpec, err, s)
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, false, fmt.Errorf("%w: %s in %q", ErrInvalidSpec, err, s)
+			}
+			if lo > hi {
+				return 0, false, fmt.Errorf("%w: empty range in the %s field %q", ErrInvalidSpec, f.name, s)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rng); err != nil {
+				return 0, false, fmt.Errorf("%w: %s in %q", ErrInvalidSpec, err, s)
+			}
+			//a/n означает значения от a до максимального с шагом n
+			if step == 1 {
+				hi = lo
+			}
+		}
+
+		mask |= bits(lo, hi, step)
+	}
+
+	return mask, false, nil
+}
+
+//value разбирает значение поля: число или наименование.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("the %s must be between %d and %d, got %q", f.name, f.min, f.max, s)
+	}
+
+	return n, nil
+}
+
+func bits(lo, hi, step int) uint64 {
+	var mask uint64
+	for i := lo; i <= hi; i += step {
+		mask |= 1 << uint(i)
+	}
+
+	return mask
+}
@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{spec: "* * * * *", from: "2021-03-01T10:00:30Z", want: "2021-03-01T10:01:00Z"},
+		{spec: "*/15 * * * *", from: "2021-03-01T10:00:00Z", want: "2021-03-01T10:15:00Z"},
+		{spec: "30 9 * * mon-fri", from: "2021-03-05T09:30:00Z", want: "2021-03-08T09:30:00Z"},
+		{spec: "0 0 1 * *", from: "2021-01-31T12:00:00Z", want: "2021-02-01T00:00:00Z"},
+		{spec: "@monthly", from: "2021-12-15T00:00:00Z", want: "2022-01-01T00:00:00Z"},
+		{spec: "0 12 29 feb *", from: "2021-01-01T00:00:00Z", want: "2024-02-29T12:00:00Z"},
+		{spec: "0 0 13 * 5", from: "2021-03-01T00:00:00Z", want: "2021-03-05T00:00:00Z"},
+		{spec: "0 0 * * 7", from: "2021-03-01T00:00:00Z", want: "2021-03-07T00:00:00Z"},
+		{spec: "5,10-12/2 * * * *", from: "2021-03-01T10:06:00Z", want: "2021-03-01T10:10:00Z"},
+		{spec: "0 0 30 2 *", from: "2021-03-01T00:00:00Z", want: "0001-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			assert.NilError(t, err)
+
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			want, _ := time.Parse(time.RFC3339, tt.want)
+			assert.Equal(t, want.UTC(), s.Next(from).UTC())
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * foo *", "5-1 * * * *",
+		"*/0 * * * *", "* * * * * *"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := Parse(spec)
+			assert.Assert(t, errors.Is(err, ErrInvalidSpec), "Parse(%q) error = %v", spec, err)
+		})
+	}
+}
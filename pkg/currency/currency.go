@@ -0,0 +1,111 @@
+//Пакет currency содержит коды валют ISO 4217 с количеством знаков дробной (минорной) единицы и операции над
+//суммами, выраженными в минорных единицах.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//Default - валюта счетов, созданных до поддержки нескольких валют, если в настройках не задана другая
+const Default = "USD"
+
+//Ошибки, которые могут возвратить функции пакета
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidRate     = errors.New("the exchange rate must be a positive decimal number")
+	ErrOverflow        = errors.New("the amount is out of range")
+)
+
+//Currency - валюта ISO 4217
+type Currency struct {
+	Code string
+	//MinorUnits - количество знаков дробной единицы: 2 для USD (центы), 0 для JPY, 3 для KWD
+	MinorUnits int
+}
+
+//minorUnits содержит количество знаков дробной единицы действующих валют ISO 4217
+var minorUnits = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2, "AZN": 2,
+	"BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2, "BND": 2, "BOB": 2, "BRL": 2,
+	"BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2, "CDF": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2,
+	"GNF": 0, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2, "KHR": 2, "KMF": 0,
+	"KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2,
+	"MVR": 2, "MWK": 2, "MXN": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2,
+	"NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "PYG": 0, "QAR": 2,
+	"RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SYP": 2, "SZL": 2, "THB": 2, "TJS": 2,
+	"TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "UGX": 0, "USD": 2,
+	"UYU": 2, "UZS": 2, "VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XOF": 0, "XPF": 0,
+	"YER": 2, "ZAR": 2, "ZMW": 2, "ZWL": 2,
+}
+
+//Lookup возвращает валюту по её коду. Регистр букв кода не учитывается.
+func Lookup(code string) (Currency, error) {
+	code = strings.ToUpper(code)
+	if units, ok := minorUnits[code]; ok {
+		return Currency{Code: code, MinorUnits: units}, nil
+	}
+
+	return Currency{}, fmt.Errorf("%w %q", ErrUnknownCurrency, code)
+}
+
+//Format возвращает сумму amount, выраженную в минорных единицах, в виде десятичного числа с кодом валюты,
+//например "-12.30 USD".
+func (c Currency) Format(amount int64) string {
+	s := new(big.Rat).SetFrac(big.NewInt(amount), pow10(c.MinorUnits)).FloatString(c.MinorUnits)
+
+	return s + " " + c.Code
+}
+
+//Convert переводит сумму amount в минорных единицах валюты from в минорные единицы валюты to по курсу rate.
+//Курс задаётся десятичной строкой и означает количество единиц валюты to за одну единицу валюты from. Результат
+//округляется в сторону нуля до минорной единицы валюты to.
+func Convert(amount int64, from, to Currency, rate string) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return 0, ErrInvalidRate
+	}
+
+	//amount / 10^from * rate * 10^to
+	res := new(big.Rat).SetInt64(amount)
+	res.Mul(res, r)
+	res.Mul(res, new(big.Rat).SetFrac(pow10(to.MinorUnits), pow10(from.MinorUnits)))
+
+	n := new(big.Int).Quo(res.Num(), res.Denom())
+	if !n.IsInt64() {
+		return 0, ErrOverflow
+	}
+
+	return n.Int64(), nil
+}
+
+//Add возвращает сумму a и b или ErrOverflow, если она не помещается в int64.
+func Add(a, b int64) (int64, error) {
+	sum := a + b
+	if b > 0 && sum < a || b < 0 && sum > a {
+		return 0, ErrOverflow
+	}
+
+	return sum, nil
+}
+
+//Sub возвращает разность a и b или ErrOverflow, если она не помещается в int64.
+func Sub(a, b int64) (int64, error) {
+	diff := a - b
+	if b > 0 && diff > a || b < 0 && diff < a {
+		return 0, ErrOverflow
+	}
+
+	return diff, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
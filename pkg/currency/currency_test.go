@@ -0,0 +1,100 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCurrency_Format(t *testing.T) {
+	tests := []struct {
+		code   string
+		amount int64
+		want   string
+	}{
+		{"USD", 1230, "12.30 USD"},
+		{"USD", -5, "-0.05 USD"},
+		{"JPY", 1230, "1230 JPY"},
+		{"KWD", 1230, "1.230 KWD"},
+	}
+	for _, tt := range tests {
+		c, err := Lookup(tt.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := c.Format(tt.amount); got != tt.want {
+			t.Errorf("Format(%d) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if c, err := Lookup("eur"); err != nil || c != (Currency{Code: "EUR", MinorUnits: 2}) {
+		t.Errorf("Lookup(eur) = %v, %v", c, err)
+	}
+	if _, err := Lookup("XXX1"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Lookup(XXX1) error = %v, want %v", err, ErrUnknownCurrency)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	usd, _ := Lookup("USD")
+	jpy, _ := Lookup("JPY")
+	kwd, _ := Lookup("KWD")
+
+	tests := []struct {
+		name     string
+		amount   int64
+		from, to Currency
+		rate     string
+		want     int64
+		err      error
+	}{
+		{name: "usd to jpy", amount: 1050, from: usd, to: jpy, rate: "150.25", want: 1577},
+		{name: "jpy to usd", amount: 1000, from: jpy, to: usd, rate: "0.0067", want: 670},
+		{name: "usd to kwd rounds toward zero", amount: 999, from: usd, to: kwd, rate: "0.30749", want: 3071},
+		{name: "negative amount rounds toward zero", amount: -999, from: usd, to: kwd, rate: "0.30749", want: -3071},
+		{name: "zero rate", amount: 100, from: usd, to: jpy, rate: "0", err: ErrInvalidRate},
+		{name: "malformed rate", amount: 100, from: usd, to: jpy, rate: "1,5", err: ErrInvalidRate},
+		{name: "overflow", amount: 1 << 62, from: usd, to: jpy, rate: "1000", err: ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.amount, tt.from, tt.to, tt.rate)
+			if err != tt.err {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	tests := []struct {
+		a, b    int64
+		sum     int64
+		sumErr  error
+		diff    int64
+		diffErr error
+	}{
+		{a: 300, b: -500, sum: -200, diff: 800},
+		{a: math.MaxInt64, b: 1, sumErr: ErrOverflow, diff: math.MaxInt64 - 1},
+		{a: math.MinInt64, b: 1, sum: math.MinInt64 + 1, diffErr: ErrOverflow},
+		{a: -1, b: math.MinInt64, sumErr: ErrOverflow, diff: math.MaxInt64},
+		{a: 0, b: math.MinInt64, sum: math.MinInt64, diffErr: ErrOverflow},
+	}
+	for _, tt := range tests {
+		sum, err := Add(tt.a, tt.b)
+		if sum != tt.sum || err != tt.sumErr {
+			t.Errorf("Add(%d, %d) = %d, %v, want %d, %v", tt.a, tt.b, sum, err, tt.sum, tt.sumErr)
+		}
+
+		diff, err := Sub(tt.a, tt.b)
+		if diff != tt.diff || err != tt.diffErr {
+			t.Errorf("Sub(%d, %d) = %d, %v, want %d, %v", tt.a, tt.b, diff, err, tt.diff, tt.diffErr)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+//Пакет labels содержит селекторы меток - условия отбора объектов по их меткам вида ключ=значение.
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Operator - операция сравнения метки
+type Operator string
+
+const (
+	//Equals - метка есть и её значение равно заданному
+	Equals Operator = "="
+	//NotEquals - метки нет или её значение отличается от заданного
+	NotEquals Operator = "!="
+	//Exists - метка есть
+	Exists Operator = "exists"
+	//NotExists - метки нет
+	NotExists Operator = "!"
+)
+
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+var (
+	keyRe   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_./]{0,61}[A-Za-z0-9])?$`)
+	valueRe = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+)
+
+//Requirement - условие на одну метку
+type Requirement struct {
+	Key      string
+	Operator Operator
+	//Value - значение для операций Equals и NotEquals
+	Value string
+}
+
+//Selector - набор условий, которые должны выполняться одновременно. Пустой селектор соответствует любым меткам.
+type Selector []Requirement
+
+//Parse разбирает селектор в виде перечисленных через запятую условий: key=value, key!=value, key (метка есть)
+//и !key (метки нет). Условия упорядочиваются по ключу.
+func Parse(s string) (Selector, error) {
+	var selector Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			r = Requirement{Key: strings.TrimSpace(kv[0]), Operator: NotEquals, Value: strings.TrimSpace(kv[1])}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			r = Requirement{Key: strings.TrimSpace(kv[0]), Operator: Equals, Value: strings.TrimSpace(kv[1])}
+		case strings.HasPrefix(part, "!"):
+			r = Requirement{Key: strings.TrimSpace(part[1:]), Operator: NotExists}
+		default:
+			r = Requirement{Key: part, Operator: Exists}
+		}
+
+		if !keyRe.MatchString(r.Key) {
+			return nil, fmt.Errorf("%w: invalid key in %q", ErrInvalidSelector, part)
+		}
+		if !valueRe.MatchString(r.Value) {
+			return nil, fmt.Errorf("%w: invalid value in %q", ErrInvalidSelector, part)
+		}
+
+		selector = append(selector, r)
+	}
+
+	sort.SliceStable(selector, func(i, j int) bool {
+		return selector[i].Key < selector[j].Key
+	})
+
+	return selector, nil
+}
+
+//Validate проверяет ключи и значения меток.
+func Validate(labels map[string]string) error {
+	for k, v := range labels {
+		if !keyRe.MatchString(k) {
+			return fmt.Errorf("invalid label key %q", k)
+		}
+		if !valueRe.MatchString(v) {
+			return fmt.Errorf("invalid value %q of label %q", v, k)
+		}
+	}
+
+	return nil
+}
+
+//Matches проверяет, что метки labels удовлетворяют всем условиям селектора.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		v, ok := labels[r.Key]
+		switch r.Operator {
+		case Equals:
+			if !ok || v != r.Value {
+				return false
+			}
+		case NotEquals:
+			if ok && v == r.Value {
+				return false
+			}
+		case Exists:
+			if !ok {
+				return false
+			}
+		case NotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+//String возвращает селектор в том же виде, в котором его принимает Parse.
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		switch r.Operator {
+		case Equals, NotEquals:
+			parts = append(parts, r.Key+string(r.Operator)+r.Value)
+		case Exists:
+			parts = append(parts, r.Key)
+		case NotExists:
+			parts = append(parts, "!"+r.Key)
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,72 @@
+package labels
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Selector
+		err   bool
+	}{
+		{input: "", want: nil},
+		{
+			input: "tier!=web, env=prod,team,!legacy",
+			want: Selector{
+				{Key: "env", Operator: Equals, Value: "prod"},
+				{Key: "legacy", Operator: NotExists},
+				{Key: "team", Operator: Exists},
+				{Key: "tier", Operator: NotEquals, Value: "web"},
+			},
+		},
+		{input: "env=", want: Selector{{Key: "env", Operator: Equals}}},
+		{input: "=prod", err: true},
+		{input: "env=pr od", err: true},
+		{input: "!", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.err {
+				assert.Assert(t, errors.Is(err, ErrInvalidSelector), "Parse(%q) error = %v", tt.input, err)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.want, got)
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "payments"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env=dev", false},
+		{"env!=dev,team", true},
+		{"team!=payments", false},
+		{"!legacy", true},
+		{"!env", false},
+		{"legacy", false},
+		{"legacy!=x", true},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.selector)
+		assert.NilError(t, err)
+
+		if got := s.Matches(labels); got != tt.want {
+			t.Errorf("Parse(%q).Matches() = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
@@ -1,62 +1,152 @@
-//Простая обёртка для стандартного логера, добавляющая в вывод теги [INFO], [WARNING],
-//[ERROR], [FATAL] в зависимости от наименования метода выводаю
-// Сделано для упрощения поиска по файлу.
+//Логер с уровнями важности и именованными полями. В текстовом формате к сообщениям добавляются теги [DEBUG],
+//[INFO], [WARNING], [ERROR], [FATAL] в зависимости от уровня. Сделано для упрощения поиска по файлу.
+//Функции пакета выводят сообщения через стандартный логер.
 package log
+
 import (
+	"fmt"
 	"io"
-	"log"
+	"os"
 	"strings"
 )
 
 const (
 	spaceTag   = " "
+	debugTag   = "[DEBUG]"
 	infoTag    = "[INFO]"
 	warningTag = "[WARNING]"
 	errorTag   = "[ERROR]"
 	fatalTag   = "[FATAL]"
 )
 
-func Info(msg string) {
-	log.Printf("%s %s%s", infoTag, msg, LineBreak)
+//Level - уровень важности сообщения. Сообщения с уровнем ниже установленного не выводятся.
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	}
+
+	return "unknown"
+}
+
+func (l Level) tag() string {
+	switch l {
+	case LevelDebug:
+		return debugTag
+	case LevelInfo:
+		return infoTag
+	case LevelWarning:
+		return warningTag
+	case LevelError:
+		return errorTag
+	}
+
+	return fatalTag
+}
+
+//ParseLevel возвращает уровень по его наименованию.
+func ParseLevel(s string) (Level, error) {
+	for l := LevelDebug; l <= LevelFatal; l++ {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+var std = New(os.Stderr)
+
+//Std возвращает стандартный логер.
+func Std() *Logger {
+	return std
+}
+
+//With возвращает логер, добавляющий к каждому сообщению указанные поля.
+func With(fields ...Field) *Logger {
+	return std.With(fields...)
 }
 
-func Warning(msg string) {
-	log.Printf("%s %s%s", warningTag, msg, LineBreak)
+//SetLevel устанавливает минимальный уровень выводимых сообщений. Может вызываться из разных горутин.
+func SetLevel(l Level) {
+	std.SetLevel(l)
 }
 
-func Error(msg string) {
-	log.Printf("%s %s%s", errorTag, msg, LineBreak)
+func GetLevel() Level {
+	return std.Level()
 }
 
-func Fatal(msg string) {
-	log.Fatalf("%s, %s%s", fatalTag, msg, LineBreak)
+//SetFormat устанавливает формат вывода сообщений. Может вызываться из разных горутин.
+func SetFormat(f Format) {
+	std.SetFormat(f)
+}
+
+func Debug(msg string, fields ...Field) {
+	std.log(LevelDebug, msg, fields)
+}
+
+func Info(msg string, fields ...Field) {
+	std.log(LevelInfo, msg, fields)
+}
+
+func Warning(msg string, fields ...Field) {
+	std.log(LevelWarning, msg, fields)
+}
+
+func Error(msg string, fields ...Field) {
+	std.log(LevelError, msg, fields)
+}
+
+func Fatal(msg string, fields ...Field) {
+	std.Fatal(msg, fields...)
+}
+
+func Debugf(format string, v ...interface{}) {
+	if std.Enabled(LevelDebug) {
+		std.log(LevelDebug, fmt.Sprintf(format, v...), nil)
+	}
 }
 
 func Infof(format string, v ...interface{}) {
-	log.Printf(concat(infoTag, spaceTag, format), v...)
+	if std.Enabled(LevelInfo) {
+		std.log(LevelInfo, fmt.Sprintf(format, v...), nil)
+	}
 }
 
 func Warningf(format string, v ...interface{}) {
-	log.Printf(concat(warningTag, spaceTag, format), v...)
+	if std.Enabled(LevelWarning) {
+		std.log(LevelWarning, fmt.Sprintf(format, v...), nil)
+	}
 }
 
 func Errorf(format string, v ...interface{}) {
-	log.Printf(concat(errorTag, spaceTag, format), v...)
+	if std.Enabled(LevelError) {
+		std.log(LevelError, fmt.Sprintf(format, v...), nil)
+	}
 }
 
 func Fatalf(format string, v ...interface{}) {
-	log.Fatalf(concat(fatalTag, spaceTag, format), v...)
+	std.Fatal(fmt.Sprintf(format, v...))
 }
 
 func SetOutput(w io.Writer) {
-	log.SetOutput(w)
-}
-
-func concat(v ...string) string {
-	builder := strings.Builder{}
-	for _, val := range v {
-		builder.WriteString(val)
-	}
-
-	return builder.String()
+	std.SetOutput(w)
 }
@@ -0,0 +1,281 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Наименования часто используемых полей
+const (
+	FieldRequestId = "request_id"
+	FieldMethod    = "method"
+	FieldBalanceId = "balance_id"
+	FieldLatency   = "latency"
+	FieldError     = "error"
+)
+
+//Format - формат вывода сообщений
+type Format int32
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "json"
+	}
+
+	return "unknown"
+}
+
+//ParseFormat возвращает формат вывода по его наименованию.
+func ParseFormat(s string) (Format, error) {
+	for f := FormatText; f <= FormatJSON; f++ {
+		if strings.EqualFold(s, f.String()) {
+			return f, nil
+		}
+	}
+
+	return FormatText, fmt.Errorf("unknown log format %q", s)
+}
+
+//Field - именованное значение, добавляемое к сообщению
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//F создаёт поле сообщения.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+//core содержит общие для логера и всех порождённых им логеров настройки.
+type core struct {
+	mu     sync.Mutex
+	out    io.Writer
+	level  int32
+	format int32
+}
+
+//Logger выводит сообщения с уровнем важности и набором полей в текстовом формате или в формате JSON.
+//Методы типа могут вызываться из разных горутин.
+type Logger struct {
+	core   *core
+	fields []Field
+}
+
+//New создаёт логер, выводящий сообщения с уровнем не ниже LevelInfo в текстовом формате.
+func New(out io.Writer) *Logger {
+	return &Logger{
+		core: &core{
+			out:   out,
+			level: int32(LevelInfo),
+		},
+	}
+}
+
+//With возвращает логер, добавляющий к каждому сообщению указанные поля. Настройки уровня, формата и вывода
+//у порождённого логера общие с родительским.
+func (l *Logger) With(fields ...Field) *Logger {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+
+	return &Logger{
+		core:   l.core,
+		fields: all,
+	}
+}
+
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.core.level, int32(level))
+}
+
+func (l *Logger) Level() Level {
+	return Level(atomic.LoadInt32(&l.core.level))
+}
+
+func (l *Logger) SetFormat(format Format) {
+	atomic.StoreInt32(&l.core.format, int32(format))
+}
+
+func (l *Logger) Format() Format {
+	return Format(atomic.LoadInt32(&l.core.format))
+}
+
+func (l *Logger) SetOutput(w io.Writer) {
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+
+	l.core.out = w
+}
+
+//Enabled сообщает, будут ли выводиться сообщения с уровнем level.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.log(LevelDebug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warning(msg string, fields ...Field) {
+	l.log(LevelWarning, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.log(LevelError, msg, fields)
+}
+
+//Fatal выводит сообщение и завершает программу.
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.log(LevelFatal, msg, fields)
+	os.Exit(1)
+}
+
+func (l *Logger) log(level Level, msg string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	now := time.Now()
+	msg = strings.TrimRight(msg, "\r\n")
+
+	all := fields
+	if len(l.fields) > 0 {
+		all = make([]Field, 0, len(l.fields)+len(fields))
+		all = append(all, l.fields...)
+		all = append(all, fields...)
+	}
+
+	var line []byte
+	if l.Format() == FormatJSON {
+		line = formatJSON(now, level, msg, all)
+	} else {
+		line = formatText(now, level, msg, all)
+	}
+
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+
+	l.core.out.Write(line)
+}
+
+func formatText(t time.Time, level Level, msg string, fields []Field) []byte {
+	builder := strings.Builder{}
+	builder.WriteString(t.Format("2006/01/02 15:04:05"))
+	builder.WriteString(spaceTag)
+	builder.WriteString(level.tag())
+	builder.WriteString(spaceTag)
+	builder.WriteString(msg)
+
+	for _, f := range fields {
+		val := fmt.Sprint(textValue(f.Value))
+		if val == "" || strings.ContainsAny(val, " \t\r\n=\"") {
+			val = strconv.Quote(val)
+		}
+
+		builder.WriteString(spaceTag)
+		builder.WriteString(f.Key)
+		builder.WriteString("=")
+		builder.WriteString(val)
+	}
+
+	builder.WriteString(LineBreak)
+
+	return []byte(builder.String())
+}
+
+func formatJSON(t time.Time, level Level, msg string, fields []Field) []byte {
+	m := make(map[string]interface{}, len(fields)+3)
+	for _, f := range fields {
+		m[f.Key] = jsonValue(f.Value)
+	}
+	m["time"] = t.Format(time.RFC3339Nano)
+	m["level"] = level.String()
+	m["msg"] = msg
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	builder := strings.Builder{}
+	builder.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(m[k])
+		if err != nil {
+			val, _ = json.Marshal(fmt.Sprint(m[k]))
+		}
+
+		builder.Write(key)
+		builder.WriteString(":")
+		builder.Write(val)
+	}
+	builder.WriteString("}")
+	builder.WriteString(LineBreak)
+
+	return []byte(builder.String())
+}
+
+func textValue(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+
+	return v
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case time.Duration:
+		return val.String()
+	case fmt.Stringer:
+		return val.String()
+	}
+
+	return v
+}
+
+type contextKey struct{}
+
+//NewContext возвращает копию ctx, содержащую логер l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+//FromContext возвращает логер, сохранённый в ctx функцией NewContext, или стандартный логер, если его там нет.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+
+	return std
+}
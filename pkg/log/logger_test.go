@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLogger_Format(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		level  Level
+		log    func(l *Logger)
+		want   string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			level:  LevelInfo,
+			log: func(l *Logger) {
+				l.With(F(FieldRequestId, "abc")).Info("request done\n", F(FieldBalanceId, 1), F(FieldError, errors.New("some error")))
+			},
+			want: `^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[INFO\] request done request_id=abc balance_id=1 error="some error"` + LineBreak + `$`,
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			level:  LevelInfo,
+			log: func(l *Logger) {
+				l.Warning("slow call", F(FieldMethod, "GetAmount"), F(FieldLatency, 1500*time.Millisecond))
+			},
+			want: `^\{"latency":"1.5s","level":"warning","method":"GetAmount","msg":"slow call","time":"[^"]+"\}` + LineBreak + `$`,
+		},
+		{
+			name:   "filtered by level",
+			format: FormatText,
+			level:  LevelWarning,
+			log: func(l *Logger) {
+				l.Info("hidden")
+				l.Debug("hidden")
+			},
+			want: `^$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := New(buf)
+			l.SetFormat(tt.format)
+			l.SetLevel(tt.level)
+
+			tt.log(l)
+
+			if !regexp.MustCompile(tt.want).MatchString(buf.String()) {
+				t.Errorf("Logger output = %q, want match %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for l := LevelDebug; l <= LevelFatal; l++ {
+		got, err := ParseLevel(l.String())
+		if err != nil || got != l {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", l.String(), got, err, l)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("ParseLevel(%q): expected error", "verbose")
+	}
+}
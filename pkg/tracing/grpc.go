@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const instrumentationName = "github.com/vps2/accounttesttask/pkg/tracing"
+
+//metadataCarrier позволяет передавать контекст трассировки W3C в метаданных gRPC запроса.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+//Extract возвращает контекст, содержащий контекст трассировки W3C, полученный из метаданных входящего gRPC запроса.
+func Extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return propagation.TraceContext{}.Extract(ctx, metadataCarrier(md))
+}
+
+//Inject добавляет контекст трассировки W3C текущего span'а в метаданные исходящего gRPC запроса.
+func Inject(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+
+	propagation.TraceContext{}.Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+//UnaryClientInterceptor создаёт span для каждого исходящего вызова и передаёт контекст трассировки серверу.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := otel.Tracer(instrumentationName).Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(label.String("rpc.system", "grpc"), label.String("rpc.method", method)),
+		)
+		defer span.End()
+
+		err := invoker(Inject(ctx), method, req, reply, cc, opts...)
+		SetStatus(span, err)
+
+		return err
+	}
+}
+
+//SetStatus устанавливает состояние span'а в соответствии с ошибкой gRPC вызова.
+func SetStatus(span trace.Span, err error) {
+	st, _ := status.FromError(err)
+	span.SetAttributes(label.Uint32("rpc.grpc.status_code", uint32(st.Code())))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, st.Message())
+	}
+}
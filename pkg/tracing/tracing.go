@@ -0,0 +1,113 @@
+//Пакет tracing настраивает трассировку OpenTelemetry и передаёт контекст трассировки W3C в метаданных gRPC запросов.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlphttp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	export "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+//Типы экспортёров
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+//Setup создаёт поставщика трассировщиков с экспортёром указанного типа и устанавливает его глобальным. Для типа file
+//target - это путь к файлу, для типа otlp - адрес коллектора OTLP/HTTP (например, http://localhost:55681). Возвращает
+//функцию, которую нужно вызвать перед завершением программы для отправки накопленных span'ов. Если тип экспортёра
+//none, то трассировка не включается.
+func Setup(serviceName, exporterKind, target string) (func(context.Context) error, error) {
+	exporter, closer, err := newExporter(exporterKind, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+
+		return err
+	}, nil
+}
+
+//newExporter создаёт экспортёр указанного типа. Для типа file также возвращается файл, который нужно закрыть после
+//завершения работы поставщика трассировщиков. Для типа none возвращается nil.
+func newExporter(kind, target string) (export.SpanExporter, io.Closer, error) {
+	switch kind {
+	case ExporterNone, "":
+		return nil, nil, nil
+	case ExporterStdout:
+		exporter, err := stdout.NewExporter(stdout.WithoutMetricExport())
+		return exporter, nil, err
+	case ExporterFile:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := stdout.NewExporter(stdout.WithWriter(file), stdout.WithoutMetricExport())
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+
+		return exporter, file, nil
+	case ExporterOTLP:
+		opts, err := otlpOptions(target)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		exporter, err := otlp.NewExporter(context.Background(), otlphttp.NewDriver(opts...))
+		return exporter, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("unknown trace exporter %q", kind)
+}
+
+//otlpOptions преобразует адрес коллектора вида http://host:port/path в настройки драйвера OTLP/HTTP.
+func otlpOptions(target string) ([]otlphttp.Option, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid otlp collector address %q", target)
+	}
+
+	opts := []otlphttp.Option{otlphttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		opts = append(opts, otlphttp.WithInsecure())
+	}
+	if path := strings.TrimSuffix(u.Path, "/"); path != "" {
+		opts = append(opts, otlphttp.WithTracesURLPath(path+"/v1/traces"))
+	}
+
+	return opts, nil
+}
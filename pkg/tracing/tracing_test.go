@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	export "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+//recorder запоминает экспортированные span'ы
+type recorder struct {
+	mu    sync.Mutex
+	spans map[string]*export.SpanSnapshot
+}
+
+func (r *recorder) ExportSpans(ctx context.Context, spans []*export.SpanSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range spans {
+		r.spans[s.Name] = s
+	}
+
+	return nil
+}
+
+func (r *recorder) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestProvider_Propagation(t *testing.T) {
+	rec := &recorder{spans: make(map[string]*export.SpanSnapshot)}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(rec))
+	tracer := provider.Tracer("test")
+
+	ctx, client := tracer.Start(context.Background(), "client")
+
+	//передаём контекст трассировки так же, как это происходит между клиентом и сервером
+	md, _ := metadata.FromOutgoingContext(Inject(ctx))
+	serverCtx := Extract(metadata.NewIncomingContext(context.Background(), md))
+
+	serverCtx, server := tracer.Start(serverCtx, "server")
+	_, child := tracer.Start(serverCtx, "child")
+	child.End()
+	server.End()
+	client.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.spans
+	if len(spans) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(spans))
+	}
+	for _, name := range []string{"server", "child"} {
+		if spans[name].SpanContext.TraceID != spans["client"].SpanContext.TraceID {
+			t.Errorf("span %q: trace id = %s, want %s", name, spans[name].SpanContext.TraceID, spans["client"].SpanContext.TraceID)
+		}
+	}
+	if got, want := spans["server"].ParentSpanID, spans["client"].SpanContext.SpanID; got != want {
+		t.Errorf("server span parent = %s, want %s", got, want)
+	}
+	if got, want := spans["child"].ParentSpanID, spans["server"].SpanContext.SpanID; got != want {
+		t.Errorf("child span parent = %s, want %s", got, want)
+	}
+	if spans["client"].ParentSpanID.IsValid() {
+		t.Errorf("client span has parent %s", spans["client"].ParentSpanID)
+	}
+}
+
+func TestOTLPOptions(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    int
+		wantErr bool
+	}{
+		{target: "http://localhost:55681", want: 2},
+		{target: "https://collector:55681/otlp/", want: 2},
+		{target: "localhost:55681", wantErr: true},
+		{target: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		opts, err := otlpOptions(tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("otlpOptions(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+		}
+		if len(opts) != tt.want {
+			t.Errorf("otlpOptions(%q) returned %d options, want %d", tt.target, len(opts), tt.want)
+		}
+	}
+}
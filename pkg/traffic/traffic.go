@@ -0,0 +1,187 @@
+//Пакет traffic содержит формат записи вызовов gRPC для последующего воспроизведения. Запись хранится в формате
+//JSON Lines: одна строка на каждый вызов, сообщения запроса и ответа кодируются в JSON по правилам protobuf.
+package traffic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+//maxLineSize - максимальный размер строки записи при чтении
+const maxLineSize = 16 << 20
+
+//Record - записанный вызов
+type Record struct {
+	//Time - момент начала вызова
+	Time time.Time `json:"time"`
+	//Method - полное наименование метода, например "/api.AccountsService/GetAmount"
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Code     string          `json:"code"`
+	Message  string          `json:"message,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+//NewRecord создаёт запись вызова method, начатого в момент t, с запросом req и результатом resp и err.
+func NewRecord(t time.Time, method string, req, resp interface{}, err error) (*Record, error) {
+	st := status.Convert(err)
+	rec := &Record{
+		Time:    t,
+		Method:  method,
+		Code:    st.Code().String(),
+		Message: st.Message(),
+	}
+
+	request, marshalErr := marshal(req)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	rec.Request = request
+
+	if err == nil {
+		response, marshalErr := marshal(resp)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+		rec.Response = response
+	}
+
+	return rec, nil
+}
+
+func marshal(v interface{}) (json.RawMessage, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a protobuf message", v)
+	}
+
+	return protojson.Marshal(msg)
+}
+
+//FindMethod возвращает описание метода method. Описание сервиса должно быть зарегистрировано, то есть пакет
+//со сгенерированным кодом должен быть импортирован. Наименование метода сравнивается без учёта регистра, так как
+//в UnaryServerInfo.FullMethod сгенерированного кода первая буква наименования заглавная, а в описании сервиса и
+//при вызове может быть строчной.
+func FindMethod(method string) (protoreflect.MethodDescriptor, error) {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid method %q", method)
+	}
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %w", parts[0], err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", parts[0])
+	}
+
+	for i := 0; i < sd.Methods().Len(); i++ {
+		if md := sd.Methods().Get(i); strings.EqualFold(string(md.Name()), parts[1]) {
+			return md, nil
+		}
+	}
+
+	return nil, fmt.Errorf("method %s is not found", method)
+}
+
+//NewMessages возвращает пустые сообщения запроса и ответа метода method.
+func NewMessages(method string) (req, resp proto.Message, err error) {
+	md, err := FindMethod(method)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reqType, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+	respType, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reqType.New().Interface(), respType.New().Interface(), nil
+}
+
+//Messages возвращает записанные запрос и ответ. Для неуспешного вызова ответ пустой.
+func (r *Record) Messages() (req, resp proto.Message, err error) {
+	req, resp, err = NewMessages(r.Method)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = protojson.Unmarshal(r.Request, req); err != nil {
+		return nil, nil, fmt.Errorf("request: %w", err)
+	}
+	if len(r.Response) > 0 {
+		if err = protojson.Unmarshal(r.Response, resp); err != nil {
+			return nil, nil, fmt.Errorf("response: %w", err)
+		}
+	}
+
+	return req, resp, nil
+}
+
+//Writer записывает вызовы в w. Методы Writer потокобезопасны.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: w,
+	}
+}
+
+//Write записывает вызов отдельной строкой.
+func (w *Writer) Write(rec *Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.w.Write(line)
+
+	return err
+}
+
+//Read читает все записанные вызовы из r. Пустые строки пропускаются.
+func Read(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var records []Record
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,68 @@
+package traffic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gotest.tools/assert"
+)
+
+func TestFindMethod(t *testing.T) {
+	tests := []struct {
+		method  string
+		wantErr bool
+	}{
+		{method: "/google.protobuf.Struct/Get", wantErr: true},
+		{method: "/unknown.Service/Get", wantErr: true},
+		{method: "invalid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			_, err := FindMethod(tt.method)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	now := time.Now().UTC()
+	rec, err := NewRecord(now, "/test.Service/Get", wrapperspb.Int64(1), wrapperspb.String("one"), nil)
+	assert.NilError(t, err)
+	assert.NilError(t, w.Write(rec))
+
+	rec, err = NewRecord(now, "/test.Service/Get", wrapperspb.Int64(2), nil, status.Error(codes.NotFound, "not found"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Write(rec))
+	buf.WriteString("\n")
+
+	_, err = NewRecord(now, "/test.Service/Get", "not a message", nil, nil)
+	assert.ErrorContains(t, err, "is not a protobuf message")
+
+	records, err := Read(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(records))
+
+	assert.Assert(t, records[0].Time.Equal(now))
+	assert.Equal(t, "OK", records[0].Code)
+	resp := &wrapperspb.StringValue{}
+	assert.NilError(t, protojson.Unmarshal(records[0].Response, resp))
+	assert.Assert(t, proto.Equal(wrapperspb.String("one"), resp))
+
+	assert.Equal(t, "NotFound", records[1].Code)
+	assert.Equal(t, "not found", records[1].Message)
+	assert.Equal(t, 0, len(records[1].Response))
+
+	_, err = Read(strings.NewReader("{}\nnot json\n"))
+	assert.ErrorContains(t, err, "line 2")
+}